@@ -0,0 +1,67 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/BurntSushi/toml"
+
+	"github.com/CoxxA/nomadbank/v2/internal/config"
+)
+
+// configFlags registers the flags that take part in config.Load, which has
+// them override the environment and the config file.
+func configFlags(flags *flag.FlagSet) func() config.Options {
+	var (
+		file    string
+		port    int
+		dataDir string
+	)
+	flags.StringVar(&file, "config", "", "TOML 或 YAML 配置文件")
+	flags.IntVar(&port, "port", 0, "HTTP 端口")
+	flags.StringVar(&dataDir, "data", "", "数据目录")
+	return func() config.Options {
+		overrides := make(map[string]string)
+		if port != 0 {
+			overrides["PORT"] = strconv.Itoa(port)
+		}
+		if dataDir != "" {
+			overrides["DATA_DIR"] = dataDir
+		}
+		return config.Options{File: file, Flags: overrides}
+	}
+}
+
+// runConfig handles `nomadbank config print`, which shows the effective
+// configuration, with secrets redacted, as a config file annotated with
+// where each value came from.
+func runConfig(args []string, output io.Writer) error {
+	if len(args) == 0 || args[0] != "print" {
+		return errors.New("用法: nomadbank config print [--config 文件] [--port 端口] [--data 目录]")
+	}
+	flags := flag.NewFlagSet("config print", flag.ContinueOnError)
+	options := configFlags(flags)
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+	appConfig, err := config.Load(options())
+	if err != nil {
+		return err
+	}
+	table := tabwriter.NewWriter(output, 0, 0, 2, ' ', 0)
+	for _, setting := range appConfig.Settings() {
+		// The TOML encoder escapes the value so the output reads back as a
+		// config file.
+		line, err := toml.Marshal(map[string]string{strings.ToLower(setting.Key): setting.Value})
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(table, "%s\t# %s\n", strings.TrimSuffix(string(line), "\n"), setting.Source)
+	}
+	return table.Flush()
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+)
+
+func TestConfigPrint(t *testing.T) {
+	dir := t.TempDir()
+	secret := filepath.Join(dir, "metrics_token")
+	if err := os.WriteFile(secret, []byte("file-secret-0123456789\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(dir, "nomadbank.toml")
+	if err := os.WriteFile(file, []byte(`data_dir = "C:\\nomad\t\"bank\""
+listen_socket_mode = 0o600
+metrics_token_file = "`+secret+`"
+`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"PORT", "DATA_DIR", "LISTEN_SOCKET_MODE", "METRICS_TOKEN", "METRICS_TOKEN_FILE"} {
+		t.Setenv(key, "")
+	}
+	t.Setenv("SESSION_DAYS", "7")
+
+	var output bytes.Buffer
+	if err := runConfig([]string{"print", "--config", file, "--port", "9000"}, &output); err != nil {
+		t.Fatal(err)
+	}
+	printed := output.String()
+	if strings.Contains(printed, "file-secret") {
+		t.Fatalf("secret leaked:\n%s", printed)
+	}
+	for _, want := range []string{
+		`port = "9000"`, "# 命令行参数",
+		`session_days = "7"`, "# 环境变量",
+		`listen_socket_mode = "0600"`, "# 配置文件",
+		`metrics_token = "******"`,
+		`log_level = "info"`, "# 默认值",
+	} {
+		if !strings.Contains(printed, want) {
+			t.Errorf("output is missing %q:\n%s", want, printed)
+		}
+	}
+
+	// The output reads back as a config file, escapes included.
+	var values map[string]string
+	if _, err := toml.Decode(printed, &values); err != nil {
+		t.Fatalf("output is not TOML: %v\n%s", err, printed)
+	}
+	if values["data_dir"] != "C:\\nomad\t\"bank\"" || values["port"] != "9000" {
+		t.Fatalf("unexpected values read back: %q", values)
+	}
+}
+
+func TestConfigPrintErrors(t *testing.T) {
+	if err := runConfig(nil, &bytes.Buffer{}); err == nil || !strings.Contains(err.Error(), "用法") {
+		t.Fatalf("expected usage error, got %v", err)
+	}
+	file := filepath.Join(t.TempDir(), "nomadbank.toml")
+	if err := os.WriteFile(file, []byte("prot = 8080\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := runConfig([]string{"print", "--config", file}, &bytes.Buffer{}); err == nil || !strings.Contains(err.Error(), "prot") {
+		t.Fatalf("expected unknown key error, got %v", err)
+	}
+}
@@ -5,16 +5,20 @@ import (
 	"errors"
 	"flag"
 	"fmt"
-	"log"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 	_ "time/tzdata"
 
 	"github.com/CoxxA/nomadbank/v2/internal/config"
 	"github.com/CoxxA/nomadbank/v2/internal/httpapi"
+	"github.com/CoxxA/nomadbank/v2/internal/logging"
+	"github.com/CoxxA/nomadbank/v2/internal/notify"
 	"github.com/CoxxA/nomadbank/v2/internal/sqlite"
 	"github.com/CoxxA/nomadbank/v2/web"
 )
@@ -26,19 +30,26 @@ var (
 
 func main() {
 	if err := run(); err != nil {
-		log.Fatal(err)
+		slog.Error("NomadBank 退出", "error", err)
+		os.Exit(1)
 	}
 }
 
 func run() error {
-	var (
-		showVersion bool
-		port        int
-		dataDir     string
-	)
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "simulate":
+			return runSimulate(os.Args[2:], os.Stdout)
+		case "config":
+			return runConfig(os.Args[2:], os.Stdout)
+		case "accounts", "strategies", "tasks", "batches":
+			return runOffline(os.Args[1:], os.Stdout)
+		}
+	}
+
+	var showVersion bool
 	flag.BoolVar(&showVersion, "version", false, "显示版本信息")
-	flag.IntVar(&port, "port", 0, "HTTP 端口")
-	flag.StringVar(&dataDir, "data", "", "数据目录")
+	options := configFlags(flag.CommandLine)
 	flag.Parse()
 
 	if showVersion {
@@ -46,19 +57,15 @@ func run() error {
 		return nil
 	}
 
-	appConfig, err := config.Load()
+	appConfig, err := config.Load(options())
 	if err != nil {
 		return err
 	}
-	if port != 0 {
-		appConfig.Port = port
-	}
-	if dataDir != "" {
-		appConfig.DataDir = dataDir
-	}
-	if err := appConfig.Validate(); err != nil {
+	logger, err := logging.New(os.Stderr, appConfig.LogLevel, appConfig.LogFormat)
+	if err != nil {
 		return err
 	}
+	slog.SetDefault(logger)
 
 	store, err := sqlite.Open(appConfig.DBPath())
 	if err != nil {
@@ -66,18 +73,70 @@ func run() error {
 	}
 	defer func() {
 		if err := store.Close(); err != nil {
-			log.Printf("close database: %v", err)
+			slog.Error("关闭数据库", "error", err)
 		}
 	}()
 
 	server := httpapi.New(appConfig, store)
-	web.RegisterRoutes(server.Echo())
+	if appConfig.APIValidation == config.APIValidationReport || appConfig.APIValidation == config.APIValidationEnforce {
+		if err := server.EnableContractValidation(appConfig.APIValidation == config.APIValidationEnforce); err != nil {
+			return err
+		}
+	}
+	if appConfig.TLSEnabled() {
+		if err := server.EnableTLS(); err != nil {
+			return err
+		}
+	}
+	if appConfig.MetricsEnabled() {
+		server.EnableMetrics(version, commit)
+	}
+	web.RegisterRoutes(server.Echo(), appConfig.BasePath)
+
+	// The scheduler and the webhook dispatcher stop before the deferred
+	// store.Close so that no reminder or event is left half-recorded.
+	workerContext, stopWorkers := context.WithCancel(context.Background())
+	var workers sync.WaitGroup
+	scheduler := notify.NewScheduler(store, version)
+	if appConfig.PublicURL != "" {
+		scheduler.EnableTaskLinks(appConfig.PublicURL)
+	}
+	workers.Go(func() { scheduler.Run(workerContext) })
+	workers.Go(func() { server.Webhooks().Run(workerContext) })
+	defer func() {
+		stopWorkers()
+		workers.Wait()
+	}()
 
-	serverErrors := make(chan error, 1)
+	serverErrors := make(chan error, 3)
 	go func() {
-		log.Printf("NomadBank %s 启动于 http://localhost%s", version, appConfig.Address())
+		slog.Info("NomadBank 已启动", "version", version, "commit", commit, "address", listenURL(appConfig))
 		serverErrors <- server.Start()
 	}()
+	var redirectServer *http.Server
+	if appConfig.HTTPRedirectAddress != "" {
+		redirectServer = &http.Server{
+			Addr:              appConfig.HTTPRedirectAddress,
+			Handler:           server.RedirectHandler(),
+			ReadHeaderTimeout: 5 * time.Second,
+		}
+		go func() {
+			slog.Info("HTTP 请求将重定向到 HTTPS", "address", appConfig.HTTPRedirectAddress)
+			serverErrors <- redirectServer.ListenAndServe()
+		}()
+	}
+	var metricsServer *http.Server
+	if appConfig.MetricsAddress != "" {
+		metricsServer = &http.Server{
+			Addr:              appConfig.MetricsAddress,
+			Handler:           server.MetricsHandler(),
+			ReadHeaderTimeout: 5 * time.Second,
+		}
+		go func() {
+			slog.Info("Prometheus 指标已启用", "address", "http://"+appConfig.MetricsAddress+"/metrics")
+			serverErrors <- metricsServer.ListenAndServe()
+		}()
+	}
 
 	signals := make(chan os.Signal, 1)
 	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
@@ -85,7 +144,7 @@ func run() error {
 
 	select {
 	case received := <-signals:
-		log.Printf("收到信号 %s，正在停止服务", received)
+		slog.Info("正在停止服务", "signal", received.String())
 	case err := <-serverErrors:
 		if !errors.Is(err, http.ErrServerClosed) {
 			return err
@@ -95,5 +154,31 @@ func run() error {
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
+	if metricsServer != nil {
+		if err := metricsServer.Shutdown(ctx); err != nil {
+			slog.Error("停止指标监听", "error", err)
+		}
+	}
+	if redirectServer != nil {
+		if err := redirectServer.Shutdown(ctx); err != nil {
+			slog.Error("停止 HTTP 重定向监听", "error", err)
+		}
+	}
 	return server.Shutdown(ctx)
 }
+
+// listenURL describes where the server listens, for the startup log line.
+func listenURL(appConfig config.Config) string {
+	if path, ok := appConfig.UnixSocket(); ok {
+		return "unix:" + path
+	}
+	scheme := "http"
+	if appConfig.TLSEnabled() {
+		scheme = "https"
+	}
+	host, port, _ := net.SplitHostPort(appConfig.Address())
+	if host == "" {
+		host = "localhost"
+	}
+	return scheme + "://" + net.JoinHostPort(host, port) + appConfig.BasePath + "/"
+}
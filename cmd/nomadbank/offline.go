@@ -0,0 +1,432 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/CoxxA/nomadbank/v2/internal/config"
+	"github.com/CoxxA/nomadbank/v2/internal/domain"
+	"github.com/CoxxA/nomadbank/v2/internal/event"
+	"github.com/CoxxA/nomadbank/v2/internal/logging"
+	"github.com/CoxxA/nomadbank/v2/internal/notify"
+	"github.com/CoxxA/nomadbank/v2/internal/sqlite"
+	"github.com/CoxxA/nomadbank/v2/internal/task"
+)
+
+// dueListLimit caps `tasks due`; the table notes how many were left out.
+const dueListLimit = 500
+
+// offline is an open database for the subcommands that work on it
+// directly, for when an SSH session is quicker than the web app. The
+// server may keep running: writes are audited with the cli actor and queue
+// event webhooks like the API does, and open pages see them on their next
+// refresh.
+type offline struct {
+	store    *sqlite.Store
+	webhooks *notify.Dispatcher
+	config   config.Config
+	owner    domain.Owner
+	location *time.Location
+	json     bool
+	output   io.Writer
+}
+
+// offlineAction runs a subcommand with its positional arguments.
+type offlineAction func(ctx context.Context, o *offline, args []string) error
+
+// offlineCommands register their flags and return the action to run.
+var offlineCommands = map[string]func(flags *flag.FlagSet) offlineAction{
+	"accounts list":    accountsList,
+	"accounts add":     accountsAdd,
+	"accounts disable": accountsDisable,
+	"strategies list":  strategiesList,
+	"tasks due":        tasksDue,
+	"tasks complete":   tasksComplete,
+	"batches generate": batchesGenerate,
+}
+
+// runOffline handles `nomadbank accounts|strategies|tasks|batches ...`.
+func runOffline(args []string, output io.Writer) error {
+	var name string
+	if len(args) > 1 {
+		name = args[0] + " " + args[1]
+	}
+	command, ok := offlineCommands[name]
+	if !ok {
+		var subcommands []string
+		for known := range offlineCommands {
+			if group, subcommand, _ := strings.Cut(known, " "); group == args[0] {
+				subcommands = append(subcommands, subcommand)
+			}
+		}
+		slices.Sort(subcommands)
+		return fmt.Errorf("用法: nomadbank %s %s [选项]", args[0], strings.Join(subcommands, "|"))
+	}
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	options := configFlags(flags)
+	format := flags.String("format", "table", "输出格式：table 或 json")
+	action := command(flags)
+	positional, err := parseInterspersed(flags, args[2:])
+	if err != nil {
+		return err
+	}
+	if *format != "table" && *format != "json" {
+		return errors.New("--format 必须是 table 或 json")
+	}
+
+	appConfig, err := config.Load(options())
+	if err != nil {
+		return err
+	}
+	logger, err := logging.New(os.Stderr, appConfig.LogLevel, appConfig.LogFormat)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(logger)
+	store, err := sqlite.Open(appConfig.DBPath())
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := store.Close(); err != nil {
+			slog.Error("关闭数据库", "error", err)
+		}
+	}()
+
+	ctx := context.Background()
+	credentials, err := store.OwnerCredentials(ctx)
+	if errors.Is(err, sqlite.ErrNotFound) {
+		return errors.New("尚未初始化，请先在网页中创建所有者")
+	}
+	if err != nil {
+		return err
+	}
+	location, err := time.LoadLocation(credentials.Owner.Timezone)
+	if err != nil {
+		return err
+	}
+	return action(ctx, &offline{
+		store:    store,
+		webhooks: notify.NewDispatcher(store),
+		config:   appConfig,
+		owner:    credentials.Owner,
+		location: location,
+		json:     *format == "json",
+		output:   output,
+	}, positional)
+}
+
+// parseInterspersed parses flags that may follow positional arguments, as
+// in `tasks complete 42 --format json`, and returns the positional ones.
+func parseInterspersed(flags *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := flags.Parse(args); err != nil {
+			return nil, err
+		}
+		if flags.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, flags.Arg(0))
+		args = flags.Args()[1:]
+	}
+}
+
+// idArgument returns the single positional ID of commands like
+// `accounts disable ID`.
+func idArgument(args []string, usage string) (int64, error) {
+	if len(args) != 1 {
+		return 0, errors.New("用法: nomadbank " + usage)
+	}
+	id, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("ID 无效: %s", args[0])
+	}
+	return id, nil
+}
+
+func noArguments(args []string, usage string) error {
+	if len(args) > 0 {
+		return errors.New("用法: nomadbank " + usage)
+	}
+	return nil
+}
+
+func accountsList(flags *flag.FlagSet) offlineAction {
+	group := flags.String("group", "", "只列出该分组")
+	activeOnly := flags.Bool("active", false, "只列出启用的账户")
+	return func(ctx context.Context, o *offline, args []string) error {
+		if err := noArguments(args, "accounts list [--group 分组] [--active]"); err != nil {
+			return err
+		}
+		accounts, err := o.store.ListAccounts(ctx, *activeOnly, strings.TrimSpace(*group))
+		if err != nil {
+			return err
+		}
+		return o.print(accounts, func(table io.Writer) {
+			fmt.Fprintln(table, "ID\t名称\t分组\t状态")
+			for _, account := range accounts {
+				fmt.Fprintf(table, "%d\t%s\t%s\t%s\n", account.ID, account.Name, account.GroupName, accountStatus(account))
+			}
+		})
+	}
+}
+
+func accountsAdd(flags *flag.FlagSet) offlineAction {
+	name := flags.String("name", "", "账户名称")
+	group := flags.String("group", "", "分组名称")
+	return func(ctx context.Context, o *offline, args []string) error {
+		if err := noArguments(args, "accounts add --name 名称 [--group 分组]"); err != nil {
+			return err
+		}
+		accountName, groupName, err := domain.NormalizeAccountNames(*name, *group)
+		switch {
+		case errors.Is(err, domain.ErrInvalidAccountName):
+			return fmt.Errorf("--name %w", err)
+		case errors.Is(err, domain.ErrInvalidGroupName):
+			return fmt.Errorf("--group %w", err)
+		}
+		account := domain.Account{Name: accountName, GroupName: groupName, Active: true}
+		err = o.store.WithTx(ctx, func(tx *sqlite.Store) error {
+			if err := tx.CreateAccount(ctx, &account); err != nil {
+				return err
+			}
+			return o.record(ctx, tx, domain.AuditAccountCreate, event.AccountCreated, account.ID, nil, account)
+		})
+		if errors.Is(err, sqlite.ErrConflict) {
+			return fmt.Errorf("账户「%s」已存在", account.Name)
+		}
+		if err != nil {
+			return err
+		}
+		return o.print(account, func(table io.Writer) {
+			fmt.Fprintf(table, "已创建账户 %d\t%s\t%s\n", account.ID, account.Name, account.GroupName)
+		})
+	}
+}
+
+func accountsDisable(*flag.FlagSet) offlineAction {
+	return func(ctx context.Context, o *offline, args []string) error {
+		id, err := idArgument(args, "accounts disable ID")
+		if err != nil {
+			return err
+		}
+		existing, err := o.store.GetAccount(ctx, id)
+		if errors.Is(err, sqlite.ErrNotFound) {
+			return fmt.Errorf("账户 %d 不存在", id)
+		}
+		if err != nil {
+			return err
+		}
+		account := existing
+		if account.Active {
+			account.Active = false
+			err := o.store.WithTx(ctx, func(tx *sqlite.Store) error {
+				if err := tx.UpdateAccount(ctx, &account); err != nil {
+					return err
+				}
+				return o.record(ctx, tx, domain.AuditAccountUpdate, event.AccountUpdated, account.ID, existing, account)
+			})
+			if errors.Is(err, sqlite.ErrStale) {
+				return errors.New("账户刚被其他人修改，请重试")
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return o.print(account, func(table io.Writer) {
+			fmt.Fprintf(table, "账户 %d\t%s\t%s\n", account.ID, account.Name, accountStatus(account))
+		})
+	}
+}
+
+func accountStatus(account domain.Account) string {
+	if account.Active {
+		return "启用"
+	}
+	return "停用"
+}
+
+func strategiesList(*flag.FlagSet) offlineAction {
+	return func(ctx context.Context, o *offline, args []string) error {
+		if err := noArguments(args, "strategies list"); err != nil {
+			return err
+		}
+		strategies, err := o.store.ListStrategies(ctx)
+		if err != nil {
+			return err
+		}
+		return o.print(strategies, func(table io.Writer) {
+			fmt.Fprintln(table, "ID\t名称\t间隔（天）\t时段\t金额\t每日上限")
+			for _, strategy := range strategies {
+				window := formatMinutes(strategy.TimeStartMinutes) + "～" + formatMinutes(strategy.TimeEndMinutes)
+				if strategy.SkipWeekends {
+					window += "（跳过周末）"
+				}
+				fmt.Fprintf(table, "%d\t%s\t%d～%d\t%s\t%s～%s\t%d\n",
+					strategy.ID, strategy.Name, strategy.IntervalMinDays, strategy.IntervalMaxDays, window,
+					formatCents(strategy.AmountMinCents), formatCents(strategy.AmountMaxCents), strategy.DailyLimit)
+			}
+		})
+	}
+}
+
+func formatMinutes(minutes int) string {
+	return fmt.Sprintf("%02d:%02d", minutes/60, minutes%60)
+}
+
+func tasksDue(flags *flag.FlagSet) offlineAction {
+	days := flags.Int("days", 0, "再列出今天之后 N 天内的任务")
+	return func(ctx context.Context, o *offline, args []string) error {
+		if err := noArguments(args, "tasks due [--days N]"); err != nil {
+			return err
+		}
+		if *days < 0 || *days > 366 {
+			return errors.New("--days 必须在 0 到 366 之间")
+		}
+		now := time.Now().In(o.location)
+		page, err := o.store.ListTasks(ctx, sqlite.TaskFilter{
+			Status:          domain.TaskStatusPending,
+			ScheduledBefore: domain.OverdueCutoff(now).AddDate(0, 0, *days+1),
+			Page:            1,
+			PageSize:        dueListLimit,
+		})
+		if err != nil {
+			return err
+		}
+		for index := range page.Items {
+			page.Items[index].MarkOverdue(now)
+		}
+		return o.print(page.Items, func(table io.Writer) {
+			fmt.Fprintln(table, "ID\t计划时间\t转出\t转入\t金额\t逾期")
+			for _, item := range page.Items {
+				overdue := ""
+				if item.Overdue {
+					overdue = fmt.Sprintf("%d 天", item.DaysOverdue)
+				}
+				fmt.Fprintf(table, "%d\t%s\t%s\t%s\t%s\t%s\n", item.ID,
+					item.ScheduledAt.In(o.location).Format("2006-01-02 15:04"),
+					item.FromAccountName, item.ToAccountName, formatCents(item.AmountCents), overdue)
+			}
+			if left := page.Total - int64(len(page.Items)); left > 0 {
+				fmt.Fprintf(table, "另有 %d 个任务未列出\n", left)
+			}
+		})
+	}
+}
+
+func tasksComplete(*flag.FlagSet) offlineAction {
+	return func(ctx context.Context, o *offline, args []string) error {
+		id, err := idArgument(args, "tasks complete ID")
+		if err != nil {
+			return err
+		}
+		var (
+			completed domain.Task
+			changed   bool
+		)
+		err = o.store.WithTx(ctx, func(tx *sqlite.Store) error {
+			var err error
+			completed, changed, err = tx.CompleteTask(ctx, id, time.Now().In(o.location))
+			if err != nil || !changed {
+				return err
+			}
+			before := completed
+			before.Status = domain.TaskStatusPending
+			before.CompletedAt = nil
+			return o.record(ctx, tx, domain.AuditTaskComplete, event.TaskCompleted, completed.ID, before, completed)
+		})
+		if errors.Is(err, sqlite.ErrNotFound) {
+			return fmt.Errorf("任务 %d 不存在", id)
+		}
+		if err != nil {
+			return err
+		}
+		return o.print(completed, func(table io.Writer) {
+			status := "完成于"
+			if !changed {
+				status = "此前已完成，未重复记录，完成于"
+			}
+			fmt.Fprintf(table, "任务 %d\t%s → %s\t%s\t%s %s\n", completed.ID,
+				completed.FromAccountName, completed.ToAccountName, formatCents(completed.AmountCents),
+				status, completed.CompletedAt.In(o.location).Format("2006-01-02 15:04"))
+		})
+	}
+}
+
+func batchesGenerate(flags *flag.FlagSet) offlineAction {
+	strategyID := flags.Int64("strategy", 0, "策略 ID")
+	group := flags.String("group", "", "账户分组")
+	cycles := flags.Int("cycles", 4, "生成周期数")
+	return func(ctx context.Context, o *offline, args []string) error {
+		if err := noArguments(args, "batches generate --strategy ID [--group 分组] [--cycles N]"); err != nil {
+			return err
+		}
+		if *strategyID <= 0 {
+			return errors.New("必须通过 --strategy 指定策略 ID")
+		}
+		var result task.GenerateResult
+		err := o.store.WithTx(ctx, func(tx *sqlite.Store) error {
+			var err error
+			result, err = task.NewService(tx, nil).Generate(ctx, task.GenerateInput{
+				StrategyID: *strategyID,
+				GroupName:  *group,
+				Cycles:     *cycles,
+			})
+			if err != nil {
+				return err
+			}
+			return o.record(ctx, tx, domain.AuditTaskBatchCreate, event.BatchCreated, result.Batch.ID, nil, result.Batch)
+		})
+		if errors.Is(err, sqlite.ErrNotFound) {
+			return fmt.Errorf("策略 %d 不存在", *strategyID)
+		}
+		if err != nil {
+			return err
+		}
+		return o.print(result, func(table io.Writer) {
+			fmt.Fprintf(table, "已生成批次 %d\t策略 %s\t%d 个周期\t%d 个任务\n",
+				result.Batch.ID, result.Batch.StrategyName, result.Batch.CycleCount, result.Tasks)
+		})
+	}
+}
+
+// print writes value as indented JSON, or calls table with a tab-aligned
+// writer.
+func (o *offline) print(value any, table func(io.Writer)) error {
+	if o.json {
+		encoder := json.NewEncoder(o.output)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(value)
+	}
+	writer := tabwriter.NewWriter(o.output, 0, 0, 2, ' ', 0)
+	table(writer)
+	return writer.Flush()
+}
+
+// record audits a change made through tx with the cli actor, like
+// Server.audit, and queues its event webhooks in the same transaction; a
+// running server delivers them on its next tick.
+func (o *offline) record(ctx context.Context, tx *sqlite.Store, action domain.AuditAction, kind event.Type, resourceID int64, before, after any) error {
+	audit := domain.AuditEvent{
+		Action:     action,
+		ResourceID: &resourceID,
+		ActorType:  domain.AuditActorCLI,
+		ActorName:  o.owner.Username,
+	}
+	if err := tx.Audit(ctx, audit, before, after, o.config.AuditRetentionDays); err != nil {
+		return fmt.Errorf("记录审计事件: %w", err)
+	}
+	return o.webhooks.Queue(ctx, tx, kind, resourceID)
+}
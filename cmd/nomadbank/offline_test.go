@@ -0,0 +1,294 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/CoxxA/nomadbank/v2/internal/config"
+	"github.com/CoxxA/nomadbank/v2/internal/domain"
+	"github.com/CoxxA/nomadbank/v2/internal/sqlite"
+)
+
+// openDataDir points DATA_DIR at a new directory and opens its database the
+// way the subcommands will, so tests can prepare and inspect it.
+func openDataDir(t *testing.T) *sqlite.Store {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("DATA_DIR", dir)
+	store, err := sqlite.Open(filepath.Join(dir, filepath.Base(config.Config{}.DBPath())))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := store.Close(); err != nil {
+			t.Errorf("close database: %v", err)
+		}
+	})
+	return store
+}
+
+func createOwner(t *testing.T, store *sqlite.Store) {
+	t.Helper()
+	if err := store.CreateOwner(context.Background(), domain.Owner{Username: "owner", Timezone: "UTC"}, "password-hash"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func createWebhook(t *testing.T, store *sqlite.Store, events ...domain.WebhookEvent) domain.Webhook {
+	t.Helper()
+	webhook := domain.Webhook{Name: "接收端", URL: "https://example.com/hook", Secret: "secret", Events: events, Active: true}
+	if err := store.CreateWebhook(context.Background(), &webhook); err != nil {
+		t.Fatal(err)
+	}
+	return webhook
+}
+
+func runCommand(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	var output bytes.Buffer
+	err := runOffline(args, &output)
+	return output.String(), err
+}
+
+func auditEvents(t *testing.T, store *sqlite.Store, action domain.AuditAction) []domain.AuditEvent {
+	t.Helper()
+	page, err := store.ListAuditEvents(context.Background(), sqlite.AuditFilter{Action: action, Page: 1, PageSize: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return page.Items
+}
+
+func deliveries(t *testing.T, store *sqlite.Store, webhook domain.Webhook) []domain.WebhookDelivery {
+	t.Helper()
+	queued, err := store.ListWebhookDeliveries(context.Background(), webhook.ID, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return queued
+}
+
+func TestParseInterspersed(t *testing.T) {
+	for _, test := range []struct {
+		args       []string
+		positional []string
+		format     string
+	}{
+		{args: nil, format: "table"},
+		{args: []string{"42", "--format", "json"}, positional: []string{"42"}, format: "json"},
+		{args: []string{"--format=json", "1", "2"}, positional: []string{"1", "2"}, format: "json"},
+		{args: []string{"1", "--", "--format"}, positional: []string{"1", "--format"}, format: "table"},
+	} {
+		flags := flag.NewFlagSet("test", flag.ContinueOnError)
+		format := flags.String("format", "table", "")
+		positional, err := parseInterspersed(flags, test.args)
+		if err != nil || !slices.Equal(positional, test.positional) || *format != test.format {
+			t.Errorf("parseInterspersed(%q) = %q, format %q, %v", test.args, positional, *format, err)
+		}
+	}
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags.SetOutput(&bytes.Buffer{})
+	if _, err := parseInterspersed(flags, []string{"1", "--unknown"}); err == nil {
+		t.Fatal("expected an unknown flag after an argument to be rejected")
+	}
+}
+
+func TestRunOfflineDispatch(t *testing.T) {
+	store := openDataDir(t)
+	for _, test := range []struct {
+		args []string
+		want string
+	}{
+		{args: []string{"accounts", "remove"}, want: "用法: nomadbank accounts add|disable|list"},
+		{args: []string{"tasks"}, want: "用法: nomadbank tasks complete|due"},
+		{args: []string{"accounts", "list", "--format", "xml"}, want: "--format 必须是 table 或 json"},
+		{args: []string{"accounts", "list"}, want: "尚未初始化"},
+	} {
+		if _, err := runCommand(t, test.args...); err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("%q: error = %v, want %q", test.args, err, test.want)
+		}
+	}
+
+	createOwner(t, store)
+	for _, test := range []struct {
+		args []string
+		want string
+	}{
+		{args: []string{"accounts", "list", "extra"}, want: "用法: nomadbank accounts list"},
+		{args: []string{"accounts", "disable"}, want: "用法: nomadbank accounts disable ID"},
+		{args: []string{"tasks", "complete", "abc"}, want: "ID 无效: abc"},
+		{args: []string{"tasks", "due", "--days", "400"}, want: "--days 必须在 0 到 366 之间"},
+		{args: []string{"batches", "generate"}, want: "必须通过 --strategy 指定策略 ID"},
+		{args: []string{"batches", "generate", "--strategy", "99"}, want: "策略 99 不存在"},
+	} {
+		if _, err := runCommand(t, test.args...); err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("%q: error = %v, want %q", test.args, err, test.want)
+		}
+	}
+}
+
+func TestOfflineAccountCommands(t *testing.T) {
+	store := openDataDir(t)
+	createOwner(t, store)
+	webhook := createWebhook(t, store, domain.WebhookAccountUpdated)
+
+	output, err := runCommand(t, "accounts", "add", "--name", " 工资卡 ", "--group", "主账户")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(output, "已创建账户 1") || !strings.Contains(output, "工资卡") {
+		t.Fatalf("unexpected add output: %q", output)
+	}
+	for _, test := range []struct {
+		args []string
+		want string
+	}{
+		{args: []string{"accounts", "add", "--name", "工资卡"}, want: "账户「工资卡」已存在"},
+		{args: []string{"accounts", "add", "--name", " "}, want: "--name " + domain.ErrInvalidAccountName.Error()},
+		{args: []string{"accounts", "add", "--name", "储蓄卡", "--group", strings.Repeat("组", 51)}, want: "--group " + domain.ErrInvalidGroupName.Error()},
+		{args: []string{"accounts", "disable", "99"}, want: "账户 99 不存在"},
+	} {
+		if _, err := runCommand(t, test.args...); err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("%q: error = %v, want %q", test.args, err, test.want)
+		}
+	}
+
+	// Flags may follow the ID.
+	output, err = runCommand(t, "accounts", "disable", "1", "--format", "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var disabled domain.Account
+	if err := json.Unmarshal([]byte(output), &disabled); err != nil {
+		t.Fatalf("expected JSON output: %v %q", err, output)
+	}
+	if disabled.ID != 1 || disabled.Active {
+		t.Fatalf("unexpected disabled account: %+v", disabled)
+	}
+	// Disabling again changes nothing and records nothing.
+	if _, err := runCommand(t, "accounts", "disable", "1"); err != nil {
+		t.Fatal(err)
+	}
+
+	output, err = runCommand(t, "accounts", "list")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "ID") || !strings.Contains(lines[1], "工资卡") || !strings.Contains(lines[1], "停用") {
+		t.Fatalf("unexpected table: %q", output)
+	}
+	output, err = runCommand(t, "accounts", "list", "--active", "--format", "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var active []domain.Account
+	if err := json.Unmarshal([]byte(output), &active); err != nil || len(active) != 0 {
+		t.Fatalf("expected no active accounts: %v %q", err, output)
+	}
+
+	created := auditEvents(t, store, domain.AuditAccountCreate)
+	updated := auditEvents(t, store, domain.AuditAccountUpdate)
+	if len(created) != 1 || len(updated) != 1 {
+		t.Fatalf("expected one create and one update event, got %d and %d", len(created), len(updated))
+	}
+	if event := updated[0]; event.ActorType != domain.AuditActorCLI || event.ActorName != "owner" ||
+		event.ResourceID == nil || *event.ResourceID != 1 || !bytes.Contains(event.Before, []byte(`"active":true`)) ||
+		!bytes.Contains(event.After, []byte(`"active":false`)) {
+		t.Fatalf("unexpected audit event: %+v", event)
+	}
+	queued := deliveries(t, store, webhook)
+	if len(queued) != 1 || queued[0].Event != domain.WebhookAccountUpdated || queued[0].Status != domain.DeliveryPending {
+		t.Fatalf("expected one queued account.updated delivery: %+v", queued)
+	}
+}
+
+func TestOfflineTaskCommands(t *testing.T) {
+	store := openDataDir(t)
+	createOwner(t, store)
+	ctx := context.Background()
+	for _, name := range []string{"账户 A", "账户 B"} {
+		if err := store.CreateAccount(ctx, &domain.Account{Name: name, GroupName: "主账户", Active: true}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	strategy := domain.Strategy{
+		Name: "保活", IntervalMinDays: 1, IntervalMaxDays: 2, TimeStartMinutes: 0, TimeEndMinutes: 24*60 - 1,
+		AmountMinCents: 1000, AmountMaxCents: 2000, DailyLimit: 10,
+	}
+	if err := store.CreateStrategy(ctx, &strategy); err != nil {
+		t.Fatal(err)
+	}
+	webhook := createWebhook(t, store, domain.WebhookTaskCompleted, domain.WebhookBatchCreated)
+
+	output, err := runCommand(t, "batches", "generate", "--strategy", strconv.FormatInt(strategy.ID, 10), "--group", "主账户", "--cycles", "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(output, "已生成批次 1") || !strings.Contains(output, "策略 保活") {
+		t.Fatalf("unexpected generate output: %q", output)
+	}
+
+	output, err = runCommand(t, "tasks", "due", "--days", "3", "--format", "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var due []domain.Task
+	if err := json.Unmarshal([]byte(output), &due); err != nil {
+		t.Fatalf("expected JSON output: %v %q", err, output)
+	}
+	if len(due) == 0 {
+		t.Fatal("expected generated tasks to be due within three days")
+	}
+	id := strconv.FormatInt(due[0].ID, 10)
+
+	output, err = runCommand(t, "tasks", "complete", id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(output, "任务 "+id) || !strings.Contains(output, "完成于") || strings.Contains(output, "此前已完成") {
+		t.Fatalf("unexpected complete output: %q", output)
+	}
+	output, err = runCommand(t, "tasks", "complete", id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(output, "此前已完成，未重复记录") {
+		t.Fatalf("expected a repeated completion to say so: %q", output)
+	}
+	if _, err := runCommand(t, "tasks", "complete", "9999"); err == nil || !strings.Contains(err.Error(), "任务 9999 不存在") {
+		t.Fatalf("unexpected error for a missing task: %v", err)
+	}
+
+	output, err = runCommand(t, "tasks", "due", "--days", "3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(output), "\n"); len(lines) != len(due) || strings.Contains(output, "\n"+id+" ") {
+		t.Fatalf("expected the completed task to leave the table: %q", output)
+	}
+
+	completed := auditEvents(t, store, domain.AuditTaskComplete)
+	if len(completed) != 1 || completed[0].ActorType != domain.AuditActorCLI ||
+		!bytes.Contains(completed[0].Before, []byte(`"status":"pending"`)) {
+		t.Fatalf("expected one audited completion: %+v", completed)
+	}
+	if batches := auditEvents(t, store, domain.AuditTaskBatchCreate); len(batches) != 1 {
+		t.Fatalf("expected one audited batch, got %d", len(batches))
+	}
+	var kinds []domain.WebhookEvent
+	for _, delivery := range deliveries(t, store, webhook) {
+		kinds = append(kinds, delivery.Event)
+	}
+	slices.Sort(kinds)
+	if !slices.Equal(kinds, []domain.WebhookEvent{domain.WebhookBatchCreated, domain.WebhookTaskCompleted}) {
+		t.Fatalf("unexpected queued deliveries: %v", kinds)
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/CoxxA/nomadbank/v2/internal/domain"
+	"github.com/CoxxA/nomadbank/v2/internal/task"
+)
+
+// runSimulate plans a schedule for synthetic accounts without opening the
+// database, so strategies can be tuned before touching a real instance.
+func runSimulate(args []string, output io.Writer) error {
+	flags := flag.NewFlagSet("simulate", flag.ContinueOnError)
+	var (
+		accountCount int
+		strategyFile string
+		cycles       int
+		seed         int64
+		timezone     string
+		start        string
+		buckets      int
+	)
+	flags.IntVar(&accountCount, "accounts", 3, "模拟账户数量")
+	flags.StringVar(&strategyFile, "strategy", "", "策略 JSON 文件，字段与 API 的 StrategyInput 相同")
+	flags.IntVar(&cycles, "cycles", 4, "生成周期数")
+	flags.Int64Var(&seed, "seed", 0, "随机种子；0 表示随机选择并打印")
+	flags.StringVar(&timezone, "timezone", "Asia/Shanghai", "所有者 IANA 时区")
+	flags.StringVar(&start, "start", "", "模拟的当前日期 YYYY-MM-DD，默认今天")
+	flags.IntVar(&buckets, "buckets", 10, "金额直方图分组数")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if accountCount < 2 || accountCount > 1000 {
+		return errors.New("--accounts 必须在 2 到 1000 之间")
+	}
+	if cycles < 1 || cycles > 24 {
+		return task.ErrInvalidCycles
+	}
+	if buckets < 1 || buckets > 100 {
+		return errors.New("--buckets 必须在 1 到 100 之间")
+	}
+	if strategyFile == "" {
+		return errors.New("必须通过 --strategy 指定策略文件")
+	}
+	strategy, err := loadStrategyFile(strategyFile)
+	if err != nil {
+		return err
+	}
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return fmt.Errorf("时区无效: %w", err)
+	}
+	now := time.Now().In(location)
+	if start != "" {
+		now, err = time.ParseInLocation("2006-01-02", start, location)
+		if err != nil {
+			return fmt.Errorf("--start 必须是 YYYY-MM-DD 日期: %w", err)
+		}
+	}
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+
+	accounts := make([]domain.Account, accountCount)
+	names := make(map[int64]string, accountCount)
+	for index := range accounts {
+		accounts[index] = domain.Account{ID: int64(index + 1), Name: fmt.Sprintf("账户 %d", index+1), Active: true}
+		names[accounts[index].ID] = accounts[index].Name
+	}
+	planner := task.NewPlanner(rand.New(rand.NewSource(seed)))
+	drafts := planner.Plan(task.PlanInput{
+		Accounts: accounts,
+		Strategy: strategy,
+		Cycles:   cycles,
+		Now:      now,
+	})
+	report := task.Analyze(strategy, drafts, buckets)
+	return printSimulation(output, seed, strategy, drafts, names, report)
+}
+
+func loadStrategyFile(path string) (domain.Strategy, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return domain.Strategy{}, fmt.Errorf("读取策略文件: %w", err)
+	}
+	var strategy domain.Strategy
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&strategy); err != nil {
+		return domain.Strategy{}, fmt.Errorf("解析策略文件: %w", err)
+	}
+	if strings.TrimSpace(strategy.Name) == "" {
+		strategy.Name = "模拟策略"
+	}
+	strategy, err = domain.NormalizeStrategy(strategy)
+	if err != nil {
+		return domain.Strategy{}, fmt.Errorf("策略文件: %w", err)
+	}
+	return strategy, nil
+}
+
+func printSimulation(
+	output io.Writer,
+	seed int64,
+	strategy domain.Strategy,
+	drafts []domain.TaskDraft,
+	names map[int64]string,
+	report task.Report,
+) error {
+	var text strings.Builder
+	fmt.Fprintf(&text, "策略 %s，随机种子 %d，共 %d 个任务\n\n", strategy.Name, seed, report.Tasks)
+
+	fmt.Fprintln(&text, "排期")
+	fmt.Fprintln(&text, "周期\t时间\t转出\t转入\t金额")
+	for _, draft := range drafts {
+		fmt.Fprintf(
+			&text,
+			"%d\t%s\t%s\t%s\t%s\n",
+			draft.CycleNo,
+			draft.ScheduledAt.Format("2006-01-02 Mon 15:04"),
+			names[draft.FromAccountID],
+			names[draft.ToAccountID],
+			formatCents(draft.AmountCents),
+		)
+	}
+
+	fmt.Fprintln(&text, "\n每日任务数")
+	for _, day := range report.TasksPerDay {
+		fmt.Fprintf(&text, "%s\t%d\n", day.Date, day.Count)
+	}
+
+	fmt.Fprintln(&text, "\n账户间隔（天）")
+	fmt.Fprintln(&text, "账户\t任务\t最短\t最长\t平均")
+	for _, gap := range report.AccountGaps {
+		fmt.Fprintf(
+			&text,
+			"%s\t%d\t%d\t%d\t%.1f\n",
+			names[gap.AccountID],
+			gap.Tasks,
+			gap.MinDays,
+			gap.MaxDays,
+			gap.AverageDays,
+		)
+	}
+
+	fmt.Fprintln(&text, "\n金额分布")
+	for _, bucket := range report.AmountHistogram {
+		fmt.Fprintf(
+			&text,
+			"%s～%s\t%d\t%s\n",
+			formatCents(bucket.MinCents),
+			formatCents(bucket.MaxCents),
+			bucket.Count,
+			strings.Repeat("#", bucket.Count),
+		)
+	}
+
+	fmt.Fprintf(&text, "\n约束违规：%d\n", len(report.Violations))
+	for _, violation := range report.Violations {
+		fmt.Fprintf(&text, "%s\t%s\t%s\n", violation.Rule, violation.Date, violation.Detail)
+	}
+
+	writer := tabwriter.NewWriter(output, 0, 0, 2, ' ', 0)
+	if _, err := io.WriteString(writer, text.String()); err != nil {
+		return err
+	}
+	return writer.Flush()
+}
+
+func formatCents(cents int64) string {
+	return fmt.Sprintf("%d.%02d", cents/100, cents%100)
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/CoxxA/nomadbank/v2/internal/domain"
+	"github.com/CoxxA/nomadbank/v2/internal/task"
+)
+
+const simulateStrategy = `{
+	"name": "默认策略",
+	"interval_min_days": 2,
+	"interval_max_days": 5,
+	"time_start_minutes": 540,
+	"time_end_minutes": 1080,
+	"skip_weekends": true,
+	"amount_min_cents": 1000,
+	"amount_max_cents": 5000,
+	"daily_limit": 3
+}`
+
+func writeStrategyFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "strategy.json")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func simulate(args ...string) (string, error) {
+	var output bytes.Buffer
+	err := runSimulate(args, &output)
+	return output.String(), err
+}
+
+func TestRunSimulate(t *testing.T) {
+	path := writeStrategyFile(t, simulateStrategy)
+	args := []string{"--strategy", path, "--accounts", "4", "--cycles", "3", "--seed", "42", "--timezone", "UTC", "--start", "2026-03-02"}
+	output, err := simulate(args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"策略 默认策略，随机种子 42", "排期", "每日任务数", "账户间隔（天）", "金额分布", "约束违规：0", "账户 4"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output is missing %q:\n%s", want, output)
+		}
+	}
+	again, err := simulate(args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again != output {
+		t.Fatalf("the same seed planned different schedules:\n%s\n%s", output, again)
+	}
+}
+
+func TestRunSimulateDefaultsStrategyName(t *testing.T) {
+	path := writeStrategyFile(t, strings.Replace(simulateStrategy, `"name": "默认策略",`, "", 1))
+	output, err := simulate("--strategy", path, "--seed", "1", "--timezone", "UTC", "--start", "2026-03-02")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(output, "策略 模拟策略，") {
+		t.Fatalf("unexpected header:\n%s", output)
+	}
+}
+
+func TestRunSimulateValidation(t *testing.T) {
+	valid := writeStrategyFile(t, simulateStrategy)
+	for _, test := range []struct {
+		name    string
+		args    []string
+		want    error
+		message string
+	}{
+		{name: "too few accounts", args: []string{"--strategy", valid, "--accounts", "1"}, message: "--accounts 必须在 2 到 1000 之间"},
+		{name: "too many accounts", args: []string{"--strategy", valid, "--accounts", "1001"}, message: "--accounts 必须在 2 到 1000 之间"},
+		{name: "no cycles", args: []string{"--strategy", valid, "--cycles", "0"}, want: task.ErrInvalidCycles},
+		{name: "no buckets", args: []string{"--strategy", valid, "--buckets", "0"}, message: "--buckets 必须在 1 到 100 之间"},
+		{name: "too many buckets", args: []string{"--strategy", valid, "--buckets", "101"}, message: "--buckets 必须在 1 到 100 之间"},
+		{name: "no strategy", args: nil, message: "必须通过 --strategy 指定策略文件"},
+		{name: "missing file", args: []string{"--strategy", filepath.Join(t.TempDir(), "missing.json")}, message: "读取策略文件"},
+		{name: "unknown field", args: []string{"--strategy", writeStrategyFile(t, `{"name": "策略", "extra": 1}`)}, message: "解析策略文件"},
+		{name: "long name", args: []string{"--strategy", writeStrategyFile(t, strings.Replace(simulateStrategy, "默认策略", strings.Repeat("策", domain.MaxStrategyNameRunes+1), 1))}, want: domain.ErrInvalidStrategyName},
+		{name: "inverted interval", args: []string{"--strategy", writeStrategyFile(t, strings.Replace(simulateStrategy, `"interval_max_days": 5`, `"interval_max_days": 1`, 1))}, want: domain.ErrInvalidInterval},
+		{name: "zero daily limit", args: []string{"--strategy", writeStrategyFile(t, strings.Replace(simulateStrategy, `"daily_limit": 3`, `"daily_limit": 0`, 1))}, want: domain.ErrInvalidDailyLimit},
+		{name: "bad timezone", args: []string{"--strategy", valid, "--timezone", "Mars/Base"}, message: "时区无效"},
+		{name: "bad start", args: []string{"--strategy", valid, "--start", "03/02/2026"}, message: "--start 必须是 YYYY-MM-DD 日期"},
+	} {
+		_, err := simulate(test.args...)
+		switch {
+		case err == nil:
+			t.Errorf("%s: expected an error", test.name)
+		case test.want != nil && !errors.Is(err, test.want):
+			t.Errorf("%s: got %v, want %v", test.name, err, test.want)
+		case test.message != "" && !strings.Contains(err.Error(), test.message):
+			t.Errorf("%s: got %v, want it to mention %q", test.name, err, test.message)
+		}
+	}
+}
@@ -0,0 +1,8 @@
+// Package api embeds the OpenAPI contract so the server and its tests can
+// validate HTTP traffic against the same document the frontend types use.
+package api
+
+import _ "embed"
+
+//go:embed openapi.yaml
+var OpenAPI []byte
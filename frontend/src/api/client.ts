@@ -14,12 +14,28 @@ interface ErrorBody {
   message?: string
 }
 
+// 部署在子路径（BASE_PATH）时，服务端把 index.html 的 <base> 改写为该路径，例如 /nomadbank/。
+export const basePath = new URL(document.baseURI).pathname.replace(/\/$/, '')
+
+const safeMethods = new Set(['GET', 'HEAD', 'OPTIONS'])
+
+// 服务端在登录时下发可读的 CSRF Cookie，写请求需要把它放回请求头。
+const csrfHeaders = (method: string | undefined): Record<string, string> => {
+  if (safeMethods.has((method ?? 'GET').toUpperCase())) return {}
+  const token = document.cookie
+    .split('; ')
+    .find((cookie) => cookie.startsWith('nomadbank_csrf='))
+    ?.slice('nomadbank_csrf='.length)
+  return token ? { 'X-CSRF-Token': token } : {}
+}
+
 export const request = async <T>(path: string, options: RequestInit = {}): Promise<T> => {
-  const response = await fetch(path, {
+  const response = await fetch(basePath + path, {
     ...options,
     credentials: 'include',
     headers: {
       ...(options.body ? { 'Content-Type': 'application/json' } : {}),
+      ...csrfHeaders(options.method),
       ...options.headers,
     },
   })
@@ -45,3 +61,9 @@ export const request = async <T>(path: string, options: RequestInit = {}): Promi
 }
 
 export const jsonBody = (value: unknown): string => JSON.stringify(value)
+
+// 服务端 ETag 由记录的 version 生成，写操作带上它即可在记录被其他页面修改时得到 412。
+export const ifMatch = (version: number): Record<string, string> => ({ 'If-Match': `"${version}"` })
+
+export const isStale = (error: Error): boolean =>
+  error instanceof ApiError && error.status === 412
@@ -77,6 +77,137 @@ export interface paths {
         patch?: never;
         trace?: never;
     };
+    "/metrics": {
+        parameters: {
+            query?: never;
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        /**
+         * Prometheus 指标
+         * @description 仅在设置 `METRICS_TOKEN` 或 `METRICS_ADDRESS` 后启用。设置 `METRICS_ADDRESS` 时只在该独立地址提供，主端口返回 404；设置 `METRICS_TOKEN` 时需要 `Authorization: Bearer <METRICS_TOKEN>`。
+         */
+        get: {
+            parameters: {
+                query?: never;
+                header?: never;
+                path?: never;
+                cookie?: never;
+            };
+            requestBody?: never;
+            responses: {
+                /** @description Prometheus 文本格式 */
+                200: {
+                    headers: {
+                        [name: string]: unknown;
+                    };
+                    content: {
+                        "text/plain": string;
+                    };
+                };
+                401: components["responses"]["Error"];
+                404: components["responses"]["Error"];
+            };
+        };
+        put?: never;
+        post?: never;
+        delete?: never;
+        options?: never;
+        head?: never;
+        patch?: never;
+        trace?: never;
+    };
+    "/t/{token}": {
+        parameters: {
+            query?: never;
+            header?: never;
+            path: {
+                /** @description 完成链接中的签名令牌 */
+                token: string;
+            };
+            cookie?: never;
+        };
+        /**
+         * 一键完成链接的确认页
+         * @description 无需登录。只显示任务和确认按钮，不会修改任务，避免聊天软件或邮件扫描预览链接时误完成。
+         */
+        get: {
+            parameters: {
+                query?: never;
+                header?: never;
+                path: {
+                    /** @description 完成链接中的签名令牌 */
+                    token: string;
+                };
+                cookie?: never;
+            };
+            requestBody?: never;
+            responses: {
+                /** @description 确认页；任务已完成时显示完成时间 */
+                200: {
+                    headers: {
+                        [name: string]: unknown;
+                    };
+                    content: {
+                        "text/html": string;
+                    };
+                };
+                /** @description 链接无效、已使用、已过期或任务已删除 */
+                404: {
+                    headers: {
+                        [name: string]: unknown;
+                    };
+                    content: {
+                        "text/html": string;
+                    };
+                };
+            };
+        };
+        put?: never;
+        /**
+         * 通过一键完成链接完成任务
+         * @description 无需登录，须为同源表单提交。链接只能使用一次，修改密码后全部失效。 审计日志的操作者类型为 `task_link`。
+         */
+        post: {
+            parameters: {
+                query?: never;
+                header?: never;
+                path: {
+                    /** @description 完成链接中的签名令牌 */
+                    token: string;
+                };
+                cookie?: never;
+            };
+            requestBody?: never;
+            responses: {
+                /** @description 已完成的结果页 */
+                200: {
+                    headers: {
+                        [name: string]: unknown;
+                    };
+                    content: {
+                        "text/html": string;
+                    };
+                };
+                403: components["responses"]["Error"];
+                /** @description 链接无效、已使用、已过期或任务已删除 */
+                404: {
+                    headers: {
+                        [name: string]: unknown;
+                    };
+                    content: {
+                        "text/html": string;
+                    };
+                };
+            };
+        };
+        delete?: never;
+        options?: never;
+        head?: never;
+        patch?: never;
+        trace?: never;
+    };
     "/setup": {
         parameters: {
             query?: never;
@@ -133,6 +264,7 @@ export interface paths {
                     };
                 };
                 400: components["responses"]["Error"];
+                403: components["responses"]["Error"];
                 409: components["responses"]["Error"];
                 413: components["responses"]["Error"];
                 429: components["responses"]["Error"];
@@ -167,6 +299,8 @@ export interface paths {
                         username: string;
                         /** Format: password */
                         password: string;
+                        /** @description 开启两步验证后必填：认证器中的 6 位验证码或一次性恢复码 */
+                        code?: string;
                     };
                 };
             };
@@ -182,9 +316,28 @@ export interface paths {
                     };
                 };
                 400: components["responses"]["Error"];
-                401: components["responses"]["Error"];
+                /** @description 用户名或密码错误（`invalid_credentials`）；密码正确但缺少或填错两步验证码时分别为 `totp_required` 和 `invalid_totp` */
+                401: {
+                    headers: {
+                        [name: string]: unknown;
+                    };
+                    content: {
+                        "application/json": components["schemas"]["Error"];
+                    };
+                };
+                403: components["responses"]["Error"];
                 413: components["responses"]["Error"];
-                429: components["responses"]["Error"];
+                /** @description 请求过于频繁（`rate_limited`），或连续登录失败后需要等待（`login_throttled`）、已临时锁定（`login_locked`） */
+                429: {
+                    headers: {
+                        /** @description 登录失败限制解除前的秒数 */
+                        "Retry-After"?: number;
+                        [name: string]: unknown;
+                    };
+                    content: {
+                        "application/json": components["schemas"]["Error"];
+                    };
+                };
             };
         };
         /** 退出当前会话 */
@@ -206,6 +359,7 @@ export interface paths {
                     content?: never;
                 };
                 401: components["responses"]["Error"];
+                403: components["responses"]["Error"];
             };
         };
         options?: never;
@@ -272,6 +426,7 @@ export interface paths {
                 };
                 400: components["responses"]["Error"];
                 401: components["responses"]["Error"];
+                403: components["responses"]["Error"];
                 413: components["responses"]["Error"];
             };
         };
@@ -312,21 +467,906 @@ export interface paths {
                 };
             };
             responses: {
-                /** @description 密码已更新 */
+                /** @description 密码已更新 */
+                204: {
+                    headers: {
+                        "Set-Cookie"?: string;
+                        [name: string]: unknown;
+                    };
+                    content?: never;
+                };
+                400: components["responses"]["Error"];
+                401: components["responses"]["Error"];
+                403: components["responses"]["Error"];
+                413: components["responses"]["Error"];
+            };
+        };
+        post?: never;
+        delete?: never;
+        options?: never;
+        head?: never;
+        patch?: never;
+        trace?: never;
+    };
+    "/me/totp": {
+        parameters: {
+            query?: never;
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        /** 两步验证状态 */
+        get: {
+            parameters: {
+                query?: never;
+                header?: never;
+                path?: never;
+                cookie?: never;
+            };
+            requestBody?: never;
+            responses: {
+                /** @description 当前状态 */
+                200: {
+                    headers: {
+                        [name: string]: unknown;
+                    };
+                    content: {
+                        "application/json": components["schemas"]["TOTPStatus"];
+                    };
+                };
+                401: components["responses"]["Error"];
+                403: components["responses"]["Error"];
+            };
+        };
+        put?: never;
+        post?: never;
+        delete?: never;
+        options?: never;
+        head?: never;
+        patch?: never;
+        trace?: never;
+    };
+    "/me/totp/enrollment": {
+        parameters: {
+            query?: never;
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        get?: never;
+        put?: never;
+        /** 开始设置两步验证 */
+        post: {
+            parameters: {
+                query?: never;
+                header?: never;
+                path?: never;
+                cookie?: never;
+            };
+            requestBody: {
+                content: {
+                    "application/json": components["schemas"]["PasswordConfirmation"];
+                };
+            };
+            responses: {
+                /** @description 新的待确认密钥；确认前不会影响登录 */
+                200: {
+                    headers: {
+                        [name: string]: unknown;
+                    };
+                    content: {
+                        "application/json": components["schemas"]["TOTPEnrollment"];
+                    };
+                };
+                400: components["responses"]["Error"];
+                401: components["responses"]["Error"];
+                403: components["responses"]["Error"];
+                409: components["responses"]["Error"];
+                413: components["responses"]["Error"];
+            };
+        };
+        delete?: never;
+        options?: never;
+        head?: never;
+        patch?: never;
+        trace?: never;
+    };
+    "/me/totp/confirm": {
+        parameters: {
+            query?: never;
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        get?: never;
+        put?: never;
+        /** 用验证码确认并开启两步验证 */
+        post: {
+            parameters: {
+                query?: never;
+                header?: never;
+                path?: never;
+                cookie?: never;
+            };
+            requestBody: {
+                content: {
+                    "application/json": components["schemas"]["TOTPCode"];
+                };
+            };
+            responses: {
+                /** @description 已开启；恢复码只在此响应中返回一次 */
+                200: {
+                    headers: {
+                        [name: string]: unknown;
+                    };
+                    content: {
+                        "application/json": components["schemas"]["RecoveryCodes"];
+                    };
+                };
+                400: components["responses"]["Error"];
+                401: components["responses"]["Error"];
+                403: components["responses"]["Error"];
+                409: components["responses"]["Error"];
+                413: components["responses"]["Error"];
+            };
+        };
+        delete?: never;
+        options?: never;
+        head?: never;
+        patch?: never;
+        trace?: never;
+    };
+    "/me/totp/disable": {
+        parameters: {
+            query?: never;
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        get?: never;
+        put?: never;
+        /** 关闭两步验证 */
+        post: {
+            parameters: {
+                query?: never;
+                header?: never;
+                path?: never;
+                cookie?: never;
+            };
+            requestBody: {
+                content: {
+                    "application/json": components["schemas"]["PasswordConfirmation"];
+                };
+            };
+            responses: {
+                /** @description 已关闭，恢复码一并作废 */
+                204: {
+                    headers: {
+                        [name: string]: unknown;
+                    };
+                    content?: never;
+                };
+                400: components["responses"]["Error"];
+                401: components["responses"]["Error"];
+                403: components["responses"]["Error"];
+                409: components["responses"]["Error"];
+                413: components["responses"]["Error"];
+            };
+        };
+        delete?: never;
+        options?: never;
+        head?: never;
+        patch?: never;
+        trace?: never;
+    };
+    "/me/totp/recovery-codes": {
+        parameters: {
+            query?: never;
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        get?: never;
+        put?: never;
+        /** 重新生成恢复码 */
+        post: {
+            parameters: {
+                query?: never;
+                header?: never;
+                path?: never;
+                cookie?: never;
+            };
+            requestBody: {
+                content: {
+                    "application/json": components["schemas"]["PasswordConfirmation"];
+                };
+            };
+            responses: {
+                /** @description 新的恢复码，旧恢复码全部作废 */
+                200: {
+                    headers: {
+                        [name: string]: unknown;
+                    };
+                    content: {
+                        "application/json": components["schemas"]["RecoveryCodes"];
+                    };
+                };
+                400: components["responses"]["Error"];
+                401: components["responses"]["Error"];
+                403: components["responses"]["Error"];
+                409: components["responses"]["Error"];
+                413: components["responses"]["Error"];
+            };
+        };
+        delete?: never;
+        options?: never;
+        head?: never;
+        patch?: never;
+        trace?: never;
+    };
+    "/api-tokens": {
+        parameters: {
+            query?: never;
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        /** 列出 API 令牌 */
+        get: {
+            parameters: {
+                query?: never;
+                header?: never;
+                path?: never;
+                cookie?: never;
+            };
+            requestBody?: never;
+            responses: {
+                /** @description 令牌列表，不含令牌明文 */
+                200: {
+                    headers: {
+                        [name: string]: unknown;
+                    };
+                    content: {
+                        "application/json": components["schemas"]["APIToken"][];
+                    };
+                };
+                401: components["responses"]["Error"];
+                403: components["responses"]["Error"];
+            };
+        };
+        put?: never;
+        /** 创建 API 令牌 */
+        post: {
+            parameters: {
+                query?: never;
+                header?: never;
+                path?: never;
+                cookie?: never;
+            };
+            requestBody: {
+                content: {
+                    "application/json": components["schemas"]["APITokenInput"];
+                };
+            };
+            responses: {
+                /** @description 已创建；令牌明文只在此响应中返回一次 */
+                201: {
+                    headers: {
+                        [name: string]: unknown;
+                    };
+                    content: {
+                        "application/json": components["schemas"]["CreatedAPIToken"];
+                    };
+                };
+                400: components["responses"]["Error"];
+                401: components["responses"]["Error"];
+                403: components["responses"]["Error"];
+                413: components["responses"]["Error"];
+            };
+        };
+        delete?: never;
+        options?: never;
+        head?: never;
+        patch?: never;
+        trace?: never;
+    };
+    "/api-tokens/{id}": {
+        parameters: {
+            query?: never;
+            header?: never;
+            path: {
+                id: components["parameters"]["ID"];
+            };
+            cookie?: never;
+        };
+        get?: never;
+        put?: never;
+        post?: never;
+        /** 撤销 API 令牌 */
+        delete: {
+            parameters: {
+                query?: never;
+                header?: never;
+                path: {
+                    id: components["parameters"]["ID"];
+                };
+                cookie?: never;
+            };
+            requestBody?: never;
+            responses: {
+                /** @description 已撤销 */
+                204: {
+                    headers: {
+                        [name: string]: unknown;
+                    };
+                    content?: never;
+                };
+                400: components["responses"]["Error"];
+                401: components["responses"]["Error"];
+                403: components["responses"]["Error"];
+                404: components["responses"]["Error"];
+            };
+        };
+        options?: never;
+        head?: never;
+        patch?: never;
+        trace?: never;
+    };
+    "/notifications": {
+        parameters: {
+            query?: never;
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        /**
+         * 获取任务提醒设置
+         * @description Webhook 地址只返回协议和主机，密钥只表示是否已设置。
+         */
+        get: {
+            parameters: {
+                query?: never;
+                header?: never;
+                path?: never;
+                cookie?: never;
+            };
+            requestBody?: never;
+            responses: {
+                /** @description 当前设置 */
+                200: {
+                    headers: {
+                        [name: string]: unknown;
+                    };
+                    content: {
+                        "application/json": components["schemas"]["NotificationSettings"];
+                    };
+                };
+                401: components["responses"]["Error"];
+                403: components["responses"]["Error"];
+            };
+        };
+        /** 更新任务提醒设置 */
+        put: {
+            parameters: {
+                query?: never;
+                header?: never;
+                path?: never;
+                cookie?: never;
+            };
+            requestBody: {
+                content: {
+                    "application/json": components["schemas"]["NotificationSettingsInput"];
+                };
+            };
+            responses: {
+                /** @description 已更新 */
+                200: {
+                    headers: {
+                        [name: string]: unknown;
+                    };
+                    content: {
+                        "application/json": components["schemas"]["NotificationSettings"];
+                    };
+                };
+                400: components["responses"]["Error"];
+                401: components["responses"]["Error"];
+                403: components["responses"]["Error"];
+            };
+        };
+        post?: never;
+        delete?: never;
+        options?: never;
+        head?: never;
+        patch?: never;
+        trace?: never;
+    };
+    "/notifications/deliveries": {
+        parameters: {
+            query?: never;
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        /** 最近的提醒发送记录 */
+        get: {
+            parameters: {
+                query?: never;
+                header?: never;
+                path?: never;
+                cookie?: never;
+            };
+            requestBody?: never;
+            responses: {
+                /** @description 最近排队的 50 条提醒，最新的在前 */
+                200: {
+                    headers: {
+                        [name: string]: unknown;
+                    };
+                    content: {
+                        "application/json": components["schemas"]["NotificationDelivery"][];
+                    };
+                };
+                401: components["responses"]["Error"];
+                403: components["responses"]["Error"];
+            };
+        };
+        put?: never;
+        post?: never;
+        delete?: never;
+        options?: never;
+        head?: never;
+        patch?: never;
+        trace?: never;
+    };
+    "/notifications/email": {
+        parameters: {
+            query?: never;
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        /**
+         * 获取每日邮件摘要设置
+         * @description SMTP 密码只写，响应只表示是否已设置。
+         */
+        get: {
+            parameters: {
+                query?: never;
+                header?: never;
+                path?: never;
+                cookie?: never;
+            };
+            requestBody?: never;
+            responses: {
+                /** @description 当前设置和最近一次发送结果 */
+                200: {
+                    headers: {
+                        [name: string]: unknown;
+                    };
+                    content: {
+                        "application/json": components["schemas"]["EmailDigestSettings"];
+                    };
+                };
+                401: components["responses"]["Error"];
+                403: components["responses"]["Error"];
+            };
+        };
+        /** 更新每日邮件摘要设置 */
+        put: {
+            parameters: {
+                query?: never;
+                header?: never;
+                path?: never;
+                cookie?: never;
+            };
+            requestBody: {
+                content: {
+                    "application/json": components["schemas"]["EmailDigestSettingsInput"];
+                };
+            };
+            responses: {
+                /** @description 已更新 */
+                200: {
+                    headers: {
+                        [name: string]: unknown;
+                    };
+                    content: {
+                        "application/json": components["schemas"]["EmailDigestSettings"];
+                    };
+                };
+                400: components["responses"]["Error"];
+                401: components["responses"]["Error"];
+                403: components["responses"]["Error"];
+            };
+        };
+        post?: never;
+        delete?: never;
+        options?: never;
+        head?: never;
+        patch?: never;
+        trace?: never;
+    };
+    "/webhooks": {
+        parameters: {
+            query?: never;
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        /**
+         * 列出事件 Webhook
+         * @description 地址只返回协议和主机，签名密钥只在创建时返回一次。
+         */
+        get: {
+            parameters: {
+                query?: never;
+                header?: never;
+                path?: never;
+                cookie?: never;
+            };
+            requestBody?: never;
+            responses: {
+                /** @description 全部 Webhook */
+                200: {
+                    headers: {
+                        [name: string]: unknown;
+                    };
+                    content: {
+                        "application/json": components["schemas"]["Webhook"][];
+                    };
+                };
+                401: components["responses"]["Error"];
+                403: components["responses"]["Error"];
+            };
+        };
+        put?: never;
+        /**
+         * 创建事件 Webhook
+         * @description 每次投递以 JSON POST 到 `url`，并带有以下请求头：  - `X-NomadBank-Event`：事件类型 - `X-NomadBank-Delivery`：投递 ID，重试时不变，接收方据此去重 - `X-NomadBank-Timestamp`：本次请求的 Unix 秒 - `X-NomadBank-Signature`：`sha256=` 加上以签名密钥对   `<timestamp>.<请求体>` 计算的 HMAC-SHA256 十六进制值  失败（网络错误或非 2xx 响应）后按 1、2、4、8、16 分钟退避重试，最多共 6 次。
+         */
+        post: {
+            parameters: {
+                query?: never;
+                header?: never;
+                path?: never;
+                cookie?: never;
+            };
+            requestBody: {
+                content: {
+                    "application/json": components["schemas"]["WebhookInput"];
+                };
+            };
+            responses: {
+                /** @description 已创建；`secret` 只在此返回一次 */
+                201: {
+                    headers: {
+                        [name: string]: unknown;
+                    };
+                    content: {
+                        "application/json": components["schemas"]["CreatedWebhook"];
+                    };
+                };
+                400: components["responses"]["Error"];
+                401: components["responses"]["Error"];
+                403: components["responses"]["Error"];
+            };
+        };
+        delete?: never;
+        options?: never;
+        head?: never;
+        patch?: never;
+        trace?: never;
+    };
+    "/webhooks/{id}": {
+        parameters: {
+            query?: never;
+            header?: never;
+            path: {
+                id: components["parameters"]["ID"];
+            };
+            cookie?: never;
+        };
+        get?: never;
+        /** 更新事件 Webhook */
+        put: {
+            parameters: {
+                query?: never;
+                header?: never;
+                path: {
+                    id: components["parameters"]["ID"];
+                };
+                cookie?: never;
+            };
+            requestBody: {
+                content: {
+                    "application/json": components["schemas"]["WebhookUpdate"];
+                };
+            };
+            responses: {
+                /** @description 已更新 */
+                200: {
+                    headers: {
+                        [name: string]: unknown;
+                    };
+                    content: {
+                        "application/json": components["schemas"]["Webhook"];
+                    };
+                };
+                400: components["responses"]["Error"];
+                401: components["responses"]["Error"];
+                403: components["responses"]["Error"];
+                404: components["responses"]["Error"];
+            };
+        };
+        post?: never;
+        /**
+         * 删除事件 Webhook
+         * @description 同时删除它的投递记录，尚未送达的事件不再发送。
+         */
+        delete: {
+            parameters: {
+                query?: never;
+                header?: never;
+                path: {
+                    id: components["parameters"]["ID"];
+                };
+                cookie?: never;
+            };
+            requestBody?: never;
+            responses: {
+                /** @description 已删除 */
+                204: {
+                    headers: {
+                        [name: string]: unknown;
+                    };
+                    content?: never;
+                };
+                400: components["responses"]["Error"];
+                401: components["responses"]["Error"];
+                403: components["responses"]["Error"];
+                404: components["responses"]["Error"];
+            };
+        };
+        options?: never;
+        head?: never;
+        patch?: never;
+        trace?: never;
+    };
+    "/webhooks/{id}/deliveries": {
+        parameters: {
+            query?: never;
+            header?: never;
+            path: {
+                id: components["parameters"]["ID"];
+            };
+            cookie?: never;
+        };
+        /**
+         * 最近的投递和重试记录
+         * @description 已送达或放弃的投递保留 30 天。
+         */
+        get: {
+            parameters: {
+                query?: never;
+                header?: never;
+                path: {
+                    id: components["parameters"]["ID"];
+                };
+                cookie?: never;
+            };
+            requestBody?: never;
+            responses: {
+                /** @description 最近排队的 50 次投递，最新的在前 */
+                200: {
+                    headers: {
+                        [name: string]: unknown;
+                    };
+                    content: {
+                        "application/json": components["schemas"]["WebhookDelivery"][];
+                    };
+                };
+                400: components["responses"]["Error"];
+                401: components["responses"]["Error"];
+                403: components["responses"]["Error"];
+                404: components["responses"]["Error"];
+            };
+        };
+        put?: never;
+        post?: never;
+        delete?: never;
+        options?: never;
+        head?: never;
+        patch?: never;
+        trace?: never;
+    };
+    "/webhooks/{id}/test": {
+        parameters: {
+            query?: never;
+            header?: never;
+            path: {
+                id: components["parameters"]["ID"];
+            };
+            cookie?: never;
+        };
+        get?: never;
+        put?: never;
+        /**
+         * 发送测试事件
+         * @description 立即发送一次 `webhook.test` 事件，已暂停的 Webhook 也会发送；测试事件失败后不重试。
+         */
+        post: {
+            parameters: {
+                query?: never;
+                header?: never;
+                path: {
+                    id: components["parameters"]["ID"];
+                };
+                cookie?: never;
+            };
+            requestBody?: never;
+            responses: {
+                /** @description 本次投递及其结果；接收方失败时 `status` 为 `failed` */
+                200: {
+                    headers: {
+                        [name: string]: unknown;
+                    };
+                    content: {
+                        "application/json": components["schemas"]["WebhookDelivery"];
+                    };
+                };
+                400: components["responses"]["Error"];
+                401: components["responses"]["Error"];
+                403: components["responses"]["Error"];
+                404: components["responses"]["Error"];
+            };
+        };
+        delete?: never;
+        options?: never;
+        head?: never;
+        patch?: never;
+        trace?: never;
+    };
+    "/me/login-activity": {
+        parameters: {
+            query?: never;
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        /** 登录失败限制状态和最近的登录记录 */
+        get: {
+            parameters: {
+                query?: never;
+                header?: never;
+                path?: never;
+                cookie?: never;
+            };
+            requestBody?: never;
+            responses: {
+                /** @description 当前状态和最近 20 次登录尝试 */
+                200: {
+                    headers: {
+                        [name: string]: unknown;
+                    };
+                    content: {
+                        "application/json": components["schemas"]["LoginActivity"];
+                    };
+                };
+                401: components["responses"]["Error"];
+                403: components["responses"]["Error"];
+            };
+        };
+        put?: never;
+        post?: never;
+        delete?: never;
+        options?: never;
+        head?: never;
+        patch?: never;
+        trace?: never;
+    };
+    "/sessions": {
+        parameters: {
+            query?: never;
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        /** 列出登录设备 */
+        get: {
+            parameters: {
+                query?: never;
+                header?: never;
+                path?: never;
+                cookie?: never;
+            };
+            requestBody?: never;
+            responses: {
+                /** @description 未过期的会话，最近活动的在前 */
+                200: {
+                    headers: {
+                        [name: string]: unknown;
+                    };
+                    content: {
+                        "application/json": components["schemas"]["Session"][];
+                    };
+                };
+                401: components["responses"]["Error"];
+                403: components["responses"]["Error"];
+            };
+        };
+        put?: never;
+        post?: never;
+        /** 退出当前设备以外的所有会话 */
+        delete: {
+            parameters: {
+                query?: never;
+                header?: never;
+                path?: never;
+                cookie?: never;
+            };
+            requestBody?: never;
+            responses: {
+                /** @description 已撤销 */
+                200: {
+                    headers: {
+                        [name: string]: unknown;
+                    };
+                    content: {
+                        "application/json": {
+                            /** @description 撤销的会话数量 */
+                            revoked: number;
+                        };
+                    };
+                };
+                401: components["responses"]["Error"];
+                403: components["responses"]["Error"];
+            };
+        };
+        options?: never;
+        head?: never;
+        patch?: never;
+        trace?: never;
+    };
+    "/sessions/{id}": {
+        parameters: {
+            query?: never;
+            header?: never;
+            path: {
+                id: components["parameters"]["ID"];
+            };
+            cookie?: never;
+        };
+        get?: never;
+        put?: never;
+        post?: never;
+        /**
+         * 撤销会话
+         * @description 撤销当前会话等同于退出登录。
+         */
+        delete: {
+            parameters: {
+                query?: never;
+                header?: never;
+                path: {
+                    id: components["parameters"]["ID"];
+                };
+                cookie?: never;
+            };
+            requestBody?: never;
+            responses: {
+                /** @description 已撤销 */
                 204: {
                     headers: {
-                        "Set-Cookie"?: string;
                         [name: string]: unknown;
                     };
                     content?: never;
                 };
                 400: components["responses"]["Error"];
                 401: components["responses"]["Error"];
-                413: components["responses"]["Error"];
+                403: components["responses"]["Error"];
+                404: components["responses"]["Error"];
             };
         };
-        post?: never;
-        delete?: never;
         options?: never;
         head?: never;
         patch?: never;
@@ -366,7 +1406,10 @@ export interface paths {
         post: {
             parameters: {
                 query?: never;
-                header?: never;
+                header?: {
+                    /** @description 客户端生成的唯一键（如 UUID）。24 小时内以相同键重复提交相同请求时返回首次的成功响应（包括 `ETag`）而不重复创建；同一键用于不同请求时返回 422，首次请求仍在处理时返回 409，超过 30 秒仍未完成视为已中断，可以重新提交。 */
+                    "Idempotency-Key"?: components["parameters"]["IdempotencyKey"];
+                };
                 path?: never;
                 cookie?: never;
             };
@@ -379,6 +1422,12 @@ export interface paths {
                 /** @description 已创建 */
                 201: {
                     headers: {
+                        /** @description 资源当前版本，写操作可在 If-Match 中回传 */
+                        ETag?: string;
+                        /** @description 新建资源的地址 */
+                        Location?: string;
+                        /** @description 值为 `true` 时表示这是相同 Idempotency-Key 的已存储响应 */
+                        "Idempotent-Replayed"?: "true";
                         [name: string]: unknown;
                     };
                     content: {
@@ -387,8 +1436,10 @@ export interface paths {
                 };
                 400: components["responses"]["Error"];
                 401: components["responses"]["Error"];
+                403: components["responses"]["Error"];
                 409: components["responses"]["Error"];
                 413: components["responses"]["Error"];
+                422: components["responses"]["Error"];
             };
         };
         delete?: never;
@@ -421,6 +1472,8 @@ export interface paths {
                 /** @description 账户 */
                 200: {
                     headers: {
+                        /** @description 资源当前版本，写操作可在 If-Match 中回传 */
+                        ETag?: string;
                         [name: string]: unknown;
                     };
                     content: {
@@ -436,7 +1489,10 @@ export interface paths {
         put: {
             parameters: {
                 query?: never;
-                header?: never;
+                header?: {
+                    /** @description 上次读取时的 ETag；记录已被修改时返回 412。省略时不做检查。 */
+                    "If-Match"?: components["parameters"]["IfMatch"];
+                };
                 path: {
                     id: components["parameters"]["ID"];
                 };
@@ -451,6 +1507,8 @@ export interface paths {
                 /** @description 已更新 */
                 200: {
                     headers: {
+                        /** @description 资源当前版本，写操作可在 If-Match 中回传 */
+                        ETag?: string;
                         [name: string]: unknown;
                     };
                     content: {
@@ -459,8 +1517,10 @@ export interface paths {
                 };
                 400: components["responses"]["Error"];
                 401: components["responses"]["Error"];
+                403: components["responses"]["Error"];
                 404: components["responses"]["Error"];
                 409: components["responses"]["Error"];
+                412: components["responses"]["Error"];
                 413: components["responses"]["Error"];
             };
         };
@@ -469,7 +1529,10 @@ export interface paths {
         delete: {
             parameters: {
                 query?: never;
-                header?: never;
+                header?: {
+                    /** @description 上次读取时的 ETag；记录已被修改时返回 412。省略时不做检查。 */
+                    "If-Match"?: components["parameters"]["IfMatch"];
+                };
                 path: {
                     id: components["parameters"]["ID"];
                 };
@@ -486,8 +1549,10 @@ export interface paths {
                 };
                 400: components["responses"]["Error"];
                 401: components["responses"]["Error"];
+                403: components["responses"]["Error"];
                 404: components["responses"]["Error"];
                 409: components["responses"]["Error"];
+                412: components["responses"]["Error"];
             };
         };
         options?: never;
@@ -529,7 +1594,10 @@ export interface paths {
         post: {
             parameters: {
                 query?: never;
-                header?: never;
+                header?: {
+                    /** @description 客户端生成的唯一键（如 UUID）。24 小时内以相同键重复提交相同请求时返回首次的成功响应（包括 `ETag`）而不重复创建；同一键用于不同请求时返回 422，首次请求仍在处理时返回 409，超过 30 秒仍未完成视为已中断，可以重新提交。 */
+                    "Idempotency-Key"?: components["parameters"]["IdempotencyKey"];
+                };
                 path?: never;
                 cookie?: never;
             };
@@ -542,6 +1610,12 @@ export interface paths {
                 /** @description 已创建 */
                 201: {
                     headers: {
+                        /** @description 资源当前版本，写操作可在 If-Match 中回传 */
+                        ETag?: string;
+                        /** @description 新建资源的地址 */
+                        Location?: string;
+                        /** @description 值为 `true` 时表示这是相同 Idempotency-Key 的已存储响应 */
+                        "Idempotent-Replayed"?: "true";
                         [name: string]: unknown;
                     };
                     content: {
@@ -550,8 +1624,10 @@ export interface paths {
                 };
                 400: components["responses"]["Error"];
                 401: components["responses"]["Error"];
+                403: components["responses"]["Error"];
                 409: components["responses"]["Error"];
                 413: components["responses"]["Error"];
+                422: components["responses"]["Error"];
             };
         };
         delete?: never;
@@ -584,6 +1660,8 @@ export interface paths {
                 /** @description 策略 */
                 200: {
                     headers: {
+                        /** @description 资源当前版本，写操作可在 If-Match 中回传 */
+                        ETag?: string;
                         [name: string]: unknown;
                     };
                     content: {
@@ -599,7 +1677,10 @@ export interface paths {
         put: {
             parameters: {
                 query?: never;
-                header?: never;
+                header?: {
+                    /** @description 上次读取时的 ETag；记录已被修改时返回 412。省略时不做检查。 */
+                    "If-Match"?: components["parameters"]["IfMatch"];
+                };
                 path: {
                     id: components["parameters"]["ID"];
                 };
@@ -614,6 +1695,8 @@ export interface paths {
                 /** @description 已更新 */
                 200: {
                     headers: {
+                        /** @description 资源当前版本，写操作可在 If-Match 中回传 */
+                        ETag?: string;
                         [name: string]: unknown;
                     };
                     content: {
@@ -622,8 +1705,10 @@ export interface paths {
                 };
                 400: components["responses"]["Error"];
                 401: components["responses"]["Error"];
+                403: components["responses"]["Error"];
                 404: components["responses"]["Error"];
                 409: components["responses"]["Error"];
+                412: components["responses"]["Error"];
                 413: components["responses"]["Error"];
             };
         };
@@ -632,7 +1717,10 @@ export interface paths {
         delete: {
             parameters: {
                 query?: never;
-                header?: never;
+                header?: {
+                    /** @description 上次读取时的 ETag；记录已被修改时返回 412。省略时不做检查。 */
+                    "If-Match"?: components["parameters"]["IfMatch"];
+                };
                 path: {
                     id: components["parameters"]["ID"];
                 };
@@ -649,7 +1737,9 @@ export interface paths {
                 };
                 400: components["responses"]["Error"];
                 401: components["responses"]["Error"];
+                403: components["responses"]["Error"];
                 404: components["responses"]["Error"];
+                412: components["responses"]["Error"];
             };
         };
         options?: never;
@@ -691,7 +1781,10 @@ export interface paths {
         post: {
             parameters: {
                 query?: never;
-                header?: never;
+                header?: {
+                    /** @description 客户端生成的唯一键（如 UUID）。24 小时内以相同键重复提交相同请求时返回首次的成功响应（包括 `ETag`）而不重复创建；同一键用于不同请求时返回 422，首次请求仍在处理时返回 409，超过 30 秒仍未完成视为已中断，可以重新提交。 */
+                    "Idempotency-Key"?: components["parameters"]["IdempotencyKey"];
+                };
                 path?: never;
                 cookie?: never;
             };
@@ -711,6 +1804,8 @@ export interface paths {
                 /** @description 已生成 */
                 201: {
                     headers: {
+                        /** @description 值为 `true` 时表示这是相同 Idempotency-Key 的已存储响应 */
+                        "Idempotent-Replayed"?: "true";
                         [name: string]: unknown;
                     };
                     content: {
@@ -722,8 +1817,11 @@ export interface paths {
                 };
                 400: components["responses"]["Error"];
                 401: components["responses"]["Error"];
+                403: components["responses"]["Error"];
                 404: components["responses"]["Error"];
+                409: components["responses"]["Error"];
                 413: components["responses"]["Error"];
+                422: components["responses"]["Error"];
             };
         };
         delete?: never;
@@ -765,6 +1863,7 @@ export interface paths {
                 };
                 400: components["responses"]["Error"];
                 401: components["responses"]["Error"];
+                403: components["responses"]["Error"];
                 404: components["responses"]["Error"];
             };
         };
@@ -786,6 +1885,20 @@ export interface paths {
                 query?: {
                     status?: "pending" | "completed";
                     batch_id?: number;
+                    /** @description 转出或转入任一方为该账户 */
+                    account_id?: number;
+                    /** @description 生成批次时选择的账户分组；传空字符串表示未分组 */
+                    group_name?: string;
+                    /** @description 按所有者时区解释的开始日期（含） */
+                    scheduled_from?: string;
+                    /** @description 按所有者时区解释的结束日期（含） */
+                    scheduled_to?: string;
+                    amount_min_cents?: number;
+                    amount_max_cents?: number;
+                    /** @description 为 true 时只返回计划日期（所有者时区）早于今天的待完成任务 */
+                    overdue?: boolean;
+                    /** @description 前缀 `-` 表示降序 */
+                    sort?: "scheduled_at" | "-scheduled_at" | "amount_cents" | "-amount_cents" | "completed_at" | "-completed_at";
                     page?: number;
                     page_size?: number;
                 };
@@ -850,9 +1963,106 @@ export interface paths {
                 };
                 400: components["responses"]["Error"];
                 401: components["responses"]["Error"];
+                403: components["responses"]["Error"];
+                404: components["responses"]["Error"];
+            };
+        };
+        delete?: never;
+        options?: never;
+        head?: never;
+        patch?: never;
+        trace?: never;
+    };
+    "/tasks/{id}/link": {
+        parameters: {
+            query?: never;
+            header?: never;
+            path: {
+                id: components["parameters"]["ID"];
+            };
+            cookie?: never;
+        };
+        get?: never;
+        put?: never;
+        /**
+         * 生成一键完成链接
+         * @description 返回 24 小时内有效、只能使用一次的签名链接，打开后无需登录即可完成任务。 地址以 `PUBLIC_URL` 为前缀，未设置时使用请求的地址。
+         */
+        post: {
+            parameters: {
+                query?: never;
+                header?: never;
+                path: {
+                    id: components["parameters"]["ID"];
+                };
+                cookie?: never;
+            };
+            requestBody?: never;
+            responses: {
+                /** @description 已生成 */
+                201: {
+                    headers: {
+                        [name: string]: unknown;
+                    };
+                    content: {
+                        "application/json": components["schemas"]["TaskLink"];
+                    };
+                };
+                400: components["responses"]["Error"];
+                401: components["responses"]["Error"];
+                403: components["responses"]["Error"];
                 404: components["responses"]["Error"];
+                409: components["responses"]["Error"];
+            };
+        };
+        delete?: never;
+        options?: never;
+        head?: never;
+        patch?: never;
+        trace?: never;
+    };
+    "/audit": {
+        parameters: {
+            query?: never;
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        /**
+         * 分页获取审计日志
+         * @description 记录每次成功的写操作，包括操作者、客户端 IP 以及资源变更前后的内容， 最新的在前。日志只追加，超过 `AUDIT_RETENTION_DAYS` 的记录在写入新事件时清理。
+         */
+        get: {
+            parameters: {
+                query?: {
+                    resource_type?: "owner" | "session" | "api_token" | "notification_settings" | "email_digest" | "webhook" | "account" | "strategy" | "task_batch" | "task";
+                    resource_id?: number;
+                    /** @description 例如 `account.update` */
+                    action?: string;
+                    page?: number;
+                    page_size?: number;
+                };
+                header?: never;
+                path?: never;
+                cookie?: never;
+            };
+            requestBody?: never;
+            responses: {
+                /** @description 审计事件分页 */
+                200: {
+                    headers: {
+                        [name: string]: unknown;
+                    };
+                    content: {
+                        "application/json": components["schemas"]["AuditPage"];
+                    };
+                };
+                400: components["responses"]["Error"];
+                401: components["responses"]["Error"];
             };
         };
+        put?: never;
+        post?: never;
         delete?: never;
         options?: never;
         head?: never;
@@ -896,6 +2106,46 @@ export interface paths {
         patch?: never;
         trace?: never;
     };
+    "/events": {
+        parameters: {
+            query?: never;
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        /**
+         * 订阅实时变更事件
+         * @description Server-Sent Events 流。消息的 event 字段为事件类型，data 为 Event JSON；客户端收到后应重新获取对应资源。会话失效后服务端会结束连接。
+         */
+        get: {
+            parameters: {
+                query?: never;
+                header?: never;
+                path?: never;
+                cookie?: never;
+            };
+            requestBody?: never;
+            responses: {
+                /** @description 事件流 */
+                200: {
+                    headers: {
+                        [name: string]: unknown;
+                    };
+                    content: {
+                        "text/event-stream": string;
+                    };
+                };
+                401: components["responses"]["Error"];
+            };
+        };
+        put?: never;
+        post?: never;
+        delete?: never;
+        options?: never;
+        head?: never;
+        patch?: never;
+        trace?: never;
+    };
 }
 export type webhooks = Record<string, never>;
 export interface components {
@@ -906,6 +2156,8 @@ export interface components {
         Error: {
             code: string;
             message: string;
+            /** @description 仅在 500 `internal_error` 时返回，与响应头 `X-Request-ID` 和服务日志中的 `request_id` 相同 */
+            request_id?: string;
         };
         SetupInput: {
             username: string;
@@ -927,6 +2179,247 @@ export interface components {
             display_name: string;
             timezone: string;
         };
+        APITokenInput: {
+            name: string;
+            /** @enum {string} */
+            scope: "read_only" | "read_write";
+            expires_in_days: number;
+        };
+        APIToken: {
+            /** Format: int64 */
+            id: number;
+            name: string;
+            /** @enum {string} */
+            scope: "read_only" | "read_write";
+            /** @description 令牌开头几个字符，用于辨认 */
+            prefix: string;
+            /** Format: date-time */
+            expires_at: string;
+            /** Format: date-time */
+            last_used_at: string | null;
+            /** Format: date-time */
+            created_at: string;
+        };
+        NotificationSettings: {
+            enabled: boolean;
+            /** @description 任务计划时间前多少分钟发送提醒 */
+            lead_minutes: number;
+            /** @description 已脱敏，只保留协议和主机，路径和查询参数显示为 `******` */
+            webhook_url: string;
+            /** @description 已设置时为 `******`，否则为空字符串 */
+            webhook_secret: string;
+            /** Format: date-time */
+            updated_at: string;
+        };
+        NotificationSettingsInput: {
+            enabled: boolean;
+            lead_minutes: number;
+            /** @description 省略时保持不变，空字符串表示清除 */
+            webhook_url?: string;
+            /** @description 以 `Authorization: Bearer` 发送；省略时保持不变，空字符串表示清除 */
+            webhook_secret?: string;
+        };
+        NotificationDelivery: {
+            /** Format: int64 */
+            id: number;
+            /** Format: int64 */
+            task_id: number;
+            /** @enum {string} */
+            kind: "upcoming" | "overdue";
+            /** @enum {string} */
+            status: "pending" | "sending" | "sent" | "failed" | "cancelled";
+            attempts: number;
+            /** Format: date-time */
+            next_attempt_at: string;
+            last_error: string;
+            /** Format: date-time */
+            sent_at: string | null;
+            /** Format: date-time */
+            created_at: string;
+        };
+        EmailDigestSettings: {
+            enabled: boolean;
+            /** @description 所有者时区内每天发送的时间，从 0 点起的分钟数 */
+            send_minutes: number;
+            recipient: string;
+            sender: string;
+            smtp_host: string;
+            smtp_port: number;
+            /** @enum {string} */
+            smtp_security: "starttls" | "tls" | "none";
+            smtp_username: string;
+            smtp_password_set: boolean;
+            /** @description 账户连续多少天没有完成的任务视为休眠；提前 30 天开始列入摘要 */
+            dormancy_days: number;
+            /** @description 最近一次成功发送的日期（所有者时区，YYYY-MM-DD），从未发送时为空字符串 */
+            last_sent_on: string;
+            /** Format: date-time */
+            last_attempt_at: string | null;
+            /** @description 最近一次发送失败的原因，成功后清空 */
+            last_error: string;
+            /** Format: date-time */
+            updated_at: string;
+        };
+        EmailDigestSettingsInput: {
+            enabled: boolean;
+            send_minutes: number;
+            recipient: string;
+            /** @description 可带显示名称，例如 `NomadBank <nomadbank@example.com>` */
+            sender: string;
+            smtp_host: string;
+            smtp_port: number;
+            /**
+             * @description none 只适用于本机或内网中继，且只能向 localhost 发送用户名和密码
+             * @enum {string}
+             */
+            smtp_security: "starttls" | "tls" | "none";
+            smtp_username: string;
+            /** @description 省略时保持不变，空字符串表示清除 */
+            smtp_password?: string;
+            dormancy_days: number;
+        };
+        /**
+         * @description task.overdue 在任务所在日结束后发送一次，只回溯 7 天
+         * @enum {string}
+         */
+        WebhookEvent: "task.completed" | "task.overdue" | "batch.created" | "account.updated";
+        Webhook: {
+            /** Format: int64 */
+            id: number;
+            name: string;
+            /** @description 只含协议和主机，路径和查询参数被隐藏 */
+            url: string;
+            events: components["schemas"]["WebhookEvent"][];
+            /** @description 暂停时不记录新事件，已排队的事件等恢复后再发送 */
+            active: boolean;
+            /** Format: date-time */
+            created_at: string;
+            /** Format: date-time */
+            updated_at: string;
+        };
+        CreatedWebhook: components["schemas"]["Webhook"] & {
+            /** @description 签名密钥，只返回这一次 */
+            secret: string;
+        };
+        WebhookInput: {
+            name: string;
+            /** @description http 或 https 的完整 URL */
+            url: string;
+            events: components["schemas"]["WebhookEvent"][];
+            active: boolean;
+        };
+        WebhookUpdate: {
+            name: string;
+            /** @description 省略时保持不变 */
+            url?: string;
+            events: components["schemas"]["WebhookEvent"][];
+            active: boolean;
+        };
+        WebhookDelivery: {
+            /** Format: int64 */
+            id: number;
+            /** Format: int64 */
+            webhook_id: number;
+            /** @description 随 `X-NomadBank-Delivery` 发送，也是请求体中的 `id` */
+            delivery_id: string;
+            /** @enum {string} */
+            event: "task.completed" | "task.overdue" | "batch.created" | "account.updated" | "webhook.test";
+            /**
+             * Format: int64
+             * @description 测试事件为 null
+             */
+            resource_id: number | null;
+            /** @description 发送的请求体，包含 `id`、`type`、`occurred_at` 和事件发生时的资源 `data` */
+            payload: Record<string, never>;
+            /** @enum {string} */
+            status: "pending" | "sending" | "sent" | "failed";
+            attempts: number;
+            /** Format: date-time */
+            next_attempt_at: string;
+            /** Format: date-time */
+            created_at: string;
+            /** @description 每次请求的结果，最早的在前 */
+            log: components["schemas"]["WebhookAttempt"][];
+        };
+        WebhookAttempt: {
+            /** Format: date-time */
+            attempted_at: string;
+            /** @description 没有收到响应时为 null */
+            response_status: number | null;
+            /** @description 成功时为空字符串 */
+            error: string;
+            /** Format: int64 */
+            duration_ms: number;
+        };
+        TOTPStatus: {
+            enabled: boolean;
+            recovery_codes_remaining: number;
+        };
+        TOTPEnrollment: {
+            /** @description Base32 密钥，可手动输入认证器 */
+            secret: string;
+            /** @description 供二维码或认证器打开的 otpauth:// 地址 */
+            provisioning_uri: string;
+        };
+        TOTPCode: {
+            /** @description 认证器中的 6 位验证码 */
+            code: string;
+        };
+        RecoveryCodes: {
+            /** @description 每个恢复码只能代替验证码登录一次 */
+            recovery_codes: string[];
+        };
+        PasswordConfirmation: {
+            /**
+             * Format: password
+             * @description 当前密码
+             */
+            password: string;
+        };
+        LoginActivity: {
+            /** @description 上次成功登录后（24 小时内）连续失败的次数 */
+            consecutive_failures: number;
+            /**
+             * Format: date-time
+             * @description 在此之前拒绝登录；为 null 时可以立即登录
+             */
+            retry_at: string | null;
+            /** @description 是否因失败次数过多被临时锁定 */
+            locked: boolean;
+            attempts: components["schemas"]["LoginAttempt"][];
+        };
+        LoginAttempt: {
+            /** Format: int64 */
+            id: number;
+            succeeded: boolean;
+            ip: string;
+            user_agent: string;
+            /** Format: date-time */
+            created_at: string;
+        };
+        Session: {
+            /** Format: int64 */
+            id: number;
+            /** @description 登录时浏览器的 User-Agent */
+            user_agent: string;
+            /** @description 登录时的客户端 IP */
+            ip: string;
+            /**
+             * Format: date-time
+             * @description 最近一次使用，精确到分钟
+             */
+            last_seen_at: string;
+            /** Format: date-time */
+            expires_at: string;
+            /** Format: date-time */
+            created_at: string;
+            /** @description 是否为发起本次请求的会话 */
+            current: boolean;
+        };
+        CreatedAPIToken: components["schemas"]["APIToken"] & {
+            /** @description 令牌明文，只返回一次 */
+            token: string;
+        };
         AccountInput: {
             name: string;
             group_name: string;
@@ -935,6 +2428,11 @@ export interface components {
         Account: components["schemas"]["AccountInput"] & {
             /** Format: int64 */
             id: number;
+            /**
+             * Format: int64
+             * @description 每次修改递增，ETag 由它生成
+             */
+            version: number;
             /** Format: date-time */
             created_at: string;
             /** Format: date-time */
@@ -956,6 +2454,11 @@ export interface components {
         Strategy: components["schemas"]["StrategyInput"] & {
             /** Format: int64 */
             id: number;
+            /**
+             * Format: int64
+             * @description 每次修改递增，ETag 由它生成
+             */
+            version: number;
             /** Format: date-time */
             created_at: string;
             /** Format: date-time */
@@ -991,11 +2494,21 @@ export interface components {
             amount_cents: number;
             /** @enum {string} */
             status: "pending" | "completed";
+            /** @description 待完成且计划日期（所有者时区）早于今天 */
+            overdue: boolean;
+            /** @description 按所有者时区计算的逾期自然日数，未逾期时为 0 */
+            days_overdue: number;
             /** Format: date-time */
             completed_at: string | null;
             /** Format: date-time */
             created_at: string;
         };
+        TaskLink: {
+            /** Format: uri */
+            url: string;
+            /** Format: date-time */
+            expires_at: string;
+        };
         TaskPage: {
             items: components["schemas"]["Task"][];
             /** Format: int64 */
@@ -1003,6 +2516,36 @@ export interface components {
             page: number;
             page_size: number;
         };
+        AuditEvent: {
+            /** Format: int64 */
+            id: number;
+            /** @description `<resource_type>.<操作>`，例如 `account.update` */
+            action: string;
+            resource_type: string;
+            /**
+             * Format: int64
+             * @description 操作不针对单个资源时为 null
+             */
+            resource_id: number | null;
+            /** @enum {string} */
+            actor_type: "owner" | "api_token" | "task_link" | "cli";
+            /** @description 所有者用户名或 API 令牌名称；`task_link`（一键完成链接）和 `cli`（服务器上的命令行）显示所有者用户名 */
+            actor_name: string;
+            ip: string;
+            /** @description 变更前的资源；创建时为 null */
+            before: Record<string, never> | null;
+            /** @description 变更后的资源；删除时为 null */
+            after: Record<string, never> | null;
+            /** Format: date-time */
+            created_at: string;
+        };
+        AuditPage: {
+            items: components["schemas"]["AuditEvent"][];
+            /** Format: int64 */
+            total: number;
+            page: number;
+            page_size: number;
+        };
         Dashboard: {
             /** Format: int64 */
             total_accounts: number;
@@ -1011,12 +2554,27 @@ export interface components {
             /** Format: int64 */
             pending_tasks: number;
             /** Format: int64 */
+            overdue_tasks: number;
+            /** Format: int64 */
             completed_tasks: number;
             /** Format: int64 */
             strategies: number;
             upcoming: components["schemas"]["Task"][];
             recent: components["schemas"]["Task"][];
         };
+        Event: {
+            /**
+             * Format: int64
+             * @description 进程内递增序号，重启后从 1 开始
+             */
+            sequence: number;
+            /** @enum {string} */
+            type: "account.created" | "account.updated" | "account.deleted" | "strategy.created" | "strategy.updated" | "strategy.deleted" | "batch.created" | "batch.deleted" | "task.completed";
+            /** Format: int64 */
+            resource_id: number;
+            /** Format: date-time */
+            occurred_at: string;
+        };
     };
     responses: {
         /** @description 请求失败 */
@@ -1030,6 +2588,10 @@ export interface components {
         };
     };
     parameters: {
+        /** @description 客户端生成的唯一键（如 UUID）。24 小时内以相同键重复提交相同请求时返回首次的成功响应（包括 `ETag`）而不重复创建；同一键用于不同请求时返回 422，首次请求仍在处理时返回 409，超过 30 秒仍未完成视为已中断，可以重新提交。 */
+        IdempotencyKey: string;
+        /** @description 上次读取时的 ETag；记录已被修改时返回 412。省略时不做检查。 */
+        IfMatch: string;
         ID: number;
     };
     requestBodies: never;
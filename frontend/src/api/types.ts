@@ -5,6 +5,27 @@ type Schemas = components['schemas']
 export type SetupStatus = paths['/setup']['get']['responses'][200]['content']['application/json']
 export type SetupInput = Schemas['SetupInput']
 export type Owner = Schemas['Owner']
+export type APIToken = Schemas['APIToken']
+export type APITokenInput = Schemas['APITokenInput']
+export type CreatedAPIToken = Schemas['CreatedAPIToken']
+export type Session = Schemas['Session']
+export type LoginActivity = Schemas['LoginActivity']
+export type AuditEvent = Schemas['AuditEvent']
+export type AuditPage = Schemas['AuditPage']
+export type TOTPStatus = Schemas['TOTPStatus']
+export type TOTPEnrollment = Schemas['TOTPEnrollment']
+export type RecoveryCodes = Schemas['RecoveryCodes']
+export type NotificationSettings = Schemas['NotificationSettings']
+export type NotificationSettingsInput = Schemas['NotificationSettingsInput']
+export type NotificationDelivery = Schemas['NotificationDelivery']
+export type EmailDigestSettings = Schemas['EmailDigestSettings']
+export type EmailDigestSettingsInput = Schemas['EmailDigestSettingsInput']
+export type Webhook = Schemas['Webhook']
+export type WebhookInput = Schemas['WebhookInput']
+export type WebhookUpdate = Schemas['WebhookUpdate']
+export type CreatedWebhook = Schemas['CreatedWebhook']
+export type WebhookEvent = Schemas['WebhookEvent']
+export type WebhookDelivery = Schemas['WebhookDelivery']
 export type Account = Schemas['Account']
 export type AccountInput = Schemas['AccountInput']
 export type Strategy = Schemas['Strategy']
@@ -14,6 +35,7 @@ export type Task = Schemas['Task']
 export type TaskStatus = Task['status']
 export type TaskPage = Schemas['TaskPage']
 export type Dashboard = Schemas['Dashboard']
+export type LiveEvent = Schemas['Event']
 
 export type GenerateBatchInput =
   paths['/task-batches']['post']['requestBody']['content']['application/json']
@@ -12,6 +12,7 @@ import {
 } from 'lucide-react'
 import { toast } from 'sonner'
 import { logout, meQuery } from '@/features/session/api'
+import { useLiveUpdates } from './live-updates'
 
 const navigation = [
   { to: '/', label: '概览', icon: LayoutDashboard },
@@ -27,6 +28,7 @@ export const AppShell = () => {
   const navigate = useNavigate()
   const ownerName = owner.display_name || owner.username
   const ownerInitial = ownerName.trim().charAt(0).toUpperCase() || 'N'
+  useLiveUpdates()
   const logoutMutation = useMutation({
     mutationFn: logout,
     onSuccess: async () => {
@@ -0,0 +1,41 @@
+import { useEffect } from 'react'
+import { type QueryKey, useQueryClient } from '@tanstack/react-query'
+import { basePath } from '@/api/client'
+import type { LiveEvent } from '@/api/types'
+import { accountKeys } from '@/features/accounts/api'
+import { strategyKeys } from '@/features/strategies/api'
+import { taskKeys } from '@/features/tasks/api'
+
+const dashboardKey = ['dashboard'] as const
+
+// 服务端事件只说明哪类资源发生变化，收到后让对应查询重新获取。
+const invalidations: Record<LiveEvent['type'], readonly QueryKey[]> = {
+  'account.created': [accountKeys.all, dashboardKey],
+  'account.updated': [accountKeys.all, taskKeys.all, dashboardKey],
+  'account.deleted': [accountKeys.all, dashboardKey],
+  'strategy.created': [strategyKeys.all, dashboardKey],
+  'strategy.updated': [strategyKeys.all, dashboardKey],
+  'strategy.deleted': [strategyKeys.all, taskKeys.batches, dashboardKey],
+  'batch.created': [taskKeys.all, taskKeys.batches, dashboardKey],
+  'batch.deleted': [taskKeys.all, taskKeys.batches, dashboardKey],
+  'task.completed': [taskKeys.all, taskKeys.batches, dashboardKey],
+}
+
+export const useLiveUpdates = () => {
+  const queryClient = useQueryClient()
+
+  useEffect(() => {
+    const source = new EventSource(`${basePath}/api/v1/events`)
+    const listeners = Object.entries(invalidations).map(([type, keys]) => {
+      const listener = () => {
+        for (const queryKey of keys) void queryClient.invalidateQueries({ queryKey })
+      }
+      source.addEventListener(type, listener)
+      return [type, listener] as const
+    })
+    return () => {
+      for (const [type, listener] of listeners) source.removeEventListener(type, listener)
+      source.close()
+    }
+  }, [queryClient])
+}
@@ -6,7 +6,7 @@ import {
   createRouter,
   redirect,
 } from '@tanstack/react-router'
-import { ApiError } from '@/api/client'
+import { ApiError, basePath } from '@/api/client'
 import { AccountsPage } from '@/features/accounts/page'
 import { DashboardPage } from '@/features/dashboard/page'
 import { LoginPage } from '@/features/session/login-page'
@@ -33,7 +33,7 @@ const rootRoute = createRootRouteWithContext<RouterContext>()({
         <p className='mt-2 text-sm leading-6 text-[#68736e]'>
           地址可能已经变化，返回概览继续查看账户计划。
         </p>
-        <a className='button-primary mt-6' href='/'>
+        <a className='button-primary mt-6' href={`${basePath}/`}>
           返回首页
         </a>
       </div>
@@ -127,6 +127,7 @@ const routeTree = rootRoute.addChildren([
 
 export const router = createRouter({
   routeTree,
+  basepath: basePath || '/',
   context: { queryClient: undefined! },
   defaultPreload: 'intent',
 })
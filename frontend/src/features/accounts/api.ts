@@ -1,5 +1,5 @@
 import { queryOptions } from '@tanstack/react-query'
-import { jsonBody, request } from '@/api/client'
+import { ifMatch, jsonBody, request } from '@/api/client'
 import type { Account, AccountInput } from '@/api/types'
 
 export const accountKeys = {
@@ -14,11 +14,12 @@ export const accountsQuery = queryOptions({
 export const createAccount = (input: AccountInput): Promise<Account> =>
   request('/api/v1/accounts', { method: 'POST', body: jsonBody(input) })
 
-export const updateAccount = (id: number, input: AccountInput): Promise<Account> =>
-  request(`/api/v1/accounts/${id}`, {
+export const updateAccount = (account: Account, input: AccountInput): Promise<Account> =>
+  request(`/api/v1/accounts/${account.id}`, {
     method: 'PUT',
+    headers: ifMatch(account.version),
     body: jsonBody(input),
   })
 
-export const deleteAccount = (id: number): Promise<void> =>
-  request(`/api/v1/accounts/${id}`, { method: 'DELETE' })
+export const deleteAccount = (account: Account): Promise<void> =>
+  request(`/api/v1/accounts/${account.id}`, { method: 'DELETE', headers: ifMatch(account.version) })
@@ -2,6 +2,7 @@ import { useState } from 'react'
 import { useMutation, useQueryClient, useSuspenseQuery } from '@tanstack/react-query'
 import { Pencil, Plus, Power, Trash2, WalletCards } from 'lucide-react'
 import { toast } from 'sonner'
+import { isStale } from '@/api/client'
 import type { Account, AccountInput } from '@/api/types'
 import { ConfirmDialog } from '@/ui/confirm-dialog'
 import { Modal } from '@/ui/modal'
@@ -21,16 +22,20 @@ export const AccountsPage = () => {
     await queryClient.invalidateQueries({ queryKey: accountKeys.all })
     await queryClient.invalidateQueries({ queryKey: ['dashboard'] })
   }
+  const showError = (error: Error) => {
+    toast.error(error.message)
+    if (isStale(error)) void refresh()
+  }
   const saveMutation = useMutation({
     mutationFn: (input: AccountInput) =>
-      editing ? updateAccount(editing.id, input) : createAccount(input),
+      editing ? updateAccount(editing, input) : createAccount(input),
     onSuccess: async () => {
       await refresh()
       setFormOpen(false)
       setEditing(undefined)
       toast.success('账户已保存')
     },
-    onError: (error) => toast.error(error.message),
+    onError: showError,
   })
   const deleteMutation = useMutation({
     mutationFn: deleteAccount,
@@ -39,17 +44,17 @@ export const AccountsPage = () => {
       setDeleting(undefined)
       toast.success('账户已删除')
     },
-    onError: (error) => toast.error(error.message),
+    onError: showError,
   })
   const toggleMutation = useMutation({
     mutationFn: (account: Account) =>
-      updateAccount(account.id, {
+      updateAccount(account, {
         name: account.name,
         group_name: account.group_name,
         active: !account.active,
       }),
     onSuccess: refresh,
-    onError: (error) => toast.error(error.message),
+    onError: showError,
   })
 
   const openCreate = () => {
@@ -186,7 +191,7 @@ export const AccountsPage = () => {
         confirmLabel='删除账户'
         pending={deleteMutation.isPending}
         onConfirm={() => {
-          if (deleting) deleteMutation.mutate(deleting.id)
+          if (deleting) deleteMutation.mutate(deleting)
         }}
         onClose={() => setDeleting(undefined)}
       />
@@ -22,7 +22,11 @@ export const DashboardPage = () => {
       value: `${data.active_accounts}/${data.total_accounts}`,
       icon: WalletCards,
     },
-    { label: '待办任务', value: data.pending_tasks, icon: Clock3 },
+    {
+      label: data.overdue_tasks > 0 ? `待办任务（${data.overdue_tasks} 项逾期）` : '待办任务',
+      value: data.pending_tasks,
+      icon: Clock3,
+    },
     { label: '已完成', value: data.completed_tasks, icon: CheckCircle2 },
     { label: '保活策略', value: data.strategies, icon: SlidersHorizontal },
   ]
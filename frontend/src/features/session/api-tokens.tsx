@@ -0,0 +1,182 @@
+import { useState, type FormEvent } from 'react'
+import { useMutation, useQueryClient, useSuspenseQuery } from '@tanstack/react-query'
+import { Copy, Terminal, Trash2 } from 'lucide-react'
+import { toast } from 'sonner'
+import type { APIToken, APITokenInput } from '@/api/types'
+import { formatDateTime } from '@/lib/format'
+import { ConfirmDialog } from '@/ui/confirm-dialog'
+import { apiTokensQuery, createAPIToken, revokeAPIToken, sessionKeys } from './api'
+
+const scopeLabels: Record<APIToken['scope'], string> = {
+  read_only: '只读',
+  read_write: '读写',
+}
+
+export const APITokensCard = () => {
+  const { data: tokens } = useSuspenseQuery(apiTokensQuery)
+  const queryClient = useQueryClient()
+  const [name, setName] = useState('')
+  const [scope, setScope] = useState<APITokenInput['scope']>('read_only')
+  const [expiresInDays, setExpiresInDays] = useState(90)
+  const [secret, setSecret] = useState<string | undefined>()
+  const [revoking, setRevoking] = useState<APIToken | undefined>()
+
+  const refresh = () => queryClient.invalidateQueries({ queryKey: sessionKeys.apiTokens })
+  const createMutation = useMutation({
+    mutationFn: () => createAPIToken({ name, scope, expires_in_days: expiresInDays }),
+    onSuccess: async (created) => {
+      await refresh()
+      setName('')
+      setSecret(created.token)
+    },
+    onError: (error) => toast.error(error.message),
+  })
+  const revokeMutation = useMutation({
+    mutationFn: (token: APIToken) => revokeAPIToken(token.id),
+    onSuccess: async () => {
+      await refresh()
+      setRevoking(undefined)
+      toast.success('令牌已撤销')
+    },
+    onError: (error) => toast.error(error.message),
+  })
+
+  const submit = (event: FormEvent) => {
+    event.preventDefault()
+    createMutation.mutate()
+  }
+  const copySecret = async () => {
+    if (!secret) return
+    await navigator.clipboard.writeText(secret)
+    toast.success('已复制到剪贴板')
+  }
+
+  return (
+    <section className='surface overflow-hidden'>
+      <header className='flex items-center gap-3 border-b border-[#e2e6e2] bg-[#faf9f5] px-5 py-4'>
+        <div className='flex h-10 w-10 items-center justify-center rounded-xl bg-[#e6ecf4] text-[#3d5f86]'>
+          <Terminal size={19} />
+        </div>
+        <div>
+          <h2 className='font-semibold text-[#25312c]'>API 令牌</h2>
+          <p className='mt-0.5 text-xs text-[#748079]'>
+            供脚本和快捷指令通过 Authorization: Bearer 访问，令牌无法管理令牌或修改密码
+          </p>
+        </div>
+      </header>
+      <div className='space-y-5 p-5 sm:p-6'>
+        {secret && (
+          <div className='rounded-xl border border-[#cfe3d8] bg-[#eef6f1] p-4 text-sm text-[#2f5e4d]'>
+            <p className='font-medium'>新令牌只显示这一次，请立即保存。</p>
+            <div className='mt-3 flex items-center gap-2'>
+              <code className='min-w-0 flex-1 truncate rounded-lg bg-white px-3 py-2 font-mono text-xs'>
+                {secret}
+              </code>
+              <button
+                type='button'
+                className='icon-button'
+                onClick={() => void copySecret()}
+                aria-label='复制令牌'
+                title='复制'
+              >
+                <Copy size={17} />
+              </button>
+            </div>
+          </div>
+        )}
+
+        <form className='grid gap-4 sm:grid-cols-[1fr_auto_auto_auto] sm:items-end' onSubmit={submit}>
+          <label htmlFor='api-token-name'>
+            <span className='label'>名称</span>
+            <input
+              id='api-token-name'
+              className='field'
+              value={name}
+              onChange={(event) => setName(event.target.value)}
+              placeholder='例如：iOS 快捷指令'
+              maxLength={60}
+              required
+            />
+          </label>
+          <label htmlFor='api-token-scope'>
+            <span className='label'>权限</span>
+            <select
+              id='api-token-scope'
+              className='field'
+              value={scope}
+              onChange={(event) => setScope(event.target.value as APITokenInput['scope'])}
+            >
+              <option value='read_only'>只读</option>
+              <option value='read_write'>读写</option>
+            </select>
+          </label>
+          <label htmlFor='api-token-expiry'>
+            <span className='label'>有效期（天）</span>
+            <input
+              id='api-token-expiry'
+              className='field'
+              type='number'
+              min={1}
+              max={365}
+              value={expiresInDays}
+              onChange={(event) => setExpiresInDays(Number(event.target.value))}
+              required
+            />
+          </label>
+          <button className='button-primary' disabled={createMutation.isPending}>
+            {createMutation.isPending ? '正在创建…' : '创建令牌'}
+          </button>
+        </form>
+
+        {tokens.length === 0 ? (
+          <p className='text-sm text-[#748079]'>还没有 API 令牌。</p>
+        ) : (
+          <div className='divide-y divide-[#e5e8e4] rounded-xl border border-[#e2e6e2]'>
+            {tokens.map((token) => (
+              <article
+                key={token.id}
+                className='flex flex-col gap-3 px-4 py-3.5 sm:flex-row sm:items-center sm:justify-between'
+              >
+                <div className='min-w-0'>
+                  <div className='flex flex-wrap items-center gap-2'>
+                    <h3 className='truncate text-sm font-semibold text-[#25312c]'>{token.name}</h3>
+                    <span className='status-pill px-2 py-0.5 bg-[#eff0ed] text-[#5f6a64]'>
+                      {scopeLabels[token.scope]}
+                    </span>
+                    <code className='font-mono text-xs text-[#748079]'>{token.prefix}…</code>
+                  </div>
+                  <p className='mt-1 text-xs text-[#748079]'>
+                    {formatDateTime(token.expires_at)} 到期 ·{' '}
+                    {token.last_used_at ? `最近使用 ${formatDateTime(token.last_used_at)}` : '从未使用'}
+                  </p>
+                </div>
+                <button
+                  type='button'
+                  className='icon-button-danger self-end sm:self-auto'
+                  onClick={() => setRevoking(token)}
+                  disabled={revokeMutation.isPending}
+                  aria-label={`撤销令牌 ${token.name}`}
+                  title='撤销'
+                >
+                  <Trash2 size={17} />
+                </button>
+              </article>
+            ))}
+          </div>
+        )}
+      </div>
+
+      <ConfirmDialog
+        open={Boolean(revoking)}
+        title={`撤销“${revoking?.name ?? ''}”`}
+        description='撤销后使用该令牌的脚本会立即收到 401，无法恢复。'
+        confirmLabel='撤销令牌'
+        pending={revokeMutation.isPending}
+        onConfirm={() => {
+          if (revoking) revokeMutation.mutate(revoking)
+        }}
+        onClose={() => setRevoking(undefined)}
+      />
+    </section>
+  )
+}
@@ -1,10 +1,42 @@
 import { queryOptions } from '@tanstack/react-query'
 import { jsonBody, request } from '@/api/client'
-import type { Owner, SetupInput, SetupStatus } from '@/api/types'
+import type {
+  APIToken,
+  APITokenInput,
+  AuditPage,
+  CreatedAPIToken,
+  CreatedWebhook,
+  EmailDigestSettings,
+  EmailDigestSettingsInput,
+  LoginActivity,
+  NotificationDelivery,
+  NotificationSettings,
+  NotificationSettingsInput,
+  Owner,
+  RecoveryCodes,
+  Session,
+  SetupInput,
+  SetupStatus,
+  TOTPEnrollment,
+  TOTPStatus,
+  Webhook,
+  WebhookDelivery,
+  WebhookInput,
+  WebhookUpdate,
+} from '@/api/types'
 
 export const sessionKeys = {
   setup: ['setup'] as const,
   me: ['session', 'me'] as const,
+  apiTokens: ['session', 'api-tokens'] as const,
+  sessions: ['session', 'sessions'] as const,
+  totp: ['session', 'totp'] as const,
+  loginActivity: ['session', 'login-activity'] as const,
+  notifications: ['session', 'notifications'] as const,
+  deliveries: ['session', 'notifications', 'deliveries'] as const,
+  emailDigest: ['session', 'notifications', 'email'] as const,
+  webhooks: ['session', 'webhooks'] as const,
+  audit: ['audit'] as const,
 }
 
 export const setupStatusQuery = queryOptions({
@@ -23,10 +55,11 @@ export const meQuery = queryOptions({
 export const setup = (input: SetupInput): Promise<Owner> =>
   request('/api/v1/setup', { method: 'POST', body: jsonBody(input) })
 
-export const login = (username: string, password: string): Promise<Owner> =>
+// code 只在开启两步验证后需要；缺少时服务端返回 totp_required。
+export const login = (username: string, password: string, code?: string): Promise<Owner> =>
   request('/api/v1/session', {
     method: 'POST',
-    body: jsonBody({ username, password }),
+    body: jsonBody({ username, password, ...(code ? { code } : {}) }),
   })
 
 export const logout = (): Promise<void> => request('/api/v1/session', { method: 'DELETE' })
@@ -45,3 +78,100 @@ export const changePassword = (currentPassword: string, newPassword: string): Pr
       new_password: newPassword,
     }),
   })
+
+export const apiTokensQuery = queryOptions({
+  queryKey: sessionKeys.apiTokens,
+  queryFn: () => request<APIToken[]>('/api/v1/api-tokens'),
+})
+
+export const createAPIToken = (input: APITokenInput): Promise<CreatedAPIToken> =>
+  request('/api/v1/api-tokens', { method: 'POST', body: jsonBody(input) })
+
+export const revokeAPIToken = (id: number): Promise<void> =>
+  request(`/api/v1/api-tokens/${id}`, { method: 'DELETE' })
+
+export const sessionsQuery = queryOptions({
+  queryKey: sessionKeys.sessions,
+  queryFn: () => request<Session[]>('/api/v1/sessions'),
+})
+
+export const revokeSession = (id: number): Promise<void> =>
+  request(`/api/v1/sessions/${id}`, { method: 'DELETE' })
+
+export const revokeOtherSessions = (): Promise<{ revoked: number }> =>
+  request('/api/v1/sessions', { method: 'DELETE' })
+
+export const totpStatusQuery = queryOptions({
+  queryKey: sessionKeys.totp,
+  queryFn: () => request<TOTPStatus>('/api/v1/me/totp'),
+})
+
+export const beginTOTPEnrollment = (password: string): Promise<TOTPEnrollment> =>
+  request('/api/v1/me/totp/enrollment', { method: 'POST', body: jsonBody({ password }) })
+
+export const confirmTOTP = (code: string): Promise<RecoveryCodes> =>
+  request('/api/v1/me/totp/confirm', { method: 'POST', body: jsonBody({ code }) })
+
+export const disableTOTP = (password: string): Promise<void> =>
+  request('/api/v1/me/totp/disable', { method: 'POST', body: jsonBody({ password }) })
+
+export const regenerateRecoveryCodes = (password: string): Promise<RecoveryCodes> =>
+  request('/api/v1/me/totp/recovery-codes', { method: 'POST', body: jsonBody({ password }) })
+
+export const loginActivityQuery = queryOptions({
+  queryKey: sessionKeys.loginActivity,
+  queryFn: () => request<LoginActivity>('/api/v1/me/login-activity'),
+})
+
+export const notificationSettingsQuery = queryOptions({
+  queryKey: sessionKeys.notifications,
+  queryFn: () => request<NotificationSettings>('/api/v1/notifications'),
+})
+
+// webhook_url 和 webhook_secret 省略时保持不变，空字符串表示清除。
+export const updateNotificationSettings = (input: NotificationSettingsInput): Promise<NotificationSettings> =>
+  request('/api/v1/notifications', { method: 'PUT', body: jsonBody(input) })
+
+export const notificationDeliveriesQuery = queryOptions({
+  queryKey: sessionKeys.deliveries,
+  queryFn: () => request<NotificationDelivery[]>('/api/v1/notifications/deliveries'),
+})
+
+export const emailDigestQuery = queryOptions({
+  queryKey: sessionKeys.emailDigest,
+  queryFn: () => request<EmailDigestSettings>('/api/v1/notifications/email'),
+})
+
+// smtp_password 只写：省略时保持不变，空字符串表示清除。
+export const updateEmailDigest = (input: EmailDigestSettingsInput): Promise<EmailDigestSettings> =>
+  request('/api/v1/notifications/email', { method: 'PUT', body: jsonBody(input) })
+
+export const webhooksQuery = queryOptions({
+  queryKey: sessionKeys.webhooks,
+  queryFn: () => request<Webhook[]>('/api/v1/webhooks'),
+})
+
+export const createWebhook = (input: WebhookInput): Promise<CreatedWebhook> =>
+  request('/api/v1/webhooks', { method: 'POST', body: jsonBody(input) })
+
+// url 省略时保持不变。
+export const updateWebhook = (id: number, input: WebhookUpdate): Promise<Webhook> =>
+  request(`/api/v1/webhooks/${id}`, { method: 'PUT', body: jsonBody(input) })
+
+export const deleteWebhook = (id: number): Promise<void> =>
+  request(`/api/v1/webhooks/${id}`, { method: 'DELETE' })
+
+export const webhookDeliveriesQuery = (id: number) =>
+  queryOptions({
+    queryKey: [...sessionKeys.webhooks, id, 'deliveries'] as const,
+    queryFn: () => request<WebhookDelivery[]>(`/api/v1/webhooks/${id}/deliveries`),
+  })
+
+export const sendTestWebhook = (id: number): Promise<WebhookDelivery> =>
+  request(`/api/v1/webhooks/${id}/test`, { method: 'POST' })
+
+export const auditQuery = (page: number) =>
+  queryOptions({
+    queryKey: [...sessionKeys.audit, page] as const,
+    queryFn: () => request<AuditPage>(`/api/v1/audit?page=${page}&page_size=20`),
+  })
@@ -0,0 +1,108 @@
+import { useState } from 'react'
+import { useSuspenseQuery } from '@tanstack/react-query'
+import { History } from 'lucide-react'
+import type { AuditEvent } from '@/api/types'
+import { formatDateTime } from '@/lib/format'
+import { auditQuery } from './api'
+
+const actionLabels: Record<string, string> = {
+  'owner.setup': '完成初始化',
+  'owner.update': '修改个人资料',
+  'owner.password_change': '修改密码',
+  'owner.totp_enable': '开启两步验证',
+  'owner.totp_disable': '关闭两步验证',
+  'owner.recovery_codes_regenerate': '重新生成恢复码',
+  'session.login': '登录',
+  'session.logout': '退出登录',
+  'session.revoke': '退出设备',
+  'session.revoke_others': '退出其他设备',
+  'api_token.create': '创建 API 令牌',
+  'api_token.delete': '撤销 API 令牌',
+  'notification_settings.update': '修改任务提醒',
+  'email_digest.update': '修改每日邮件摘要',
+  'webhook.create': '创建事件 Webhook',
+  'webhook.update': '修改事件 Webhook',
+  'webhook.delete': '删除事件 Webhook',
+  'account.create': '创建账户',
+  'account.update': '修改账户',
+  'account.delete': '删除账户',
+  'strategy.create': '创建策略',
+  'strategy.update': '修改策略',
+  'strategy.delete': '删除策略',
+  'task_batch.create': '生成任务批次',
+  'task_batch.delete': '删除任务批次',
+  'task.complete': '完成任务',
+}
+
+// 优先展示资源名称，其次是 ID。
+const resourceName = (event: AuditEvent) => {
+  const state = (event.after ?? event.before) as { name?: unknown } | null
+  if (typeof state?.name === 'string') return state.name
+  return event.resource_id ? `#${event.resource_id}` : ''
+}
+
+const actorLabels: Record<AuditEvent['actor_type'], (name: string) => string> = {
+  owner: (name) => name,
+  api_token: (name) => `令牌 ${name}`,
+  task_link: (name) => `${name}（完成链接）`,
+  cli: (name) => `${name}（命令行）`,
+}
+
+export const AuditLogCard = () => {
+  const [page, setPage] = useState(1)
+  const { data: audit } = useSuspenseQuery(auditQuery(page))
+  const totalPages = Math.max(1, Math.ceil(audit.total / audit.page_size))
+
+  return (
+    <section className='surface overflow-hidden'>
+      <header className='flex items-center gap-3 border-b border-[#e2e6e2] bg-[#faf9f5] px-5 py-4'>
+        <div className='flex h-10 w-10 items-center justify-center rounded-xl bg-[#e7f0eb] text-[#39745f]'>
+          <History size={19} />
+        </div>
+        <div>
+          <h2 className='font-semibold text-[#25312c]'>审计日志</h2>
+          <p className='mt-0.5 text-xs text-[#748079]'>每次成功的修改都会留下记录，最新的在前</p>
+        </div>
+      </header>
+      {audit.items.length === 0 ? (
+        <p className='p-5 text-sm text-[#748079] sm:p-6'>还没有审计记录。</p>
+      ) : (
+        <ul className='divide-y divide-[#e5e8e4]'>
+          {audit.items.map((event) => (
+            <li key={event.id} className='flex flex-wrap items-center gap-x-3 gap-y-1 px-5 py-3 text-sm'>
+              <span className='font-medium text-[#25312c]'>{actionLabels[event.action] ?? event.action}</span>
+              <span className='text-[#4f5b55]'>{resourceName(event)}</span>
+              <span className='ml-auto text-xs text-[#748079]'>
+                {actorLabels[event.actor_type](event.actor_name)} ·{' '}
+                {event.ip || '未知 IP'} · {formatDateTime(event.created_at)}
+              </span>
+            </li>
+          ))}
+        </ul>
+      )}
+      {audit.total > audit.page_size ? (
+        <footer className='flex flex-wrap items-center justify-between gap-3 border-t border-[#e2e6e2] bg-[#faf9f5] px-5 py-4 text-sm text-[#68736e]'>
+          <span>
+            第 {page} / {totalPages} 页
+          </span>
+          <div className='flex items-center gap-2'>
+            <button
+              className='button-secondary min-h-9 px-3 py-1.5'
+              disabled={page <= 1}
+              onClick={() => setPage((value) => value - 1)}
+            >
+              上一页
+            </button>
+            <button
+              className='button-secondary min-h-9 px-3 py-1.5'
+              disabled={page * audit.page_size >= audit.total}
+              onClick={() => setPage((value) => value + 1)}
+            >
+              下一页
+            </button>
+          </div>
+        </footer>
+      ) : null}
+    </section>
+  )
+}
@@ -0,0 +1,212 @@
+import { useState, type FormEvent } from 'react'
+import { useMutation, useQueryClient, useSuspenseQuery } from '@tanstack/react-query'
+import { Mail } from 'lucide-react'
+import { toast } from 'sonner'
+import type { EmailDigestSettingsInput } from '@/api/types'
+import { formatDateTime, minutesToTime, timeToMinutes } from '@/lib/format'
+import { emailDigestQuery, sessionKeys, updateEmailDigest } from './api'
+
+type Security = EmailDigestSettingsInput['smtp_security']
+
+const defaultPorts: Record<Security, number> = { starttls: 587, tls: 465, none: 25 }
+
+export const EmailDigestCard = () => {
+  const { data: settings } = useSuspenseQuery(emailDigestQuery)
+  const queryClient = useQueryClient()
+  const [form, setForm] = useState({
+    enabled: settings.enabled,
+    send_minutes: settings.send_minutes,
+    recipient: settings.recipient,
+    sender: settings.sender,
+    smtp_host: settings.smtp_host,
+    smtp_port: settings.smtp_port,
+    smtp_security: settings.smtp_security,
+    smtp_username: settings.smtp_username,
+    dormancy_days: settings.dormancy_days,
+  })
+  // 密码只写，留空表示保持不变。
+  const [password, setPassword] = useState('')
+  const update = <K extends keyof typeof form>(key: K, value: (typeof form)[K]) =>
+    setForm((current) => ({ ...current, [key]: value }))
+
+  const mutation = useMutation({
+    mutationFn: (input: EmailDigestSettingsInput) => updateEmailDigest(input),
+    onSuccess: async () => {
+      await queryClient.invalidateQueries({ queryKey: sessionKeys.emailDigest })
+      setPassword('')
+      toast.success('每日摘要已保存')
+    },
+    onError: (error) => toast.error(error.message),
+  })
+
+  const submit = (event: FormEvent) => {
+    event.preventDefault()
+    mutation.mutate({ ...form, ...(password ? { smtp_password: password } : {}) })
+  }
+  const changeSecurity = (security: Security) => {
+    // 端口仍是上一种方式的默认值时跟着切换。
+    if (form.smtp_port === defaultPorts[form.smtp_security]) update('smtp_port', defaultPorts[security])
+    update('smtp_security', security)
+  }
+
+  return (
+    <section className='surface overflow-hidden'>
+      <header className='flex items-center gap-3 border-b border-[#e2e6e2] bg-[#faf9f5] px-5 py-4'>
+        <div className='flex h-10 w-10 items-center justify-center rounded-xl bg-[#e6ecf4] text-[#3d5f86]'>
+          <Mail size={19} />
+        </div>
+        <div>
+          <h2 className='font-semibold text-[#25312c]'>每日邮件摘要</h2>
+          <p className='mt-0.5 text-xs text-[#748079]'>
+            每天在设定时间通过 SMTP 发送今日任务、逾期任务和即将休眠的账户
+          </p>
+        </div>
+      </header>
+      <form className='grid gap-4 p-5 sm:grid-cols-2 sm:p-6' onSubmit={submit}>
+        <label className='flex items-start gap-3 rounded-xl border border-[#d9dfda] bg-[#f7f7f2] px-4 py-3.5 sm:col-span-2'>
+          <input
+            type='checkbox'
+            checked={form.enabled}
+            onChange={(event) => update('enabled', event.target.checked)}
+            className='mt-0.5 h-4 w-4'
+          />
+          <span>
+            <span className='block text-sm font-semibold text-[#33413b]'>开启每日摘要</span>
+            <span className='mt-0.5 block text-xs leading-5 text-[#748079]'>
+              {settings.last_error
+                ? `最近一次发送失败：${settings.last_error}`
+                : settings.last_sent_on
+                  ? `最近发送于 ${settings.last_attempt_at ? formatDateTime(settings.last_attempt_at) : settings.last_sent_on}`
+                  : '按所有者时区每天发送一次，失败后每 15 分钟重试，直到当天成功。'}
+            </span>
+          </span>
+        </label>
+        <label htmlFor='digest-recipient'>
+          <span className='label'>收件人</span>
+          <input
+            id='digest-recipient'
+            className='field'
+            type='email'
+            value={form.recipient}
+            onChange={(event) => update('recipient', event.target.value)}
+            placeholder='me@example.com'
+            maxLength={320}
+          />
+        </label>
+        <label htmlFor='digest-sender'>
+          <span className='label'>发件人</span>
+          <input
+            id='digest-sender'
+            className='field'
+            value={form.sender}
+            onChange={(event) => update('sender', event.target.value)}
+            placeholder='NomadBank <nomadbank@example.com>'
+            maxLength={320}
+          />
+        </label>
+        <label htmlFor='digest-time'>
+          <span className='label'>发送时间</span>
+          <input
+            id='digest-time'
+            className='field'
+            type='time'
+            value={minutesToTime(form.send_minutes)}
+            onChange={(event) => update('send_minutes', timeToMinutes(event.target.value))}
+            required
+          />
+        </label>
+        <label htmlFor='digest-dormancy'>
+          <span className='label'>休眠天数</span>
+          <input
+            id='digest-dormancy'
+            className='field'
+            type='number'
+            min={60}
+            max={3650}
+            value={form.dormancy_days}
+            onChange={(event) => update('dormancy_days', Number(event.target.value))}
+            required
+          />
+        </label>
+        <label htmlFor='digest-smtp-host'>
+          <span className='label'>SMTP 服务器</span>
+          <input
+            id='digest-smtp-host'
+            className='field'
+            value={form.smtp_host}
+            onChange={(event) => update('smtp_host', event.target.value)}
+            placeholder='smtp.example.com'
+            maxLength={253}
+          />
+        </label>
+        <div className='grid grid-cols-[1fr_7rem] gap-3'>
+          <label htmlFor='digest-smtp-security'>
+            <span className='label'>加密方式</span>
+            <select
+              id='digest-smtp-security'
+              className='field'
+              value={form.smtp_security}
+              onChange={(event) => changeSecurity(event.target.value as Security)}
+            >
+              <option value='starttls'>STARTTLS</option>
+              <option value='tls'>TLS</option>
+              <option value='none'>不加密（仅本机）</option>
+            </select>
+          </label>
+          <label htmlFor='digest-smtp-port'>
+            <span className='label'>端口</span>
+            <input
+              id='digest-smtp-port'
+              className='field'
+              type='number'
+              min={1}
+              max={65535}
+              value={form.smtp_port}
+              onChange={(event) => update('smtp_port', Number(event.target.value))}
+              required
+            />
+          </label>
+        </div>
+        <label htmlFor='digest-smtp-username'>
+          <span className='label'>用户名（可选）</span>
+          <input
+            id='digest-smtp-username'
+            className='field'
+            autoComplete='off'
+            value={form.smtp_username}
+            onChange={(event) => update('smtp_username', event.target.value)}
+            maxLength={256}
+          />
+        </label>
+        <label htmlFor='digest-smtp-password'>
+          <span className='label'>密码</span>
+          <input
+            id='digest-smtp-password'
+            className='field'
+            type='password'
+            autoComplete='new-password'
+            value={password}
+            onChange={(event) => setPassword(event.target.value)}
+            placeholder={settings.smtp_password_set ? '已设置，留空保持不变' : '未设置'}
+            maxLength={256}
+          />
+        </label>
+        <div className='flex justify-end gap-2 sm:col-span-2'>
+          {settings.smtp_password_set && (
+            <button
+              type='button'
+              className='button-secondary'
+              disabled={mutation.isPending}
+              onClick={() => mutation.mutate({ ...form, smtp_password: '' })}
+            >
+              清除密码
+            </button>
+          )}
+          <button className='button-primary' disabled={mutation.isPending}>
+            {mutation.isPending ? '正在保存…' : '保存'}
+          </button>
+        </div>
+      </form>
+    </section>
+  )
+}
@@ -0,0 +1,57 @@
+import { useSuspenseQuery } from '@tanstack/react-query'
+import { ShieldAlert } from 'lucide-react'
+import { formatDateTime } from '@/lib/format'
+import { loginActivityQuery } from './api'
+
+export const LoginActivityCard = () => {
+  const { data: activity } = useSuspenseQuery(loginActivityQuery)
+
+  return (
+    <section className='surface overflow-hidden'>
+      <header className='flex items-center gap-3 border-b border-[#e2e6e2] bg-[#faf9f5] px-5 py-4'>
+        <div className='flex h-10 w-10 items-center justify-center rounded-xl bg-[#f5ecdc] text-[#8b642d]'>
+          <ShieldAlert size={19} />
+        </div>
+        <div>
+          <h2 className='font-semibold text-[#25312c]'>登录记录</h2>
+          <p className='mt-0.5 text-xs text-[#748079]'>
+            连续失败 5 次后每次需要等待更久，10 次后临时锁定 15 分钟，与来源 IP 无关
+          </p>
+        </div>
+      </header>
+      <div className='space-y-4 p-5 sm:p-6'>
+        {activity.retry_at ? (
+          <p className='rounded-xl border border-[#f0d9b5] bg-[#fbf3e6] p-4 text-sm text-[#8b642d]'>
+            已连续失败 {activity.consecutive_failures} 次，
+            {activity.locked ? '登录已临时锁定' : '登录暂时受限'}，{formatDateTime(activity.retry_at)} 后可再次尝试。
+          </p>
+        ) : activity.consecutive_failures > 0 ? (
+          <p className='text-sm text-[#8b642d]'>
+            上次成功登录后已有 {activity.consecutive_failures} 次失败的尝试。
+          </p>
+        ) : null}
+        {activity.attempts.length === 0 ? (
+          <p className='text-sm text-[#748079]'>还没有登录记录。</p>
+        ) : (
+          <ul className='divide-y divide-[#e5e8e4] rounded-xl border border-[#e2e6e2]'>
+            {activity.attempts.map((attempt) => (
+              <li key={attempt.id} className='flex flex-wrap items-center gap-x-3 gap-y-1 px-4 py-3 text-sm'>
+                <span
+                  className={`status-pill px-2 py-0.5 ${
+                    attempt.succeeded ? 'bg-[#e7f0eb] text-[#39745f]' : 'bg-[#f7e3df] text-[#a4452f]'
+                  }`}
+                >
+                  {attempt.succeeded ? '成功' : '失败'}
+                </span>
+                <span className='text-[#25312c]'>{formatDateTime(attempt.created_at)}</span>
+                <span className='text-xs text-[#748079]' title={attempt.user_agent}>
+                  {attempt.ip || '未知 IP'}
+                </span>
+              </li>
+            ))}
+          </ul>
+        )}
+      </div>
+    </section>
+  )
+}
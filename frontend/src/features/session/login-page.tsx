@@ -3,6 +3,7 @@ import { useMutation, useQueryClient } from '@tanstack/react-query'
 import { useNavigate, useRouter } from '@tanstack/react-router'
 import { Database, Eye, EyeOff, Landmark, LockKeyhole, LogIn, UserRound } from 'lucide-react'
 import { toast } from 'sonner'
+import { ApiError } from '@/api/client'
 import { AuthCard } from '@/ui/auth-card'
 import { login, meQuery } from './api'
 
@@ -13,14 +14,23 @@ export const LoginPage = () => {
   const [username, setUsername] = useState('')
   const [password, setPassword] = useState('')
   const [passwordVisible, setPasswordVisible] = useState(false)
+  const [code, setCode] = useState('')
+  const [codeRequired, setCodeRequired] = useState(false)
   const mutation = useMutation({
-    mutationFn: () => login(username, password),
+    mutationFn: () => login(username, password, codeRequired ? code : undefined),
     onSuccess: async (owner) => {
       queryClient.setQueryData(meQuery.queryKey, owner)
       await router.invalidate()
       await navigate({ to: '/' })
     },
-    onError: (error) => toast.error(error.message),
+    onError: (error) => {
+      // 密码正确但需要第二步时，展开验证码输入框而不是报错。
+      if (error instanceof ApiError && error.code === 'totp_required') {
+        setCodeRequired(true)
+        return
+      }
+      toast.error(error.message)
+    },
   })
 
   const submit = (event: FormEvent) => {
@@ -90,6 +100,21 @@ export const LoginPage = () => {
             </button>
           </div>
         </div>
+        {codeRequired && (
+          <label htmlFor='login-code'>
+            <span className='label'>两步验证码</span>
+            <input
+              id='login-code'
+              className='field border-stone-300 bg-white/70 font-mono shadow-none focus:border-[#35715f]'
+              value={code}
+              onChange={(event) => setCode(event.target.value)}
+              placeholder='认证器中的 6 位数字或恢复码'
+              autoComplete='one-time-code'
+              autoFocus
+              required
+            />
+          </label>
+        )}
         <button
           className='button-primary min-h-12 w-full rounded-xl bg-[#285f50] shadow-none hover:bg-[#1f4d41]'
           type='submit'
@@ -0,0 +1,169 @@
+import { useState, type FormEvent } from 'react'
+import { useMutation, useQueryClient, useSuspenseQuery } from '@tanstack/react-query'
+import { BellRing } from 'lucide-react'
+import { toast } from 'sonner'
+import type { NotificationDelivery, NotificationSettingsInput } from '@/api/types'
+import { formatDateTime } from '@/lib/format'
+import {
+  notificationDeliveriesQuery,
+  notificationSettingsQuery,
+  sessionKeys,
+  updateNotificationSettings,
+} from './api'
+
+const kindLabels: Record<NotificationDelivery['kind'], string> = {
+  upcoming: '即将到期',
+  overdue: '已逾期',
+}
+
+const statusStyles: Record<NotificationDelivery['status'], [string, string]> = {
+  pending: ['等待发送', 'bg-[#eff0ed] text-[#5f6a64]'],
+  sending: ['发送中', 'bg-[#e6ecf4] text-[#3d5f86]'],
+  sent: ['已送达', 'bg-[#e7f0eb] text-[#39745f]'],
+  failed: ['失败', 'bg-[#f7e3df] text-[#a4452f]'],
+  cancelled: ['已取消', 'bg-[#eff0ed] text-[#5f6a64]'],
+}
+
+export const NotificationsCard = () => {
+  const { data: settings } = useSuspenseQuery(notificationSettingsQuery)
+  const { data: deliveries } = useSuspenseQuery(notificationDeliveriesQuery)
+  const queryClient = useQueryClient()
+  const [enabled, setEnabled] = useState(settings.enabled)
+  const [leadMinutes, setLeadMinutes] = useState(settings.lead_minutes)
+  // 地址和密钥只以脱敏形式返回，留空表示保持不变。
+  const [webhookURL, setWebhookURL] = useState('')
+  const [webhookSecret, setWebhookSecret] = useState('')
+
+  const mutation = useMutation({
+    mutationFn: (input: NotificationSettingsInput) => updateNotificationSettings(input),
+    onSuccess: async () => {
+      await queryClient.invalidateQueries({ queryKey: sessionKeys.notifications })
+      setWebhookURL('')
+      setWebhookSecret('')
+      toast.success('任务提醒已保存')
+    },
+    onError: (error) => toast.error(error.message),
+  })
+
+  const submit = (event: FormEvent) => {
+    event.preventDefault()
+    mutation.mutate({
+      enabled,
+      lead_minutes: leadMinutes,
+      ...(webhookURL ? { webhook_url: webhookURL } : {}),
+      ...(webhookSecret ? { webhook_secret: webhookSecret } : {}),
+    })
+  }
+
+  return (
+    <section className='surface overflow-hidden'>
+      <header className='flex items-center gap-3 border-b border-[#e2e6e2] bg-[#faf9f5] px-5 py-4'>
+        <div className='flex h-10 w-10 items-center justify-center rounded-xl bg-[#e7f0eb] text-[#39745f]'>
+          <BellRing size={19} />
+        </div>
+        <div>
+          <h2 className='font-semibold text-[#25312c]'>任务提醒</h2>
+          <p className='mt-0.5 text-xs text-[#748079]'>
+            任务到期前和逾期后各向 Webhook 推送一次，送达失败时最多重试 4 次
+          </p>
+        </div>
+      </header>
+      <div className='space-y-5 p-5 sm:p-6'>
+        <form className='grid gap-4 sm:grid-cols-2' onSubmit={submit}>
+          <label className='flex items-start gap-3 rounded-xl border border-[#d9dfda] bg-[#f7f7f2] px-4 py-3.5 sm:col-span-2'>
+            <input
+              type='checkbox'
+              checked={enabled}
+              onChange={(event) => setEnabled(event.target.checked)}
+              className='mt-0.5 h-4 w-4'
+            />
+            <span>
+              <span className='block text-sm font-semibold text-[#33413b]'>开启任务提醒</span>
+              <span className='mt-0.5 block text-xs leading-5 text-[#748079]'>
+                只提醒未完成的任务；开启时最近 7 天内逾期的任务也会提醒一次。
+              </span>
+            </span>
+          </label>
+          <label htmlFor='notification-webhook-url'>
+            <span className='label'>Webhook 地址</span>
+            <input
+              id='notification-webhook-url'
+              className='field'
+              type='url'
+              value={webhookURL}
+              onChange={(event) => setWebhookURL(event.target.value)}
+              placeholder={settings.webhook_url || 'https://example.com/hook'}
+              maxLength={2048}
+            />
+          </label>
+          <label htmlFor='notification-lead-minutes'>
+            <span className='label'>提前提醒（分钟）</span>
+            <input
+              id='notification-lead-minutes'
+              className='field'
+              type='number'
+              min={0}
+              max={10080}
+              value={leadMinutes}
+              onChange={(event) => setLeadMinutes(Number(event.target.value))}
+              required
+            />
+          </label>
+          <label htmlFor='notification-webhook-secret'>
+            <span className='label'>Bearer 密钥（可选）</span>
+            <input
+              id='notification-webhook-secret'
+              className='field'
+              type='password'
+              autoComplete='off'
+              value={webhookSecret}
+              onChange={(event) => setWebhookSecret(event.target.value)}
+              placeholder={settings.webhook_secret ? '已设置，留空保持不变' : '未设置'}
+              maxLength={256}
+            />
+          </label>
+          <div className='flex items-end justify-end gap-2'>
+            {settings.webhook_secret && (
+              <button
+                type='button'
+                className='button-secondary'
+                disabled={mutation.isPending}
+                onClick={() => mutation.mutate({ enabled, lead_minutes: leadMinutes, webhook_secret: '' })}
+              >
+                清除密钥
+              </button>
+            )}
+            <button className='button-primary' disabled={mutation.isPending}>
+              {mutation.isPending ? '正在保存…' : '保存'}
+            </button>
+          </div>
+        </form>
+
+        {deliveries.length === 0 ? (
+          <p className='text-sm text-[#748079]'>还没有发送过提醒。</p>
+        ) : (
+          <ul className='divide-y divide-[#e5e8e4] rounded-xl border border-[#e2e6e2]'>
+            {deliveries.map((delivery) => {
+              const [label, style] = statusStyles[delivery.status]
+              return (
+                <li key={delivery.id} className='flex flex-wrap items-center gap-x-3 gap-y-1 px-4 py-3 text-sm'>
+                  <span className={`status-pill px-2 py-0.5 ${style}`}>{label}</span>
+                  <span className='text-[#25312c]'>
+                    任务 #{delivery.task_id} {kindLabels[delivery.kind]}
+                  </span>
+                  <span className='text-xs text-[#748079]'>
+                    {formatDateTime(delivery.sent_at ?? delivery.created_at)}
+                    {delivery.attempts > 1 && ` · 尝试 ${delivery.attempts} 次`}
+                  </span>
+                  {delivery.last_error && (
+                    <span className='w-full text-xs text-[#a4452f]'>{delivery.last_error}</span>
+                  )}
+                </li>
+              )
+            })}
+          </ul>
+        )}
+      </div>
+    </section>
+  )
+}
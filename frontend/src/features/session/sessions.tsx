@@ -0,0 +1,140 @@
+import { useState } from 'react'
+import { useMutation, useQueryClient, useSuspenseQuery } from '@tanstack/react-query'
+import { MonitorSmartphone, Trash2 } from 'lucide-react'
+import { toast } from 'sonner'
+import type { Session } from '@/api/types'
+import { formatDateTime } from '@/lib/format'
+import { ConfirmDialog } from '@/ui/confirm-dialog'
+import { revokeOtherSessions, revokeSession, sessionKeys, sessionsQuery } from './api'
+
+// Checked in order: Edge and Chrome user agents also mention Safari.
+const browsers = [
+  ['Edg/', 'Edge'],
+  ['Firefox/', 'Firefox'],
+  ['Chrome/', 'Chrome'],
+  ['Safari/', 'Safari'],
+] as const
+const systems = [
+  ['iPhone', 'iPhone'],
+  ['iPad', 'iPad'],
+  ['Android', 'Android'],
+  ['Mac OS X', 'macOS'],
+  ['Windows', 'Windows'],
+  ['Linux', 'Linux'],
+] as const
+
+// A short label for a User-Agent string; the full value is shown on hover.
+const describeUserAgent = (userAgent: string) => {
+  if (!userAgent) return '未知设备'
+  const browser = browsers.find(([token]) => userAgent.includes(token))?.[1] ?? '浏览器'
+  const system = systems.find(([token]) => userAgent.includes(token))?.[1] ?? '未知系统'
+  return `${browser} · ${system}`
+}
+
+export const SessionsCard = () => {
+  const { data: sessions } = useSuspenseQuery(sessionsQuery)
+  const queryClient = useQueryClient()
+  const [revoking, setRevoking] = useState<Session | undefined>()
+  const [revokingOthers, setRevokingOthers] = useState(false)
+  const others = sessions.filter((session) => !session.current)
+
+  const refresh = () => queryClient.invalidateQueries({ queryKey: sessionKeys.sessions })
+  const revokeMutation = useMutation({
+    mutationFn: (session: Session) => revokeSession(session.id),
+    onSuccess: async () => {
+      await refresh()
+      setRevoking(undefined)
+      toast.success('已退出该设备')
+    },
+    onError: (error) => toast.error(error.message),
+  })
+  const revokeOthersMutation = useMutation({
+    mutationFn: revokeOtherSessions,
+    onSuccess: async (result) => {
+      await refresh()
+      setRevokingOthers(false)
+      toast.success(`已退出 ${result.revoked} 台其他设备`)
+    },
+    onError: (error) => toast.error(error.message),
+  })
+
+  return (
+    <section className='surface overflow-hidden'>
+      <header className='flex flex-col gap-3 border-b border-[#e2e6e2] bg-[#faf9f5] px-5 py-4 sm:flex-row sm:items-center sm:justify-between'>
+        <div className='flex items-center gap-3'>
+          <div className='flex h-10 w-10 items-center justify-center rounded-xl bg-[#e6ecf4] text-[#3d5f86]'>
+            <MonitorSmartphone size={19} />
+          </div>
+          <div>
+            <h2 className='font-semibold text-[#25312c]'>登录设备</h2>
+            <p className='mt-0.5 text-xs text-[#748079]'>发现不认识的设备时请立即退出并修改密码</p>
+          </div>
+        </div>
+        <button
+          type='button'
+          className='button-secondary self-start sm:self-auto'
+          onClick={() => setRevokingOthers(true)}
+          disabled={others.length === 0 || revokeOthersMutation.isPending}
+        >
+          退出其他设备
+        </button>
+      </header>
+      <div className='divide-y divide-[#e5e8e4]'>
+        {sessions.map((session) => (
+          <article
+            key={session.id}
+            className='flex flex-col gap-3 px-5 py-3.5 sm:flex-row sm:items-center sm:justify-between'
+          >
+            <div className='min-w-0'>
+              <div className='flex flex-wrap items-center gap-2'>
+                <h3 className='truncate text-sm font-semibold text-[#25312c]' title={session.user_agent}>
+                  {describeUserAgent(session.user_agent)}
+                </h3>
+                {session.current && (
+                  <span className='status-pill px-2 py-0.5 bg-[#eef6f1] text-[#2f5e4d]'>当前设备</span>
+                )}
+              </div>
+              <p className='mt-1 text-xs text-[#748079]'>
+                {session.ip || '未知 IP'} · 最近活动 {formatDateTime(session.last_seen_at)} · 登录于{' '}
+                {formatDateTime(session.created_at)}
+              </p>
+            </div>
+            {!session.current && (
+              <button
+                type='button'
+                className='icon-button-danger self-end sm:self-auto'
+                onClick={() => setRevoking(session)}
+                disabled={revokeMutation.isPending}
+                aria-label='退出该设备'
+                title='退出该设备'
+              >
+                <Trash2 size={17} />
+              </button>
+            )}
+          </article>
+        ))}
+      </div>
+
+      <ConfirmDialog
+        open={Boolean(revoking)}
+        title='退出该设备'
+        description='该设备需要重新输入密码才能登录。'
+        confirmLabel='退出'
+        pending={revokeMutation.isPending}
+        onConfirm={() => {
+          if (revoking) revokeMutation.mutate(revoking)
+        }}
+        onClose={() => setRevoking(undefined)}
+      />
+      <ConfirmDialog
+        open={revokingOthers}
+        title='退出其他设备'
+        description={`除当前设备外的 ${others.length} 个会话都会失效，需要重新登录。`}
+        confirmLabel='全部退出'
+        pending={revokeOthersMutation.isPending}
+        onConfirm={() => revokeOthersMutation.mutate()}
+        onClose={() => setRevokingOthers(false)}
+      />
+    </section>
+  )
+}
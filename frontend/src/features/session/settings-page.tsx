@@ -5,6 +5,14 @@ import { toast } from 'sonner'
 import { passwordValidationMessage } from '@/lib/password'
 import { PageHeader } from '@/ui/page-header'
 import { changePassword, meQuery, updateOwner } from './api'
+import { APITokensCard } from './api-tokens'
+import { AuditLogCard } from './audit-log'
+import { EmailDigestCard } from './email-digest'
+import { LoginActivityCard } from './login-activity'
+import { NotificationsCard } from './notifications'
+import { SessionsCard } from './sessions'
+import { TwoFactorCard } from './two-factor'
+import { WebhooksCard } from './webhooks'
 
 export const SettingsPage = () => {
   const { data: owner } = useSuspenseQuery(meQuery)
@@ -50,7 +58,7 @@ export const SettingsPage = () => {
     <div className='space-y-8'>
       <PageHeader
         title='所有者设置'
-        description='管理显示名称、任务排期时区与登录密码。设置只保存在当前实例。'
+        description='管理显示名称、任务排期时区、登录密码与 API 令牌。设置只保存在当前实例。'
       />
 
       <div className='grid gap-5 xl:grid-cols-2'>
@@ -142,6 +150,15 @@ export const SettingsPage = () => {
           </div>
         </form>
       </div>
+
+      <TwoFactorCard />
+      <SessionsCard />
+      <LoginActivityCard />
+      <NotificationsCard />
+      <EmailDigestCard />
+      <WebhooksCard />
+      <APITokensCard />
+      <AuditLogCard />
     </div>
   )
 }
@@ -0,0 +1,214 @@
+import { useState, type FormEvent } from 'react'
+import { useMutation, useQueryClient, useSuspenseQuery } from '@tanstack/react-query'
+import { Copy, ShieldCheck } from 'lucide-react'
+import { toast } from 'sonner'
+import type { TOTPEnrollment } from '@/api/types'
+import {
+  beginTOTPEnrollment,
+  confirmTOTP,
+  disableTOTP,
+  regenerateRecoveryCodes,
+  sessionKeys,
+  totpStatusQuery,
+} from './api'
+
+type PasswordAction = 'enroll' | 'regenerate' | 'disable'
+
+const actionLabels: Record<PasswordAction, string> = {
+  enroll: '开始设置',
+  regenerate: '重新生成恢复码',
+  disable: '关闭两步验证',
+}
+
+export const TwoFactorCard = () => {
+  const { data: status } = useSuspenseQuery(totpStatusQuery)
+  const queryClient = useQueryClient()
+  const [action, setAction] = useState<PasswordAction | undefined>()
+  const [password, setPassword] = useState('')
+  const [enrollment, setEnrollment] = useState<TOTPEnrollment | undefined>()
+  const [code, setCode] = useState('')
+  const [recoveryCodes, setRecoveryCodes] = useState<string[] | undefined>()
+
+  const refresh = () => queryClient.invalidateQueries({ queryKey: sessionKeys.totp })
+  const reset = () => {
+    setAction(undefined)
+    setPassword('')
+    setCode('')
+  }
+  const passwordMutation = useMutation({
+    mutationFn: async (selected: PasswordAction) => {
+      switch (selected) {
+        case 'enroll':
+          setEnrollment(await beginTOTPEnrollment(password))
+          return
+        case 'regenerate':
+          setRecoveryCodes((await regenerateRecoveryCodes(password)).recovery_codes)
+          return
+        case 'disable':
+          await disableTOTP(password)
+          setRecoveryCodes(undefined)
+          toast.success('两步验证已关闭')
+      }
+    },
+    onSuccess: async () => {
+      await refresh()
+      reset()
+    },
+    onError: (error) => toast.error(error.message),
+  })
+  const confirmMutation = useMutation({
+    mutationFn: () => confirmTOTP(code),
+    onSuccess: async (result) => {
+      await refresh()
+      setEnrollment(undefined)
+      setRecoveryCodes(result.recovery_codes)
+      reset()
+      toast.success('两步验证已开启')
+    },
+    onError: (error) => toast.error(error.message),
+  })
+
+  const submitPassword = (event: FormEvent) => {
+    event.preventDefault()
+    if (action) passwordMutation.mutate(action)
+  }
+  const submitCode = (event: FormEvent) => {
+    event.preventDefault()
+    confirmMutation.mutate()
+  }
+  const copy = async (value: string) => {
+    await navigator.clipboard.writeText(value)
+    toast.success('已复制到剪贴板')
+  }
+
+  return (
+    <section className='surface overflow-hidden'>
+      <header className='flex items-center gap-3 border-b border-[#e2e6e2] bg-[#faf9f5] px-5 py-4'>
+        <div className='flex h-10 w-10 items-center justify-center rounded-xl bg-[#e4f0ea] text-[#216a55]'>
+          <ShieldCheck size={19} />
+        </div>
+        <div>
+          <h2 className='font-semibold text-[#25312c]'>两步验证</h2>
+          <p className='mt-0.5 text-xs text-[#748079]'>
+            {status.enabled
+              ? `已开启，登录时需要认证器中的验证码；剩余 ${status.recovery_codes_remaining} 个恢复码`
+              : '登录时除密码外还需要认证器（如 1Password、Google Authenticator）中的 6 位验证码'}
+          </p>
+        </div>
+      </header>
+      <div className='space-y-5 p-5 sm:p-6'>
+        {recoveryCodes && (
+          <div className='rounded-xl border border-[#cfe3d8] bg-[#eef6f1] p-4 text-sm text-[#2f5e4d]'>
+            <div className='flex items-center justify-between gap-3'>
+              <p className='font-medium'>恢复码只显示这一次，每个只能使用一次，请离线保存。</p>
+              <button
+                type='button'
+                className='icon-button'
+                onClick={() => void copy(recoveryCodes.join('\n'))}
+                aria-label='复制恢复码'
+                title='复制'
+              >
+                <Copy size={17} />
+              </button>
+            </div>
+            <ul className='mt-3 grid gap-2 font-mono text-xs sm:grid-cols-2'>
+              {recoveryCodes.map((recoveryCode) => (
+                <li key={recoveryCode} className='rounded-lg bg-white px-3 py-2'>
+                  {recoveryCode}
+                </li>
+              ))}
+            </ul>
+          </div>
+        )}
+
+        {enrollment && (
+          <form className='space-y-4' onSubmit={submitCode}>
+            <p className='text-sm text-[#5f6a64]'>
+              在手机上
+              <a className='mx-1 font-medium text-[#216a55] underline' href={enrollment.provisioning_uri}>
+                打开认证器
+              </a>
+              添加账户，或手动输入下面的密钥，然后填写认证器显示的验证码。
+            </p>
+            <div className='flex items-center gap-2'>
+              <code className='min-w-0 flex-1 truncate rounded-lg bg-[#f3f4f1] px-3 py-2 font-mono text-xs'>
+                {enrollment.secret}
+              </code>
+              <button
+                type='button'
+                className='icon-button'
+                onClick={() => void copy(enrollment.secret)}
+                aria-label='复制密钥'
+                title='复制'
+              >
+                <Copy size={17} />
+              </button>
+            </div>
+            <div className='flex flex-col gap-3 sm:flex-row sm:items-end'>
+              <label htmlFor='totp-confirm-code' className='sm:w-48'>
+                <span className='label'>验证码</span>
+                <input
+                  id='totp-confirm-code'
+                  className='field font-mono'
+                  value={code}
+                  onChange={(event) => setCode(event.target.value)}
+                  inputMode='numeric'
+                  autoComplete='one-time-code'
+                  maxLength={6}
+                  required
+                />
+              </label>
+              <button className='button-primary' disabled={confirmMutation.isPending}>
+                {confirmMutation.isPending ? '正在确认…' : '确认并开启'}
+              </button>
+              <button type='button' className='button-secondary' onClick={() => setEnrollment(undefined)}>
+                取消
+              </button>
+            </div>
+          </form>
+        )}
+
+        {!enrollment &&
+          (action ? (
+            <form className='flex flex-col gap-3 sm:flex-row sm:items-end' onSubmit={submitPassword}>
+              <label htmlFor='totp-password' className='sm:flex-1'>
+                <span className='label'>当前密码</span>
+                <input
+                  id='totp-password'
+                  className='field'
+                  type='password'
+                  value={password}
+                  onChange={(event) => setPassword(event.target.value)}
+                  autoComplete='current-password'
+                  autoFocus
+                  required
+                />
+              </label>
+              <button
+                className={action === 'disable' ? 'button-danger' : 'button-primary'}
+                disabled={passwordMutation.isPending}
+              >
+                {actionLabels[action]}
+              </button>
+              <button type='button' className='button-secondary' onClick={reset}>
+                取消
+              </button>
+            </form>
+          ) : status.enabled ? (
+            <div className='flex flex-wrap gap-3'>
+              <button type='button' className='button-secondary' onClick={() => setAction('regenerate')}>
+                重新生成恢复码
+              </button>
+              <button type='button' className='button-secondary' onClick={() => setAction('disable')}>
+                关闭两步验证
+              </button>
+            </div>
+          ) : (
+            <button type='button' className='button-primary' onClick={() => setAction('enroll')}>
+              开启两步验证
+            </button>
+          ))}
+      </div>
+    </section>
+  )
+}
@@ -0,0 +1,278 @@
+import { useState, type FormEvent } from 'react'
+import { useMutation, useQuery, useQueryClient, useSuspenseQuery } from '@tanstack/react-query'
+import { Copy, Send, Trash2, Webhook as WebhookIcon } from 'lucide-react'
+import { toast } from 'sonner'
+import type { Webhook, WebhookDelivery, WebhookEvent } from '@/api/types'
+import { formatDateTime } from '@/lib/format'
+import { ConfirmDialog } from '@/ui/confirm-dialog'
+import {
+  createWebhook,
+  deleteWebhook,
+  sendTestWebhook,
+  sessionKeys,
+  updateWebhook,
+  webhookDeliveriesQuery,
+  webhooksQuery,
+} from './api'
+
+const eventLabels: Record<WebhookEvent, string> = {
+  'task.completed': '任务完成',
+  'task.overdue': '任务逾期',
+  'batch.created': '生成任务批次',
+  'account.updated': '账户修改',
+}
+
+const statusStyles: Record<WebhookDelivery['status'], [string, string]> = {
+  pending: ['等待重试', 'bg-[#eff0ed] text-[#5f6a64]'],
+  sending: ['发送中', 'bg-[#e6ecf4] text-[#3d5f86]'],
+  sent: ['已送达', 'bg-[#e7f0eb] text-[#39745f]'],
+  failed: ['失败', 'bg-[#f7e3df] text-[#a4452f]'],
+}
+
+export const WebhooksCard = () => {
+  const { data: webhooks } = useSuspenseQuery(webhooksQuery)
+  const queryClient = useQueryClient()
+  const [name, setName] = useState('')
+  const [url, setURL] = useState('')
+  const [events, setEvents] = useState<WebhookEvent[]>(['task.completed'])
+  const [secret, setSecret] = useState<string | undefined>()
+  const [expanded, setExpanded] = useState<number | undefined>()
+  const [deleting, setDeleting] = useState<Webhook | undefined>()
+
+  const refresh = () => queryClient.invalidateQueries({ queryKey: sessionKeys.webhooks })
+  const createMutation = useMutation({
+    mutationFn: () => createWebhook({ name, url, events, active: true }),
+    onSuccess: async (created) => {
+      await refresh()
+      setName('')
+      setURL('')
+      setSecret(created.secret)
+    },
+    onError: (error) => toast.error(error.message),
+  })
+  const toggleMutation = useMutation({
+    mutationFn: (webhook: Webhook) =>
+      updateWebhook(webhook.id, { name: webhook.name, events: webhook.events, active: !webhook.active }),
+    onSuccess: refresh,
+    onError: (error) => toast.error(error.message),
+  })
+  const testMutation = useMutation({
+    mutationFn: (webhook: Webhook) => sendTestWebhook(webhook.id),
+    onSuccess: async (delivery, webhook) => {
+      await refresh()
+      setExpanded(webhook.id)
+      if (delivery.status === 'sent') toast.success('测试事件已送达')
+      else toast.error(`测试事件发送失败：${delivery.log.at(-1)?.error ?? ''}`)
+    },
+    onError: (error) => toast.error(error.message),
+  })
+  const deleteMutation = useMutation({
+    mutationFn: (webhook: Webhook) => deleteWebhook(webhook.id),
+    onSuccess: async () => {
+      await refresh()
+      setDeleting(undefined)
+      toast.success('Webhook 已删除')
+    },
+    onError: (error) => toast.error(error.message),
+  })
+
+  const submit = (event: FormEvent) => {
+    event.preventDefault()
+    createMutation.mutate()
+  }
+  const toggleEvent = (value: WebhookEvent, checked: boolean) =>
+    setEvents((current) => (checked ? [...current, value] : current.filter((item) => item !== value)))
+  const copySecret = async () => {
+    if (!secret) return
+    await navigator.clipboard.writeText(secret)
+    toast.success('已复制到剪贴板')
+  }
+
+  return (
+    <section className='surface overflow-hidden'>
+      <header className='flex items-center gap-3 border-b border-[#e2e6e2] bg-[#faf9f5] px-5 py-4'>
+        <div className='flex h-10 w-10 items-center justify-center rounded-xl bg-[#e6ecf4] text-[#3d5f86]'>
+          <WebhookIcon size={19} />
+        </div>
+        <div>
+          <h2 className='font-semibold text-[#25312c]'>事件 Webhook</h2>
+          <p className='mt-0.5 text-xs text-[#748079]'>
+            发生订阅的事件时 POST 到你的地址，以 X-NomadBank-Signature 签名，失败后自动重试
+          </p>
+        </div>
+      </header>
+      <div className='space-y-5 p-5 sm:p-6'>
+        {secret && (
+          <div className='rounded-xl border border-[#cfe3d8] bg-[#eef6f1] p-4 text-sm text-[#2f5e4d]'>
+            <p className='font-medium'>签名密钥只显示这一次，请保存到接收端用于校验签名。</p>
+            <div className='mt-3 flex items-center gap-2'>
+              <code className='min-w-0 flex-1 truncate rounded-lg bg-white px-3 py-2 font-mono text-xs'>
+                {secret}
+              </code>
+              <button
+                type='button'
+                className='icon-button'
+                onClick={() => void copySecret()}
+                aria-label='复制密钥'
+                title='复制'
+              >
+                <Copy size={17} />
+              </button>
+            </div>
+          </div>
+        )}
+
+        <form className='grid gap-4 sm:grid-cols-[12rem_1fr_auto] sm:items-end' onSubmit={submit}>
+          <label htmlFor='webhook-name'>
+            <span className='label'>名称</span>
+            <input
+              id='webhook-name'
+              className='field'
+              value={name}
+              onChange={(event) => setName(event.target.value)}
+              placeholder='例如：记账服务'
+              maxLength={64}
+              required
+            />
+          </label>
+          <label htmlFor='webhook-url'>
+            <span className='label'>地址</span>
+            <input
+              id='webhook-url'
+              className='field'
+              type='url'
+              value={url}
+              onChange={(event) => setURL(event.target.value)}
+              placeholder='https://example.com/nomadbank'
+              maxLength={2048}
+              required
+            />
+          </label>
+          <button className='button-primary' disabled={createMutation.isPending || events.length === 0}>
+            {createMutation.isPending ? '正在创建…' : '添加'}
+          </button>
+          <fieldset className='flex flex-wrap gap-x-5 gap-y-2 sm:col-span-3'>
+            <legend className='label'>订阅事件</legend>
+            {(Object.keys(eventLabels) as WebhookEvent[]).map((value) => (
+              <label key={value} className='flex items-center gap-2 text-sm text-[#33413b]'>
+                <input
+                  type='checkbox'
+                  checked={events.includes(value)}
+                  onChange={(event) => toggleEvent(value, event.target.checked)}
+                  className='h-4 w-4'
+                />
+                {eventLabels[value]}
+                <code className='font-mono text-xs text-[#748079]'>{value}</code>
+              </label>
+            ))}
+          </fieldset>
+        </form>
+
+        {webhooks.length === 0 ? (
+          <p className='text-sm text-[#748079]'>还没有事件 Webhook。</p>
+        ) : (
+          <div className='divide-y divide-[#e5e8e4] rounded-xl border border-[#e2e6e2]'>
+            {webhooks.map((webhook) => (
+              <article key={webhook.id} className='px-4 py-3.5'>
+                <div className='flex flex-col gap-3 sm:flex-row sm:items-center sm:justify-between'>
+                  <div className='min-w-0'>
+                    <div className='flex flex-wrap items-center gap-2'>
+                      <h3 className='truncate text-sm font-semibold text-[#25312c]'>{webhook.name}</h3>
+                      {!webhook.active && (
+                        <span className='status-pill px-2 py-0.5 bg-[#eff0ed] text-[#5f6a64]'>已暂停</span>
+                      )}
+                      <code className='truncate font-mono text-xs text-[#748079]'>{webhook.url}</code>
+                    </div>
+                    <p className='mt-1 text-xs text-[#748079]'>
+                      {webhook.events.map((value) => eventLabels[value]).join('、')}
+                    </p>
+                  </div>
+                  <div className='flex shrink-0 items-center gap-2 self-end sm:self-auto'>
+                    <button
+                      type='button'
+                      className='button-secondary'
+                      onClick={() => setExpanded(expanded === webhook.id ? undefined : webhook.id)}
+                    >
+                      {expanded === webhook.id ? '收起记录' : '投递记录'}
+                    </button>
+                    <button
+                      type='button'
+                      className='button-secondary'
+                      onClick={() => toggleMutation.mutate(webhook)}
+                      disabled={toggleMutation.isPending}
+                    >
+                      {webhook.active ? '暂停' : '恢复'}
+                    </button>
+                    <button
+                      type='button'
+                      className='icon-button'
+                      onClick={() => testMutation.mutate(webhook)}
+                      disabled={testMutation.isPending}
+                      aria-label={`向 ${webhook.name} 发送测试事件`}
+                      title='发送测试事件'
+                    >
+                      <Send size={17} />
+                    </button>
+                    <button
+                      type='button'
+                      className='icon-button-danger'
+                      onClick={() => setDeleting(webhook)}
+                      disabled={deleteMutation.isPending}
+                      aria-label={`删除 Webhook ${webhook.name}`}
+                      title='删除'
+                    >
+                      <Trash2 size={17} />
+                    </button>
+                  </div>
+                </div>
+                {expanded === webhook.id && <WebhookDeliveries id={webhook.id} />}
+              </article>
+            ))}
+          </div>
+        )}
+      </div>
+
+      <ConfirmDialog
+        open={Boolean(deleting)}
+        title={`删除“${deleting?.name ?? ''}”`}
+        description='删除后不再发送事件，尚未送达的事件和投递记录一并删除。'
+        confirmLabel='删除 Webhook'
+        pending={deleteMutation.isPending}
+        onConfirm={() => {
+          if (deleting) deleteMutation.mutate(deleting)
+        }}
+        onClose={() => setDeleting(undefined)}
+      />
+    </section>
+  )
+}
+
+const WebhookDeliveries = ({ id }: { id: number }) => {
+  const { data: deliveries, isPending } = useQuery(webhookDeliveriesQuery(id))
+  if (isPending) return <p className='mt-3 text-xs text-[#748079]'>正在加载…</p>
+  if (!deliveries?.length) return <p className='mt-3 text-xs text-[#748079]'>还没有投递记录。</p>
+  return (
+    <ul className='mt-3 divide-y divide-[#e5e8e4] rounded-lg border border-[#e2e6e2] bg-[#faf9f5]'>
+      {deliveries.map((delivery) => {
+        const [label, style] = statusStyles[delivery.status]
+        const last = delivery.log.at(-1)
+        return (
+          <li key={delivery.id} className='flex flex-wrap items-center gap-x-3 gap-y-1 px-3 py-2.5 text-sm'>
+            <span className={`status-pill px-2 py-0.5 ${style}`}>{label}</span>
+            <code className='font-mono text-xs text-[#25312c]'>{delivery.event}</code>
+            <span className='text-xs text-[#748079]'>
+              {formatDateTime(delivery.created_at)}
+              {delivery.attempts > 1 && ` · 尝试 ${delivery.attempts} 次`}
+              {delivery.status === 'pending' &&
+                delivery.attempts > 0 &&
+                ` · ${formatDateTime(delivery.next_attempt_at)} 重试`}
+              {last?.response_status != null && ` · HTTP ${last.response_status}`}
+              {last && ` · ${last.duration_ms} ms`}
+            </span>
+            {last?.error && <span className='w-full text-xs text-[#a4452f]'>{last.error}</span>}
+          </li>
+        )
+      })}
+    </ul>
+  )
+}
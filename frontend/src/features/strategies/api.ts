@@ -1,5 +1,5 @@
 import { queryOptions } from '@tanstack/react-query'
-import { jsonBody, request } from '@/api/client'
+import { ifMatch, jsonBody, request } from '@/api/client'
 import type { Strategy, StrategyInput } from '@/api/types'
 
 export const strategyKeys = {
@@ -14,11 +14,12 @@ export const strategiesQuery = queryOptions({
 export const createStrategy = (input: StrategyInput): Promise<Strategy> =>
   request('/api/v1/strategies', { method: 'POST', body: jsonBody(input) })
 
-export const updateStrategy = (id: number, input: StrategyInput): Promise<Strategy> =>
-  request(`/api/v1/strategies/${id}`, {
+export const updateStrategy = (strategy: Strategy, input: StrategyInput): Promise<Strategy> =>
+  request(`/api/v1/strategies/${strategy.id}`, {
     method: 'PUT',
+    headers: ifMatch(strategy.version),
     body: jsonBody(input),
   })
 
-export const deleteStrategy = (id: number): Promise<void> =>
-  request(`/api/v1/strategies/${id}`, { method: 'DELETE' })
+export const deleteStrategy = (strategy: Strategy): Promise<void> =>
+  request(`/api/v1/strategies/${strategy.id}`, { method: 'DELETE', headers: ifMatch(strategy.version) })
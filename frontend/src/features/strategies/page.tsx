@@ -2,6 +2,7 @@ import { useState } from 'react'
 import { useMutation, useQueryClient, useSuspenseQuery } from '@tanstack/react-query'
 import { CalendarClock, Pencil, Plus, Trash2 } from 'lucide-react'
 import { toast } from 'sonner'
+import { isStale } from '@/api/client'
 import type { Strategy, StrategyInput } from '@/api/types'
 import { formatMoney, minutesToTime } from '@/lib/format'
 import { ConfirmDialog } from '@/ui/confirm-dialog'
@@ -23,16 +24,20 @@ export const StrategiesPage = () => {
   const [deleting, setDeleting] = useState<Strategy | undefined>()
   const [formOpen, setFormOpen] = useState(false)
   const refresh = () => queryClient.invalidateQueries({ queryKey: strategyKeys.all })
+  const showError = (error: Error) => {
+    toast.error(error.message)
+    if (isStale(error)) void refresh()
+  }
   const saveMutation = useMutation({
     mutationFn: (input: StrategyInput) =>
-      editing ? updateStrategy(editing.id, input) : createStrategy(input),
+      editing ? updateStrategy(editing, input) : createStrategy(input),
     onSuccess: async () => {
       await refresh()
       setFormOpen(false)
       setEditing(undefined)
       toast.success('策略已保存')
     },
-    onError: (error) => toast.error(error.message),
+    onError: showError,
   })
   const deleteMutation = useMutation({
     mutationFn: deleteStrategy,
@@ -41,7 +46,7 @@ export const StrategiesPage = () => {
       setDeleting(undefined)
       toast.success('策略已删除，历史批次仍保留策略名称')
     },
-    onError: (error) => toast.error(error.message),
+    onError: showError,
   })
 
   const openCreate = () => {
@@ -162,7 +167,7 @@ export const StrategiesPage = () => {
         confirmLabel='删除策略'
         pending={deleteMutation.isPending}
         onConfirm={() => {
-          if (deleting) deleteMutation.mutate(deleting.id)
+          if (deleting) deleteMutation.mutate(deleting)
         }}
         onClose={() => setDeleting(undefined)}
       />
@@ -30,8 +30,17 @@ export const tasksQuery = (status: TaskStatus | '', batchID: number, page: numbe
     },
   })
 
-export const generateBatch = (input: GenerateBatchInput): Promise<GenerateResult> =>
-  request('/api/v1/task-batches', { method: 'POST', body: jsonBody(input) })
+// The same key is reused when a submission is retried, so the server creates
+// at most one batch for it.
+export const generateBatch = (
+  input: GenerateBatchInput,
+  idempotencyKey: string,
+): Promise<GenerateResult> =>
+  request('/api/v1/task-batches', {
+    method: 'POST',
+    headers: { 'Idempotency-Key': idempotencyKey },
+    body: jsonBody(input),
+  })
 
 export const deleteBatch = (id: number): Promise<void> =>
   request(`/api/v1/task-batches/${id}`, { method: 'DELETE' })
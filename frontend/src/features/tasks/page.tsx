@@ -2,7 +2,7 @@ import { useState } from 'react'
 import { useMutation, useQueryClient, useSuspenseQuery } from '@tanstack/react-query'
 import { ArrowRight, Check, ListChecks, Plus, Trash2 } from 'lucide-react'
 import { toast } from 'sonner'
-import type { TaskStatus } from '@/api/types'
+import type { GenerateBatchInput, TaskStatus } from '@/api/types'
 import { accountsQuery } from '@/features/accounts/api'
 import { strategiesQuery } from '@/features/strategies/api'
 import { formatDateTime, formatMoney } from '@/lib/format'
@@ -25,6 +25,7 @@ export const TasksPage = () => {
   const [batchID, setBatchID] = useState(0)
   const [page, setPage] = useState(1)
   const [generateOpen, setGenerateOpen] = useState(false)
+  const [generateKey, setGenerateKey] = useState(() => crypto.randomUUID())
   const [deleteBatchOpen, setDeleteBatchOpen] = useState(false)
   const { data: batches } = useSuspenseQuery(taskBatchesQuery)
   const { data: accounts } = useSuspenseQuery(accountsQuery)
@@ -40,8 +41,13 @@ export const TasksPage = () => {
       queryClient.invalidateQueries({ queryKey: ['dashboard'] }),
     ])
   }
+  // A fresh key per form opening; retries of the same submission reuse it.
+  const openGenerate = () => {
+    setGenerateKey(crypto.randomUUID())
+    setGenerateOpen(true)
+  }
   const generateMutation = useMutation({
-    mutationFn: generateBatch,
+    mutationFn: (input: GenerateBatchInput) => generateBatch(input, generateKey),
     onSuccess: async (result) => {
       await refresh()
       setGenerateOpen(false)
@@ -91,7 +97,7 @@ export const TasksPage = () => {
         title='保活任务'
         description='按批次生成平衡的转入与转出计划，实际完成后再手动标记。'
         actions={
-          <button className='button-primary' onClick={() => setGenerateOpen(true)}>
+          <button className='button-primary' onClick={openGenerate}>
             <Plus size={17} /> 生成任务
           </button>
         }
@@ -181,9 +187,13 @@ export const TasksPage = () => {
                       <span className='truncate'>{task.to_account_name}</span>
                     </p>
                     <span
-                      className={`status-pill ${task.status === 'completed' ? 'bg-[#e7f0eb] text-[#39745f]' : 'bg-[#f5ecdc] text-[#8b642d]'}`}
+                      className={`status-pill ${task.status === 'completed' ? 'bg-[#e7f0eb] text-[#39745f]' : task.overdue ? 'bg-[#f7e3df] text-[#a4452f]' : 'bg-[#f5ecdc] text-[#8b642d]'}`}
                     >
-                      {task.status === 'completed' ? '已完成' : '待执行'}
+                      {task.status === 'completed'
+                        ? '已完成'
+                        : task.overdue
+                          ? `逾期 ${task.days_overdue} 天`
+                          : '待执行'}
                     </span>
                   </div>
                   <div className='mt-2 flex flex-wrap items-center gap-x-3 gap-y-1 text-xs text-[#748079]'>
@@ -6,6 +6,8 @@ import { defineConfig } from 'vite'
 const backendTarget = process.env.NOMADBANK_DEV_BACKEND ?? 'http://localhost:8080'
 
 export default defineConfig({
+  // 资源地址相对 index.html 的 <base>，服务端按 BASE_PATH 改写它。
+  base: './',
   plugins: [react(), tailwindcss()],
   resolve: {
     alias: {
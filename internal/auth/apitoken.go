@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/CoxxA/nomadbank/v2/internal/domain"
+	"github.com/CoxxA/nomadbank/v2/internal/sqlite"
+)
+
+const (
+	// APITokenPrefix marks NomadBank secrets so they are easy to recognize in
+	// scripts and secret scanners.
+	APITokenPrefix     = "nbk_"
+	apiTokenShownChars = 8
+	maxAPITokenRunes   = 60
+	maxAPITokenDays    = 365
+)
+
+type APITokenInput struct {
+	Name          string
+	Scope         domain.APITokenScope
+	ExpiresInDays int
+}
+
+// CreateAPIToken stores a new token and returns its secret. The secret is
+// only kept as a hash, so this is the only time it can be shown.
+func (s *Service) CreateAPIToken(ctx context.Context, input APITokenInput) (domain.APIToken, string, error) {
+	name := strings.TrimSpace(input.Name)
+	if utf8.RuneCountInString(name) < 1 || utf8.RuneCountInString(name) > maxAPITokenRunes {
+		return domain.APIToken{}, "", ErrInvalidInput
+	}
+	if input.Scope != domain.APITokenScopeReadOnly && input.Scope != domain.APITokenScopeReadWrite {
+		return domain.APIToken{}, "", ErrInvalidInput
+	}
+	if input.ExpiresInDays < 1 || input.ExpiresInDays > maxAPITokenDays {
+		return domain.APIToken{}, "", ErrInvalidInput
+	}
+	secret, err := randomToken()
+	if err != nil {
+		return domain.APIToken{}, "", err
+	}
+	rawToken := APITokenPrefix + secret
+	token := domain.APIToken{
+		Name:      name,
+		Scope:     input.Scope,
+		Prefix:    rawToken[:len(APITokenPrefix)+apiTokenShownChars],
+		ExpiresAt: s.now().UTC().AddDate(0, 0, input.ExpiresInDays),
+	}
+	if err := s.store.CreateAPIToken(ctx, &token, rawToken); err != nil {
+		return domain.APIToken{}, "", err
+	}
+	return token, rawToken, nil
+}
+
+func (s *Service) AuthenticateAPIToken(ctx context.Context, rawToken string) (domain.Owner, domain.APIToken, error) {
+	if !strings.HasPrefix(rawToken, APITokenPrefix) {
+		return domain.Owner{}, domain.APIToken{}, ErrInvalidSession
+	}
+	token, err := s.store.UseAPIToken(ctx, rawToken, s.now())
+	if err != nil {
+		if errors.Is(err, sqlite.ErrNotFound) {
+			return domain.Owner{}, domain.APIToken{}, ErrInvalidSession
+		}
+		return domain.Owner{}, domain.APIToken{}, err
+	}
+	credentials, err := s.store.OwnerCredentials(ctx)
+	if err != nil {
+		return domain.Owner{}, domain.APIToken{}, err
+	}
+	return credentials.Owner, token, nil
+}
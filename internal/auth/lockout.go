@@ -0,0 +1,90 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/CoxxA/nomadbank/v2/internal/domain"
+)
+
+// Failed logins are counted for the single owner rather than per client IP,
+// so rotating addresses or restarting the process does not reset them. The
+// first few failures are free; after that each attempt must wait twice as
+// long as the previous one, and enough failures lock logins for a while.
+const (
+	loginFreeFailures    = 5
+	loginLockoutFailures = 10
+	loginBackoffBase     = 5 * time.Second
+	loginLockoutDuration = 15 * time.Minute
+	// Failures older than this no longer count, even without a successful login.
+	loginFailureWindow = 24 * time.Hour
+)
+
+var ErrLoginThrottled = errors.New("登录失败次数过多")
+
+// LoginThrottledError is returned by Login while failed attempts are being
+// throttled. It matches ErrLoginThrottled.
+type LoginThrottledError struct {
+	RetryAt time.Time
+	Locked  bool
+}
+
+func (e *LoginThrottledError) Error() string {
+	return fmt.Sprintf("%s，请在 %s 后重试", ErrLoginThrottled, e.RetryAt.Format(time.RFC3339))
+}
+
+func (e *LoginThrottledError) Is(target error) bool {
+	return target == ErrLoginThrottled
+}
+
+// LoginThrottle is the current state of failed-login throttling. RetryAt is
+// zero when a login may be attempted now.
+type LoginThrottle struct {
+	Failures int
+	RetryAt  time.Time
+	Locked   bool
+}
+
+func (s *Service) LoginThrottle(ctx context.Context) (LoginThrottle, error) {
+	now := s.now()
+	failures, last, err := s.store.ConsecutiveLoginFailures(ctx, now.Add(-loginFailureWindow))
+	if err != nil {
+		return LoginThrottle{}, err
+	}
+	throttle := LoginThrottle{Failures: failures}
+	delay, locked := loginDelay(failures)
+	if retryAt := last.Add(delay); delay > 0 && retryAt.After(now) {
+		throttle.RetryAt = retryAt
+		throttle.Locked = locked
+	}
+	return throttle, nil
+}
+
+// LoginActivity returns the most recent login attempts, newest first.
+func (s *Service) LoginActivity(ctx context.Context, limit int) ([]domain.LoginAttempt, error) {
+	return s.store.ListLoginAttempts(ctx, limit)
+}
+
+// loginDelay is how long to wait after the latest of failures consecutive
+// failed attempts.
+func loginDelay(failures int) (time.Duration, bool) {
+	switch {
+	case failures >= loginLockoutFailures:
+		return loginLockoutDuration, true
+	case failures >= loginFreeFailures:
+		return loginBackoffBase << (failures - loginFreeFailures), false
+	default:
+		return 0, false
+	}
+}
+
+func (s *Service) recordLoginAttempt(ctx context.Context, succeeded bool, client Client) error {
+	return s.store.RecordLoginAttempt(ctx, domain.LoginAttempt{
+		Succeeded: succeeded,
+		IP:        client.IP,
+		UserAgent: truncateUTF8(client.UserAgent, maximumUserAgentLen),
+		CreatedAt: s.now(),
+	})
+}
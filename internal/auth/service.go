@@ -6,6 +6,7 @@ import (
 	"encoding/base64"
 	"errors"
 	"strings"
+	"sync"
 	"time"
 	"unicode/utf8"
 
@@ -24,6 +25,7 @@ var (
 const (
 	minimumPasswordRunes = 10
 	maximumPasswordBytes = 72
+	maximumUserAgentLen  = 512
 )
 
 type SetupInput struct {
@@ -33,6 +35,21 @@ type SetupInput struct {
 	Timezone    string
 }
 
+// LoginInput carries the password and, when two-factor login is on, the
+// current TOTP code or a recovery code.
+type LoginInput struct {
+	Username string
+	Password string
+	Code     string
+}
+
+// Client identifies the device a session is created for; it is shown in the
+// session list.
+type Client struct {
+	UserAgent string
+	IP        string
+}
+
 type Session struct {
 	Token     string
 	ExpiresAt time.Time
@@ -43,16 +60,29 @@ type Service struct {
 	store       *sqlite.Store
 	sessionDays int
 	now         func() time.Time
+	// loginMu serializes Login from the throttle check to the recorded
+	// outcome, so concurrent guesses cannot all pass the same check. Copies
+	// made by InTx share it.
+	loginMu *sync.Mutex
 }
 
 func NewService(store *sqlite.Store, sessionDays int) *Service {
 	if sessionDays <= 0 {
 		sessionDays = 30
 	}
-	return &Service{store: store, sessionDays: sessionDays, now: time.Now}
+	return &Service{store: store, sessionDays: sessionDays, now: time.Now, loginMu: &sync.Mutex{}}
+}
+
+// InTx returns a copy of the service that works in tx, so a caller can
+// record further changes, such as an audit event, that commit or roll back
+// together with the service's.
+func (s *Service) InTx(tx *sqlite.Store) *Service {
+	service := *s
+	service.store = tx
+	return &service
 }
 
-func (s *Service) Setup(ctx context.Context, input SetupInput) (Session, error) {
+func (s *Service) Setup(ctx context.Context, input SetupInput, client Client) (Session, error) {
 	owner, err := validateSetup(input)
 	if err != nil {
 		return Session{}, err
@@ -82,31 +112,72 @@ func (s *Service) Setup(ctx context.Context, input SetupInput) (Session, error)
 	if err != nil {
 		return Session{}, err
 	}
-	return s.createSession(ctx, owner)
+	return s.createSession(ctx, owner, client)
+}
+
+// Login refuses attempts while failed logins are throttled and records the
+// outcome of every other attempt. A wrong second factor counts as a failure;
+// a missing one does not. Attempts run one at a time; the HTTP server uses a
+// single Service and its InTx copies, so this covers every login of the
+// process.
+func (s *Service) Login(ctx context.Context, input LoginInput, client Client) (Session, error) {
+	s.loginMu.Lock()
+	defer s.loginMu.Unlock()
+	throttle, err := s.LoginThrottle(ctx)
+	if err != nil {
+		return Session{}, err
+	}
+	if !throttle.RetryAt.IsZero() {
+		return Session{}, &LoginThrottledError{RetryAt: throttle.RetryAt, Locked: throttle.Locked}
+	}
+	owner, err := s.login(ctx, input)
+	switch {
+	case err == nil:
+		if err := s.recordLoginAttempt(ctx, true, client); err != nil {
+			return Session{}, err
+		}
+		return s.createSession(ctx, owner, client)
+	case errors.Is(err, ErrInvalidCredentials), errors.Is(err, ErrInvalidSecondFactor):
+		if err := s.recordLoginAttempt(ctx, false, client); err != nil {
+			return Session{}, err
+		}
+	}
+	return Session{}, err
 }
 
-func (s *Service) Login(ctx context.Context, username, password string) (Session, error) {
+// login checks the password before the second factor, so the two-factor
+// errors are only ever returned to someone who knows the password.
+func (s *Service) login(ctx context.Context, input LoginInput) (domain.Owner, error) {
 	credentials, err := s.store.OwnerCredentials(ctx)
 	if err != nil {
 		if errors.Is(err, sqlite.ErrNotFound) {
-			return Session{}, ErrInvalidCredentials
+			return domain.Owner{}, ErrInvalidCredentials
 		}
-		return Session{}, err
+		return domain.Owner{}, err
 	}
-	if credentials.Owner.Username != strings.TrimSpace(username) {
-		return Session{}, ErrInvalidCredentials
+	if credentials.Owner.Username != strings.TrimSpace(input.Username) {
+		return domain.Owner{}, ErrInvalidCredentials
 	}
-	if err := bcrypt.CompareHashAndPassword([]byte(credentials.PasswordHash), []byte(password)); err != nil {
-		return Session{}, ErrInvalidCredentials
+	if err := bcrypt.CompareHashAndPassword([]byte(credentials.PasswordHash), []byte(input.Password)); err != nil {
+		return domain.Owner{}, ErrInvalidCredentials
 	}
-	return s.createSession(ctx, credentials.Owner)
+	state, err := s.store.OwnerTOTP(ctx)
+	if err != nil {
+		return domain.Owner{}, err
+	}
+	if state.Secret != "" {
+		if err := s.checkSecondFactor(ctx, state.Secret, input.Code); err != nil {
+			return domain.Owner{}, err
+		}
+	}
+	return credentials.Owner, nil
 }
 
 func (s *Service) Authenticate(ctx context.Context, token string) (domain.Owner, error) {
 	if token == "" {
 		return domain.Owner{}, ErrInvalidSession
 	}
-	valid, err := s.store.SessionValid(ctx, token, s.now())
+	valid, err := s.store.TouchSession(ctx, token, s.now())
 	if err != nil {
 		return domain.Owner{}, err
 	}
@@ -131,6 +202,7 @@ func (s *Service) ChangePassword(
 	ctx context.Context,
 	currentPassword string,
 	newPassword string,
+	client Client,
 ) (Session, error) {
 	if !validPassword(newPassword) {
 		return Session{}, ErrInvalidInput
@@ -156,25 +228,48 @@ func (s *Service) ChangePassword(
 	if err != nil {
 		return Session{}, err
 	}
-	return s.createSession(ctx, credentials.Owner)
+	return s.createSession(ctx, credentials.Owner, client)
 }
 
-func (s *Service) createSession(ctx context.Context, owner domain.Owner) (Session, error) {
+func (s *Service) createSession(ctx context.Context, owner domain.Owner, client Client) (Session, error) {
 	if err := s.store.DeleteExpiredSessions(ctx, s.now()); err != nil {
 		return Session{}, err
 	}
-	bytes := make([]byte, 32)
-	if _, err := rand.Read(bytes); err != nil {
+	token, err := randomToken()
+	if err != nil {
 		return Session{}, err
 	}
-	token := base64.RawURLEncoding.EncodeToString(bytes)
 	expiresAt := s.now().UTC().AddDate(0, 0, s.sessionDays)
-	if err := s.store.CreateSession(ctx, token, expiresAt); err != nil {
+	err = s.store.CreateSession(ctx, token, domain.Session{
+		UserAgent: truncateUTF8(client.UserAgent, maximumUserAgentLen),
+		IP:        client.IP,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
 		return Session{}, err
 	}
 	return Session{Token: token, ExpiresAt: expiresAt, Owner: owner}, nil
 }
 
+func randomToken() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
+
+func truncateUTF8(value string, maxBytes int) string {
+	if len(value) <= maxBytes {
+		return value
+	}
+	value = value[:maxBytes]
+	for !utf8.ValidString(value) {
+		value = value[:len(value)-1]
+	}
+	return value
+}
+
 func validateSetup(input SetupInput) (domain.Owner, error) {
 	username := strings.TrimSpace(input.Username)
 	displayName := strings.TrimSpace(input.DisplayName)
@@ -1,9 +1,17 @@
 package auth
 
 import (
+	"context"
 	"errors"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/CoxxA/nomadbank/v2/internal/domain"
+	"github.com/CoxxA/nomadbank/v2/internal/sqlite"
 )
 
 func TestValidPasswordUsesRuneMinimumAndByteMaximum(t *testing.T) {
@@ -40,3 +48,325 @@ func TestValidateSetupRejectsLongDisplayName(t *testing.T) {
 		t.Fatalf("expected ErrInvalidInput, got %v", err)
 	}
 }
+
+func TestTOTPCodeMatchesRFC6238Vectors(t *testing.T) {
+	// RFC 6238 appendix B, SHA-1, truncated to six digits.
+	key := []byte("12345678901234567890")
+	vectors := map[int64]string{
+		59:          "287082",
+		1111111109:  "081804",
+		1111111111:  "050471",
+		1234567890:  "005924",
+		2000000000:  "279037",
+		20000000000: "353130",
+	}
+	for unix, want := range vectors {
+		if got := totpCode(key, unix/totpPeriod); got != want {
+			t.Errorf("totpCode at %d = %s, want %s", unix, got, want)
+		}
+	}
+}
+
+func TestTwoFactorLogin(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 5, 1, 8, 0, 0, 0, time.UTC)
+	service := newTestService(t, func() time.Time { return now })
+	const password = "very-safe-password"
+	login := func(code string) error {
+		t.Helper()
+		_, err := service.Login(ctx, LoginInput{Username: "owner", Password: password, Code: code}, Client{})
+		return err
+	}
+	currentCode := func(secret string) string {
+		t.Helper()
+		key, err := base32NoPadding.DecodeString(secret)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return totpCode(key, now.Unix()/totpPeriod)
+	}
+
+	if _, err := service.BeginTOTPEnrollment(ctx, "wrong-password"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("expected enrollment to require the password, got %v", err)
+	}
+	enrollment, err := service.BeginTOTPEnrollment(ctx, password)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(enrollment.ProvisioningURI, "otpauth://totp/NomadBank:owner?") ||
+		!strings.Contains(enrollment.ProvisioningURI, "secret="+enrollment.Secret) {
+		t.Fatalf("unexpected provisioning URI %q", enrollment.ProvisioningURI)
+	}
+	if err := login(""); err != nil {
+		t.Fatalf("unconfirmed enrollment must not require a code: %v", err)
+	}
+	if _, err := service.ConfirmTOTP(ctx, "000000"); !errors.Is(err, ErrInvalidSecondFactor) {
+		t.Fatalf("expected wrong confirmation code to fail, got %v", err)
+	}
+	recoveryCodes, err := service.ConfirmTOTP(ctx, currentCode(enrollment.Secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recoveryCodes) != recoveryCodeCount {
+		t.Fatalf("expected %d recovery codes, got %d", recoveryCodeCount, len(recoveryCodes))
+	}
+
+	if err := login(""); !errors.Is(err, ErrSecondFactorRequired) {
+		t.Fatalf("expected second factor to be required, got %v", err)
+	}
+	if _, err := service.Login(ctx, LoginInput{Username: "owner", Password: "wrong-password", Code: "123456"}, Client{}); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("expected wrong password to fail before the code is checked, got %v", err)
+	}
+	if err := login(currentCode(enrollment.Secret)); !errors.Is(err, ErrInvalidSecondFactor) {
+		t.Fatalf("expected the confirmation code to be single use, got %v", err)
+	}
+	now = now.Add(totpPeriod * time.Second)
+	if err := login(currentCode(enrollment.Secret)); err != nil {
+		t.Fatalf("expected next code to log in: %v", err)
+	}
+
+	recovery := strings.ToLower(strings.ReplaceAll(recoveryCodes[0], "-", ""))
+	if err := login(recovery); err != nil {
+		t.Fatalf("expected recovery code to log in: %v", err)
+	}
+	if err := login(recovery); !errors.Is(err, ErrInvalidSecondFactor) {
+		t.Fatalf("expected recovery code to be single use, got %v", err)
+	}
+	status, err := service.TOTPStatus(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !status.Enabled || status.RecoveryCodesRemaining != recoveryCodeCount-1 {
+		t.Fatalf("unexpected status %+v", status)
+	}
+
+	regenerated, err := service.RegenerateRecoveryCodes(ctx, password)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := login(recoveryCodes[1]); !errors.Is(err, ErrInvalidSecondFactor) {
+		t.Fatalf("expected old recovery codes to be replaced, got %v", err)
+	}
+	if err := login(regenerated[0]); err != nil {
+		t.Fatalf("expected new recovery code to log in: %v", err)
+	}
+
+	if err := service.DisableTOTP(ctx, "wrong-password"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("expected disabling to require the password, got %v", err)
+	}
+	if err := service.DisableTOTP(ctx, password); err != nil {
+		t.Fatal(err)
+	}
+	if err := login(""); err != nil {
+		t.Fatalf("expected password-only login after disabling: %v", err)
+	}
+}
+
+func TestLoginThrottleBacksOffAndLocks(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 5, 1, 8, 0, 0, 0, time.UTC)
+	service := newTestService(t, func() time.Time { return now })
+	login := func(password, ip string) error {
+		t.Helper()
+		_, err := service.Login(ctx, LoginInput{Username: "owner", Password: password}, Client{IP: ip})
+		return err
+	}
+	for attempt := 1; attempt <= loginFreeFailures; attempt++ {
+		if err := login("wrong-password", "198.51.100."+strconv.Itoa(attempt)); !errors.Is(err, ErrInvalidCredentials) {
+			t.Fatalf("attempt %d: expected invalid credentials, got %v", attempt, err)
+		}
+	}
+	// Changing the address does not help, and the right password is refused
+	// as well while throttled.
+	var throttled *LoginThrottledError
+	if err := login("very-safe-password", "203.0.113.1"); !errors.As(err, &throttled) || throttled.Locked {
+		t.Fatalf("expected backoff after %d failures, got %v", loginFreeFailures, err)
+	}
+	if want := now.Add(loginBackoffBase); !throttled.RetryAt.Equal(want) {
+		t.Fatalf("retry at %s, want %s", throttled.RetryAt, want)
+	}
+
+	for failures := loginFreeFailures; failures < loginLockoutFailures; failures++ {
+		delay, _ := loginDelay(failures)
+		now = now.Add(delay)
+		if err := login("wrong-password", "203.0.113.1"); !errors.Is(err, ErrInvalidCredentials) {
+			t.Fatalf("failure %d: expected invalid credentials after waiting, got %v", failures+1, err)
+		}
+	}
+	now = now.Add(loginLockoutDuration - time.Second)
+	throttle, err := service.LoginThrottle(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !throttle.Locked || throttle.Failures != loginLockoutFailures {
+		t.Fatalf("expected lockout, got %+v", throttle)
+	}
+	if err := login("very-safe-password", "203.0.113.1"); !errors.Is(err, ErrLoginThrottled) {
+		t.Fatalf("expected locked login to be refused, got %v", err)
+	}
+
+	now = now.Add(time.Second)
+	if err := login("very-safe-password", "203.0.113.1"); err != nil {
+		t.Fatalf("expected login after the lockout: %v", err)
+	}
+	if throttle, err := service.LoginThrottle(ctx); err != nil || throttle.Failures != 0 {
+		t.Fatalf("expected a successful login to reset failures, got %+v %v", throttle, err)
+	}
+	attempts, err := service.LoginActivity(ctx, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(attempts) != 3 || !attempts[0].Succeeded || attempts[1].Succeeded || attempts[0].IP != "203.0.113.1" {
+		t.Fatalf("unexpected login activity %+v", attempts)
+	}
+}
+
+func TestConcurrentLoginsCannotOutrunThrottle(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 5, 1, 8, 0, 0, 0, time.UTC)
+	service := newTestService(t, func() time.Time { return now })
+
+	const attempts = 4 * loginFreeFailures
+	errs := make(chan error, attempts)
+	var wg sync.WaitGroup
+	for attempt := range attempts {
+		wg.Go(func() {
+			_, err := service.Login(ctx, LoginInput{Username: "owner", Password: "wrong-password"},
+				Client{IP: "198.51.100." + strconv.Itoa(attempt)})
+			errs <- err
+		})
+	}
+	wg.Wait()
+	close(errs)
+	var invalid, throttled int
+	for err := range errs {
+		switch {
+		case errors.Is(err, ErrInvalidCredentials):
+			invalid++
+		case errors.Is(err, ErrLoginThrottled):
+			throttled++
+		default:
+			t.Fatalf("unexpected login error: %v", err)
+		}
+	}
+	if invalid != loginFreeFailures || throttled != attempts-loginFreeFailures {
+		t.Fatalf("expected %d password checks and the rest throttled, got %d and %d", loginFreeFailures, invalid, throttled)
+	}
+	recorded, err := service.LoginActivity(ctx, attempts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recorded) != loginFreeFailures {
+		t.Fatalf("expected %d recorded failures, got %d", loginFreeFailures, len(recorded))
+	}
+}
+
+func TestTaskLinksCompleteOnceUntilPasswordChange(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 5, 1, 8, 0, 0, 0, time.UTC)
+	service := newTestService(t, func() time.Time { return now })
+	taskIDs := createTestTasks(t, service.store, 2)
+
+	token, expiresAt, err := service.IssueTaskLink(ctx, taskIDs[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !expiresAt.Equal(now.Add(TaskLinkTTL)) {
+		t.Fatalf("link expires at %s", expiresAt)
+	}
+	tampered := []byte(token)
+	tampered[3] ^= 1
+	if _, err := service.VerifyTaskLink(ctx, string(tampered)); !errors.Is(err, ErrInvalidTaskLink) {
+		t.Fatalf("expected a tampered link to fail, got %v", err)
+	}
+	if link, err := service.VerifyTaskLink(ctx, token); err != nil || link.TaskID != taskIDs[0] {
+		t.Fatalf("expected a valid link for task %d, got %+v %v", taskIDs[0], link, err)
+	}
+	task, completed, err := service.CompleteTaskLink(ctx, token, now)
+	if err != nil || !completed || task.Status != domain.TaskStatusCompleted {
+		t.Fatalf("expected the task to be completed: %+v %v %v", task, completed, err)
+	}
+	if _, _, err := service.CompleteTaskLink(ctx, token, now); !errors.Is(err, ErrInvalidTaskLink) {
+		t.Fatalf("expected a used link to fail, got %v", err)
+	}
+
+	expiring, _, err := service.IssueTaskLink(ctx, taskIDs[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	revoked, _, err := service.IssueTaskLink(ctx, taskIDs[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(TaskLinkTTL)
+	if _, err := service.VerifyTaskLink(ctx, expiring); !errors.Is(err, ErrInvalidTaskLink) {
+		t.Fatalf("expected an expired link to fail, got %v", err)
+	}
+	now = now.Add(-time.Minute)
+	if _, err := service.ChangePassword(ctx, "very-safe-password", "another-safe-password", Client{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := service.CompleteTaskLink(ctx, revoked, now); !errors.Is(err, ErrInvalidTaskLink) {
+		t.Fatalf("expected a password change to revoke the link, got %v", err)
+	}
+}
+
+// createTestTasks adds a batch of pending tasks and returns their IDs.
+func createTestTasks(t *testing.T, store *sqlite.Store, count int) []int64 {
+	t.Helper()
+	ctx := context.Background()
+	from := &domain.Account{Name: "招商银行", Active: true}
+	to := &domain.Account{Name: "工商银行", Active: true}
+	for _, account := range []*domain.Account{from, to} {
+		if err := store.CreateAccount(ctx, account); err != nil {
+			t.Fatal(err)
+		}
+	}
+	strategy := &domain.Strategy{
+		Name: "测试", IntervalMinDays: 1, IntervalMaxDays: 2, TimeStartMinutes: 540, TimeEndMinutes: 1080,
+		AmountMinCents: 100, AmountMaxCents: 200, DailyLimit: 1,
+	}
+	if err := store.CreateStrategy(ctx, strategy); err != nil {
+		t.Fatal(err)
+	}
+	drafts := make([]domain.TaskDraft, 0, count)
+	for index := range count {
+		drafts = append(drafts, domain.TaskDraft{
+			CycleNo: 1, ScheduledAt: time.Date(2026, 5, 2+index, 9, 0, 0, 0, time.UTC),
+			FromAccountID: from.ID, ToAccountID: to.ID, AmountCents: 150,
+		})
+	}
+	batch, err := store.CreateTaskBatch(ctx, *strategy, "", 1, drafts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	page, err := store.ListTasks(ctx, sqlite.TaskFilter{BatchID: batch.ID, Page: 1, PageSize: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := make([]int64, 0, count)
+	for _, task := range page.Items {
+		ids = append(ids, task.ID)
+	}
+	return ids
+}
+
+func newTestService(t *testing.T, now func() time.Time) *Service {
+	t.Helper()
+	store, err := sqlite.Open(filepath.Join(t.TempDir(), "auth.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := store.Close(); err != nil {
+			t.Errorf("close database: %v", err)
+		}
+	})
+	service := NewService(store, 30)
+	service.now = now
+	input := SetupInput{Username: "owner", Password: "very-safe-password"}
+	if _, err := service.Setup(context.Background(), input, Client{}); err != nil {
+		t.Fatal(err)
+	}
+	return service
+}
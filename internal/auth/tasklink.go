@@ -0,0 +1,132 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/CoxxA/nomadbank/v2/internal/domain"
+	"github.com/CoxxA/nomadbank/v2/internal/sqlite"
+)
+
+// TaskLinkTTL bounds how long a completion link works. Links are meant to be
+// opened from the reminder they were sent with.
+const TaskLinkTTL = 24 * time.Hour
+
+const (
+	taskLinkPayloadSize = 8 + 8 + 16
+	taskLinkMACSize     = 16
+)
+
+// ErrInvalidTaskLink covers forged, expired, used and revoked links alike,
+// so the page does not tell them apart.
+var ErrInvalidTaskLink = errors.New("链接无效、已使用或已过期")
+
+// TaskLink is a verified completion link.
+type TaskLink struct {
+	TaskID    int64
+	ExpiresAt time.Time
+	nonce     string
+}
+
+// IssueTaskLink returns a token that completes the task once without a
+// session: the task ID, expiry and a random nonce, followed by a truncated
+// HMAC-SHA256. The key is derived from the owner's link secret and password
+// hash, so changing the password revokes every link.
+func (s *Service) IssueTaskLink(ctx context.Context, taskID int64) (string, time.Time, error) {
+	key, err := s.taskLinkKey(ctx)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	expiresAt := s.now().Add(TaskLinkTTL).Truncate(time.Second)
+	payload := make([]byte, taskLinkPayloadSize, taskLinkPayloadSize+taskLinkMACSize)
+	binary.BigEndian.PutUint64(payload[0:8], uint64(taskID))
+	binary.BigEndian.PutUint64(payload[8:16], uint64(expiresAt.Unix()))
+	if _, err := rand.Read(payload[16:]); err != nil {
+		return "", time.Time{}, err
+	}
+	token := append(payload, signTaskLink(key, payload)...)
+	return base64.RawURLEncoding.EncodeToString(token), expiresAt, nil
+}
+
+// VerifyTaskLink checks a token's signature and expiry and that it was not
+// used yet.
+func (s *Service) VerifyTaskLink(ctx context.Context, token string) (TaskLink, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(decoded) != taskLinkPayloadSize+taskLinkMACSize {
+		return TaskLink{}, ErrInvalidTaskLink
+	}
+	key, err := s.taskLinkKey(ctx)
+	if errors.Is(err, sqlite.ErrNotFound) {
+		return TaskLink{}, ErrInvalidTaskLink
+	}
+	if err != nil {
+		return TaskLink{}, err
+	}
+	payload := decoded[:taskLinkPayloadSize]
+	if !hmac.Equal(decoded[taskLinkPayloadSize:], signTaskLink(key, payload)) {
+		return TaskLink{}, ErrInvalidTaskLink
+	}
+	link := TaskLink{
+		TaskID:    int64(binary.BigEndian.Uint64(payload[0:8])),
+		ExpiresAt: time.Unix(int64(binary.BigEndian.Uint64(payload[8:16])), 0).UTC(),
+		nonce:     hex.EncodeToString(payload[16:]),
+	}
+	if !s.now().Before(link.ExpiresAt) {
+		return TaskLink{}, ErrInvalidTaskLink
+	}
+	used, err := s.store.TaskLinkUsed(ctx, link.nonce)
+	if err != nil {
+		return TaskLink{}, err
+	}
+	if used {
+		return TaskLink{}, ErrInvalidTaskLink
+	}
+	return link, nil
+}
+
+// CompleteTaskLink uses a link and completes its task, reporting like
+// Store.CompleteTask whether the task was still pending.
+func (s *Service) CompleteTaskLink(ctx context.Context, token string, completedAt time.Time) (domain.Task, bool, error) {
+	link, err := s.VerifyTaskLink(ctx, token)
+	if err != nil {
+		return domain.Task{}, false, err
+	}
+	var task domain.Task
+	var completed bool
+	err = s.store.WithTx(ctx, func(tx *sqlite.Store) error {
+		fresh, err := tx.UseTaskLink(ctx, link.nonce, link.TaskID, link.ExpiresAt, s.now())
+		if err != nil {
+			return err
+		}
+		if !fresh {
+			return ErrInvalidTaskLink
+		}
+		task, completed, err = tx.CompleteTask(ctx, link.TaskID, completedAt)
+		return err
+	})
+	return task, completed, err
+}
+
+func (s *Service) taskLinkKey(ctx context.Context) ([]byte, error) {
+	secret, passwordHash, err := s.store.TaskLinkSecrets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("nomadbank task link\x00"))
+	mac.Write([]byte(passwordHash))
+	return mac.Sum(nil), nil
+}
+
+func signTaskLink(key, payload []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(payload)
+	return mac.Sum(nil)[:taskLinkMACSize]
+}
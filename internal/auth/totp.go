@@ -0,0 +1,277 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/CoxxA/nomadbank/v2/internal/sqlite"
+)
+
+// RFC 6238 parameters understood by every common authenticator app.
+const (
+	totpIssuer        = "NomadBank"
+	totpPeriod        = 30
+	totpDigits        = 6
+	totpModulus       = 1_000_000 // 10^totpDigits
+	totpSkewSteps     = 1
+	totpSecretBytes   = 20
+	recoveryCodeCount = 10
+	recoveryCodeBytes = 10
+)
+
+var (
+	ErrSecondFactorRequired = errors.New("需要两步验证码")
+	ErrInvalidSecondFactor  = errors.New("两步验证码错误")
+	ErrTOTPEnabled          = errors.New("两步验证已开启")
+	ErrTOTPNotEnabled       = errors.New("两步验证未开启")
+	ErrNoPendingTOTP        = errors.New("没有待确认的两步验证")
+)
+
+var base32NoPadding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+type TOTPStatus struct {
+	Enabled                bool
+	RecoveryCodesRemaining int
+}
+
+// TOTPEnrollment is shown to the owner once so the secret can be added to an
+// authenticator app, either from the otpauth:// URI or by typing the secret.
+type TOTPEnrollment struct {
+	Secret          string
+	ProvisioningURI string
+}
+
+func (s *Service) TOTPStatus(ctx context.Context) (TOTPStatus, error) {
+	state, err := s.store.OwnerTOTP(ctx)
+	if err != nil {
+		return TOTPStatus{}, err
+	}
+	if state.Secret == "" {
+		return TOTPStatus{}, nil
+	}
+	remaining, err := s.store.CountRecoveryCodes(ctx)
+	if err != nil {
+		return TOTPStatus{}, err
+	}
+	return TOTPStatus{Enabled: true, RecoveryCodesRemaining: remaining}, nil
+}
+
+// BeginTOTPEnrollment creates a new pending secret. Two-factor login stays
+// off until ConfirmTOTP accepts a code generated from it.
+func (s *Service) BeginTOTPEnrollment(ctx context.Context, password string) (TOTPEnrollment, error) {
+	credentials, err := s.verifyPassword(ctx, password)
+	if err != nil {
+		return TOTPEnrollment{}, err
+	}
+	state, err := s.store.OwnerTOTP(ctx)
+	if err != nil {
+		return TOTPEnrollment{}, err
+	}
+	if state.Secret != "" {
+		return TOTPEnrollment{}, ErrTOTPEnabled
+	}
+	raw := make([]byte, totpSecretBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return TOTPEnrollment{}, err
+	}
+	secret := base32NoPadding.EncodeToString(raw)
+	if err := s.store.SetPendingTOTPSecret(ctx, secret); err != nil {
+		return TOTPEnrollment{}, err
+	}
+	return TOTPEnrollment{
+		Secret:          secret,
+		ProvisioningURI: provisioningURI(secret, credentials.Owner.Username),
+	}, nil
+}
+
+// ConfirmTOTP turns on two-factor login when code matches the pending secret
+// and returns the first set of recovery codes.
+func (s *Service) ConfirmTOTP(ctx context.Context, code string) ([]string, error) {
+	state, err := s.store.OwnerTOTP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if state.Secret != "" {
+		return nil, ErrTOTPEnabled
+	}
+	if state.PendingSecret == "" {
+		return nil, ErrNoPendingTOTP
+	}
+	step, ok := matchTOTP(state.PendingSecret, code, s.now())
+	if !ok {
+		return nil, ErrInvalidSecondFactor
+	}
+	codes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	err = s.store.WithTx(ctx, func(tx *sqlite.Store) error {
+		if err := tx.EnableTOTP(ctx, step); err != nil {
+			return err
+		}
+		return tx.ReplaceRecoveryCodes(ctx, codes)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func (s *Service) DisableTOTP(ctx context.Context, password string) error {
+	if _, err := s.verifyPassword(ctx, password); err != nil {
+		return err
+	}
+	return s.store.WithTx(ctx, func(tx *sqlite.Store) error {
+		return tx.DisableTOTP(ctx)
+	})
+}
+
+// RegenerateRecoveryCodes replaces every recovery code, used or not.
+func (s *Service) RegenerateRecoveryCodes(ctx context.Context, password string) ([]string, error) {
+	if _, err := s.verifyPassword(ctx, password); err != nil {
+		return nil, err
+	}
+	state, err := s.store.OwnerTOTP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if state.Secret == "" {
+		return nil, ErrTOTPNotEnabled
+	}
+	codes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	err = s.store.WithTx(ctx, func(tx *sqlite.Store) error {
+		return tx.ReplaceRecoveryCodes(ctx, codes)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// checkSecondFactor accepts either the current TOTP code or an unused
+// recovery code. Both are single use.
+func (s *Service) checkSecondFactor(ctx context.Context, secret, code string) error {
+	if strings.TrimSpace(code) == "" {
+		return ErrSecondFactorRequired
+	}
+	if step, ok := matchTOTP(secret, code, s.now()); ok {
+		fresh, err := s.store.UseTOTPStep(ctx, step)
+		if err != nil {
+			return err
+		}
+		if fresh {
+			return nil
+		}
+		return ErrInvalidSecondFactor
+	}
+	recovery := normalizeRecoveryCode(code)
+	if len(recovery) != base32NoPadding.EncodedLen(recoveryCodeBytes) {
+		return ErrInvalidSecondFactor
+	}
+	used, err := s.store.UseRecoveryCode(ctx, formatRecoveryCode(recovery), s.now())
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrInvalidSecondFactor
+	}
+	return nil
+}
+
+func (s *Service) verifyPassword(ctx context.Context, password string) (sqlite.OwnerCredentials, error) {
+	credentials, err := s.store.OwnerCredentials(ctx)
+	if err != nil {
+		return sqlite.OwnerCredentials{}, err
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(credentials.PasswordHash), []byte(password)); err != nil {
+		return sqlite.OwnerCredentials{}, ErrInvalidCredentials
+	}
+	return credentials, nil
+}
+
+// matchTOTP returns the time step whose code equals code, allowing one step
+// of clock drift in either direction.
+func matchTOTP(secret, code string, now time.Time) (int64, bool) {
+	code = strings.ReplaceAll(strings.TrimSpace(code), " ", "")
+	if len(code) != totpDigits {
+		return 0, false
+	}
+	key, err := base32NoPadding.DecodeString(secret)
+	if err != nil {
+		return 0, false
+	}
+	current := now.Unix() / totpPeriod
+	for step := current - totpSkewSteps; step <= current+totpSkewSteps; step++ {
+		if subtle.ConstantTimeCompare([]byte(totpCode(key, step)), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// totpCode implements the HOTP truncation of RFC 4226 over the RFC 6238 step.
+func totpCode(key []byte, step int64) string {
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", totpDigits, value%totpModulus)
+}
+
+func provisioningURI(secret, username string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", totpIssuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(totpDigits))
+	query.Set("period", fmt.Sprint(totpPeriod))
+	return (&url.URL{
+		Scheme:   "otpauth",
+		Host:     "totp",
+		Path:     "/" + totpIssuer + ":" + username,
+		RawQuery: query.Encode(),
+	}).String()
+}
+
+func newRecoveryCodes() ([]string, error) {
+	codes := make([]string, recoveryCodeCount)
+	raw := make([]byte, recoveryCodeBytes)
+	for index := range codes {
+		if _, err := rand.Read(raw); err != nil {
+			return nil, err
+		}
+		codes[index] = formatRecoveryCode(base32NoPadding.EncodeToString(raw))
+	}
+	return codes, nil
+}
+
+// formatRecoveryCode groups the 16 characters as XXXX-XXXX-XXXX-XXXX.
+func formatRecoveryCode(code string) string {
+	groups := make([]string, 0, len(code)/4)
+	for start := 0; start < len(code); start += 4 {
+		groups = append(groups, code[start:min(start+4, len(code))])
+	}
+	return strings.Join(groups, "-")
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(strings.TrimSpace(code)))
+}
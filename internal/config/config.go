@@ -2,32 +2,97 @@ package config
 
 import (
 	"fmt"
+	"net"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
 	"strconv"
 	"strings"
 )
 
 type Config struct {
-	Port        int
-	DataDir     string
-	SessionDays int
+	// Listen overrides Port with "host:port" or "unix:/path/to.sock".
+	Listen string
+	// SocketMode is applied to the unix socket file.
+	SocketMode os.FileMode
+	// BasePath serves everything below a sub-path such as "/nomadbank". It
+	// has a leading slash and no trailing slash; empty serves at the root.
+	BasePath string
+	// PublicURL is the absolute URL users reach the app at, BasePath
+	// included, e.g. "https://home.example/nomadbank". Reminders only carry
+	// completion links when it is set.
+	PublicURL     string
+	Port          int
+	DataDir       string
+	SessionDays   int
+	APIValidation string
+	// AuditRetentionDays of zero keeps audit events forever.
+	AuditRetentionDays int
+	// MetricsAddress serves /metrics on a separate listener, e.g.
+	// "127.0.0.1:9464". MetricsToken requires it as a Bearer token. Metrics
+	// are disabled when both are empty.
+	MetricsAddress string
+	MetricsToken   string
+	LogLevel       string
+	LogFormat      string
+	// TrustedProxies are the reverse proxies whose X-Forwarded-For,
+	// X-Real-IP and X-Forwarded-Proto headers are believed. Empty trusts none.
+	TrustedProxies []*net.IPNet
+	// TLSCertFile and TLSKeyFile switch the listener to HTTPS.
+	// HTTPRedirectAddress, e.g. ":80", then redirects plain HTTP to it.
+	TLSCertFile         string
+	TLSKeyFile          string
+	HTTPRedirectAddress string
+
+	// settings records where Load found each value, for `config print`.
+	settings []Setting
 }
 
-func Load() (Config, error) {
-	port, err := envInt("PORT", 8080)
-	if err != nil {
-		return Config{}, err
-	}
-	sessionDays, err := envInt("SESSION_DAYS", 30)
+// API_VALIDATION modes for checking traffic against the OpenAPI contract.
+const (
+	APIValidationOff     = "off"
+	APIValidationReport  = "report"
+	APIValidationEnforce = "enforce"
+)
+
+// Options selects the layers Load reads. Each setting takes the first value
+// found in Flags, the environment, then File, and falls back to its default.
+type Options struct {
+	// File is an optional TOML or YAML config file.
+	File string
+	// Flags are command-line overrides keyed like the environment, e.g. "PORT".
+	Flags map[string]string
+}
+
+func Load(options Options) (Config, error) {
+	l, err := newLoader(options)
 	if err != nil {
 		return Config{}, err
 	}
 	config := Config{
-		Port:        port,
-		DataDir:     envString("DATA_DIR", "./data"),
-		SessionDays: sessionDays,
+		Listen:              l.string("LISTEN", ""),
+		SocketMode:          l.fileMode("LISTEN_SOCKET_MODE", 0o660),
+		BasePath:            strings.TrimRight(l.string("BASE_PATH", ""), "/"),
+		PublicURL:           strings.TrimRight(l.string("PUBLIC_URL", ""), "/"),
+		Port:                l.int("PORT", 8080),
+		DataDir:             l.string("DATA_DIR", "./data"),
+		SessionDays:         l.int("SESSION_DAYS", 30),
+		APIValidation:       l.string("API_VALIDATION", APIValidationOff),
+		AuditRetentionDays:  l.int("AUDIT_RETENTION_DAYS", 365),
+		MetricsAddress:      l.string("METRICS_ADDRESS", ""),
+		MetricsToken:        l.string("METRICS_TOKEN", ""),
+		LogLevel:            strings.ToLower(l.string("LOG_LEVEL", "info")),
+		LogFormat:           strings.ToLower(l.string("LOG_FORMAT", "text")),
+		TrustedProxies:      l.trustedProxies("TRUSTED_PROXIES"),
+		TLSCertFile:         l.string("TLS_CERT_FILE", ""),
+		TLSKeyFile:          l.string("TLS_KEY_FILE", ""),
+		HTTPRedirectAddress: l.string("HTTP_REDIRECT_ADDRESS", ""),
 	}
+	if err := l.finish(); err != nil {
+		return Config{}, err
+	}
+	config.settings = l.settings
 	if err := config.Validate(); err != nil {
 		return Config{}, err
 	}
@@ -38,39 +103,131 @@ func (c Config) Validate() error {
 	if c.Port < 1 || c.Port > 65535 {
 		return fmt.Errorf("PORT 必须在 1 到 65535 之间")
 	}
+	if socketPath, ok := c.UnixSocket(); ok {
+		if socketPath == "" {
+			return fmt.Errorf("LISTEN 的 unix 套接字路径不能为空")
+		}
+		if c.HTTPRedirectAddress != "" {
+			return fmt.Errorf("监听 unix 套接字时不能设置 HTTP_REDIRECT_ADDRESS")
+		}
+	} else if c.Listen != "" {
+		_, port, err := net.SplitHostPort(c.Listen)
+		if parsed, convErr := strconv.Atoi(port); err != nil || convErr != nil || parsed < 1 || parsed > 65535 {
+			return fmt.Errorf("LISTEN 必须是 host:port 或 unix:/path/to.sock")
+		}
+	}
+	if c.SocketMode&^os.ModePerm != 0 {
+		return fmt.Errorf("LISTEN_SOCKET_MODE 必须是 0000 到 0777 之间的八进制权限")
+	}
+	if c.BasePath != "" && (path.Clean(c.BasePath) != c.BasePath || !strings.HasPrefix(c.BasePath, "/") ||
+		c.BasePath == "/" || strings.ContainsAny(c.BasePath, "?#%\\ ")) {
+		return fmt.Errorf("BASE_PATH 必须是以 / 开头的路径，例如 /nomadbank")
+	}
+	if c.PublicURL != "" {
+		parsed, err := url.Parse(c.PublicURL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" ||
+			parsed.User != nil || parsed.RawQuery != "" || parsed.Fragment != "" {
+			return fmt.Errorf("PUBLIC_URL 必须是 http 或 https 的完整地址，例如 https://nomadbank.example")
+		}
+	}
 	if strings.TrimSpace(c.DataDir) == "" {
 		return fmt.Errorf("DATA_DIR 不能为空")
 	}
 	if c.SessionDays < 1 || c.SessionDays > 365 {
 		return fmt.Errorf("SESSION_DAYS 必须在 1 到 365 之间")
 	}
+	if c.AuditRetentionDays < 0 || c.AuditRetentionDays > 3650 {
+		return fmt.Errorf("AUDIT_RETENTION_DAYS 必须在 0 到 3650 之间")
+	}
+	if c.MetricsAddress != "" {
+		if _, port, err := net.SplitHostPort(c.MetricsAddress); err != nil || port == "" {
+			return fmt.Errorf("METRICS_ADDRESS 必须是 host:port，例如 127.0.0.1:9464")
+		}
+	}
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		return fmt.Errorf("TLS_CERT_FILE 和 TLS_KEY_FILE 必须同时设置")
+	}
+	if c.HTTPRedirectAddress != "" {
+		if !c.TLSEnabled() {
+			return fmt.Errorf("HTTP_REDIRECT_ADDRESS 需要同时设置 TLS_CERT_FILE 和 TLS_KEY_FILE")
+		}
+		if _, port, err := net.SplitHostPort(c.HTTPRedirectAddress); err != nil || port == "" {
+			return fmt.Errorf("HTTP_REDIRECT_ADDRESS 必须是 host:port，例如 :80")
+		}
+	}
+	if c.MetricsToken != "" && len(c.MetricsToken) < 16 {
+		return fmt.Errorf("METRICS_TOKEN 至少需要 16 个字符")
+	}
+	switch c.LogLevel {
+	case "", "debug", "info", "warn", "error":
+	default:
+		return fmt.Errorf("LOG_LEVEL 必须是 debug、info、warn 或 error")
+	}
+	switch c.LogFormat {
+	case "", "text", "json":
+	default:
+		return fmt.Errorf("LOG_FORMAT 必须是 text 或 json")
+	}
+	switch c.APIValidation {
+	case "", APIValidationOff, APIValidationReport, APIValidationEnforce:
+	default:
+		return fmt.Errorf("API_VALIDATION 必须是 off、report 或 enforce")
+	}
 	return nil
 }
 
+func (c Config) TLSEnabled() bool {
+	return c.TLSCertFile != "" && c.TLSKeyFile != ""
+}
+
+func (c Config) MetricsEnabled() bool {
+	return c.MetricsAddress != "" || c.MetricsToken != ""
+}
+
 func (c Config) DBPath() string {
 	return filepath.Join(c.DataDir, "nomadbank-v2.db")
 }
 
+// Address is the TCP address to listen on: LISTEN when it is host:port,
+// otherwise every interface on PORT.
 func (c Config) Address() string {
+	if _, ok := c.UnixSocket(); !ok && c.Listen != "" {
+		return c.Listen
+	}
 	return fmt.Sprintf(":%d", c.Port)
 }
 
-func envString(key, fallback string) string {
-	value := strings.TrimSpace(os.Getenv(key))
-	if value == "" {
-		return fallback
-	}
-	return value
+// UnixSocket returns the socket path when LISTEN is "unix:/path".
+func (c Config) UnixSocket() (string, bool) {
+	return strings.CutPrefix(c.Listen, "unix:")
 }
 
-func envInt(key string, fallback int) (int, error) {
-	value := strings.TrimSpace(os.Getenv(key))
-	if value == "" {
-		return fallback, nil
-	}
-	parsed, err := strconv.Atoi(value)
-	if err != nil {
-		return 0, fmt.Errorf("%s 必须是整数: %q", key, value)
+// parseTrustedProxies reads comma-separated CIDRs; a bare address is a
+// single-host range.
+func parseTrustedProxies(value string) ([]*net.IPNet, error) {
+	var ranges []*net.IPNet
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if !strings.Contains(item, "/") {
+			ip := net.ParseIP(item)
+			if ip == nil {
+				return nil, fmt.Errorf("TRUSTED_PROXIES 包含无效的地址: %q", item)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			ranges = append(ranges, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, ipRange, err := net.ParseCIDR(item)
+		if err != nil {
+			return nil, fmt.Errorf("TRUSTED_PROXIES 包含无效的 CIDR: %q", item)
+		}
+		ranges = append(ranges, ipRange)
 	}
-	return parsed, nil
+	return ranges, nil
 }
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -9,7 +11,7 @@ func TestLoadRejectsInvalidInteger(t *testing.T) {
 	t.Setenv("PORT", "not-a-number")
 	t.Setenv("SESSION_DAYS", "30")
 
-	_, err := Load()
+	_, err := Load(Options{})
 	if err == nil || !strings.Contains(err.Error(), "PORT") {
 		t.Fatalf("expected invalid PORT error, got %v", err)
 	}
@@ -20,6 +22,20 @@ func TestValidateRejectsInvalidOverrides(t *testing.T) {
 		{Port: 70_000, DataDir: "data", SessionDays: 30},
 		{Port: 8080, DataDir: " ", SessionDays: 30},
 		{Port: 8080, DataDir: "data", SessionDays: 0},
+		{Port: 8080, DataDir: "data", SessionDays: 30, APIValidation: "strict"},
+		{Port: 8080, DataDir: "data", SessionDays: 30, AuditRetentionDays: -1},
+		{Port: 8080, DataDir: "data", SessionDays: 30, MetricsAddress: "9464"},
+		{Port: 8080, DataDir: "data", SessionDays: 30, MetricsToken: "short"},
+		{Port: 8080, DataDir: "data", SessionDays: 30, LogLevel: "verbose"},
+		{Port: 8080, DataDir: "data", SessionDays: 30, LogFormat: "xml"},
+		{Port: 8080, DataDir: "data", SessionDays: 30, TLSCertFile: "cert.pem"},
+		{Port: 8080, DataDir: "data", SessionDays: 30, HTTPRedirectAddress: ":80"},
+		{Port: 8080, DataDir: "data", SessionDays: 30, Listen: "localhost"},
+		{Port: 8080, DataDir: "data", SessionDays: 30, Listen: "127.0.0.1:http"},
+		{Port: 8080, DataDir: "data", SessionDays: 30, Listen: "unix:"},
+		{Port: 8080, DataDir: "data", SessionDays: 30, SocketMode: 0o1777},
+		{Port: 8080, DataDir: "data", SessionDays: 30, PublicURL: "nomadbank.example"},
+		{Port: 8080, DataDir: "data", SessionDays: 30, PublicURL: "https://nomadbank.example/?x=1"},
 	}
 	for _, config := range tests {
 		if err := config.Validate(); err == nil {
@@ -27,3 +43,178 @@ func TestValidateRejectsInvalidOverrides(t *testing.T) {
 		}
 	}
 }
+
+func TestLoadParsesTrustedProxies(t *testing.T) {
+	t.Setenv("TRUSTED_PROXIES", "10.0.0.0/8, 172.18.0.2,fd00::/8")
+	config, err := Load(Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, ipRange := range config.TrustedProxies {
+		got = append(got, ipRange.String())
+	}
+	if strings.Join(got, " ") != "10.0.0.0/8 172.18.0.2/32 fd00::/8" {
+		t.Fatalf("unexpected trusted proxies: %v", got)
+	}
+
+	t.Setenv("TRUSTED_PROXIES", "10.0.0.0/33")
+	if _, err := Load(Options{}); err == nil || !strings.Contains(err.Error(), "TRUSTED_PROXIES") {
+		t.Fatalf("expected invalid CIDR error, got %v", err)
+	}
+}
+
+func TestListenAddress(t *testing.T) {
+	t.Setenv("PORT", "9000")
+	t.Setenv("LISTEN_SOCKET_MODE", "0600")
+	for listen, want := range map[string]string{
+		"":                  ":9000",
+		"127.0.0.1:8080":    "127.0.0.1:8080",
+		"unix:/run/nb.sock": "/run/nb.sock",
+	} {
+		t.Setenv("LISTEN", listen)
+		config, err := Load(Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := config.Address()
+		if path, ok := config.UnixSocket(); ok {
+			got = path
+		}
+		if got != want || config.SocketMode != 0o600 {
+			t.Errorf("LISTEN=%q: got %q (mode %o), want %q", listen, got, config.SocketMode, want)
+		}
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	secret := filepath.Join(dir, "metrics_token")
+	if err := os.WriteFile(secret, []byte("from-secret-file-0123\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"nomadbank.toml": `# comment
+port = 7000
+data_dir = "/from/file"
+session_days = 7 # trailing comment
+listen_socket_mode = 0o600
+trusted_proxies = ["10.0.0.0/8", '172.18.0.2']
+metrics_token_file = "` + secret + `"
+`,
+		"nomadbank.yaml": `port: 7000
+data_dir: /from/file
+session_days: 7
+listen_socket_mode: 0600
+trusted_proxies:
+  - 10.0.0.0/8
+  - 172.18.0.2
+metrics_token_file: ` + secret + `
+`,
+	}
+	for name, content := range files {
+		file := filepath.Join(dir, name)
+		if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		t.Setenv("PORT", "")
+		t.Setenv("DATA_DIR", "/from/env")
+		config, err := Load(Options{File: file, Flags: map[string]string{"PORT": "9000"}})
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if config.Port != 9000 || config.DataDir != "/from/env" || config.SessionDays != 7 ||
+			config.SocketMode != 0o600 || len(config.TrustedProxies) != 2 ||
+			config.MetricsToken != "from-secret-file-0123" || config.LogLevel != "info" {
+			t.Fatalf("%s: unexpected config %+v", name, config)
+		}
+
+		sources := make(map[string]Setting)
+		for _, setting := range config.Settings() {
+			sources[setting.Key] = setting
+		}
+		if sources["PORT"].Source != SourceFlag || sources["DATA_DIR"].Source != SourceEnv ||
+			sources["SESSION_DAYS"].Source != SourceFile || sources["LOG_LEVEL"].Source != SourceDefault {
+			t.Fatalf("%s: unexpected sources %v", name, sources)
+		}
+		if token := sources["METRICS_TOKEN"]; token.Value != redacted || !strings.Contains(token.Source, "metrics_token_file") {
+			t.Fatalf("%s: secret not redacted: %+v", name, token)
+		}
+
+		t.Setenv("METRICS_TOKEN", "from-environment-0123")
+		if config, err = Load(Options{File: file}); err != nil || config.MetricsToken != "from-environment-0123" {
+			t.Fatalf("%s: environment should override the file: %v %q", name, err, config.MetricsToken)
+		}
+		t.Setenv("METRICS_TOKEN", "")
+	}
+}
+
+func TestParseTOML(t *testing.T) {
+	values, err := parseTOML([]byte(`"data_dir" = "C:\\nomad\tbank \u00e9"
+public_url = 'https://example.com/\n'
+listen_socket_mode = 0o640
+port = 8_080
+metrics_token = """
+multi"""
+trusted_proxies = [
+  "10.0.0.0/8",  # comment
+  '172.18.0.2',
+]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[string]string{
+		"DATA_DIR":           "C:\\nomad\tbank é",
+		"PUBLIC_URL":         `https://example.com/\n`,
+		"LISTEN_SOCKET_MODE": "0640",
+		"PORT":               "8080",
+		"METRICS_TOKEN":      "multi",
+		"TRUSTED_PROXIES":    "10.0.0.0/8,172.18.0.2",
+	} {
+		if values[key] != want {
+			t.Errorf("%s = %q, want %q", key, values[key], want)
+		}
+	}
+
+	for content, want := range map[string]string{
+		"[server]\nport = 8080\n":               "不支持嵌套",
+		"[[servers]]\nport = 8080\n":            "不支持嵌套",
+		"server.port = 8080\n":                  "不支持嵌套",
+		"data_dir = { path = \"/data\" }\n":     "不支持嵌套",
+		"trusted_proxies = [[\"127.0.0.1\"]]\n": "列表只能包含标量",
+		"session_days = 30.5\n":                 "不支持浮点数",
+		"session_days = 2026-01-02\n":           "不支持日期时间",
+		"data_dir = \"\\q\"\n":                  "",
+		"port = 1\nport = 2\n":                  "",
+	} {
+		if _, err := parseTOML([]byte(content)); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("parseTOML(%q) error = %v, want %q", content, err, want)
+		}
+	}
+}
+
+func TestLoadRejectsBadConfigFiles(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"unknown.toml":  "prot = 8080\n",
+		"table.toml":    "[server]\nport = 8080\n",
+		"bare.toml":     "data_dir = /data\n",
+		"nested.yaml":   "server:\n  port: 8080\n",
+		"unknown.yaml":  "prot: 8080\n",
+		"format.json":   `{"port": 8080}`,
+		"duplicate.yml": "port: 1\nport: 2\n",
+	} {
+		file := filepath.Join(dir, name)
+		if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(Options{File: file}); err == nil {
+			t.Errorf("expected %s to be rejected", name)
+		}
+	}
+	t.Setenv("METRICS_TOKEN_FILE", filepath.Join(dir, "missing"))
+	if _, err := Load(Options{}); err == nil || !strings.Contains(err.Error(), "METRICS_TOKEN_FILE") {
+		t.Fatalf("expected missing secret file error, got %v", err)
+	}
+}
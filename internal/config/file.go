@@ -0,0 +1,166 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// fileKey is a setting name in a config file: the environment variable in
+// lower case, e.g. data_dir for DATA_DIR.
+var fileKey = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// readFile loads a flat TOML or YAML file, chosen by extension, into values
+// keyed like the environment. Lists become comma-separated values. Nested
+// tables are rejected since every setting is top-level.
+func readFile(name string) (map[string]string, error) {
+	content, err := os.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("读取配置文件: %w", err)
+	}
+	var values map[string]string
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".toml":
+		values, err = parseTOML(content)
+	case ".yaml", ".yml":
+		values, err = parseYAML(content)
+	default:
+		return nil, fmt.Errorf("配置文件 %s 必须是 .toml、.yaml 或 .yml", name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("解析配置文件 %s: %w", name, err)
+	}
+	return values, nil
+}
+
+func addValue(values map[string]string, key, value string) error {
+	if !fileKey.MatchString(key) {
+		return fmt.Errorf("配置项 %q 必须是小写字母、数字和下划线", key)
+	}
+	key = strings.ToUpper(key)
+	if _, ok := values[key]; ok {
+		return fmt.Errorf("配置项 %s 重复", strings.ToLower(key))
+	}
+	values[key] = value
+	return nil
+}
+
+// parseYAML keeps scalars as written, so 0660 stays octal text rather than
+// becoming the integer YAML would decode.
+func parseYAML(content []byte) (map[string]string, error) {
+	values := make(map[string]string)
+	var document yaml.Node
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return nil, err
+	}
+	if len(document.Content) == 0 {
+		return values, nil
+	}
+	root := document.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, errors.New("顶层必须是键值对")
+	}
+	for index := 0; index+1 < len(root.Content); index += 2 {
+		key, node := root.Content[index].Value, root.Content[index+1]
+		var value string
+		switch node.Kind {
+		case yaml.ScalarNode:
+			if node.Tag != "!!null" {
+				value = node.Value
+			}
+		case yaml.SequenceNode:
+			items := make([]string, 0, len(node.Content))
+			for _, item := range node.Content {
+				if item.Kind != yaml.ScalarNode {
+					return nil, fmt.Errorf("第 %d 行: %s 的列表只能包含标量", item.Line, key)
+				}
+				items = append(items, item.Value)
+			}
+			value = strings.Join(items, ",")
+		default:
+			return nil, fmt.Errorf("第 %d 行: %s 不支持嵌套", node.Line, key)
+		}
+		if err := addValue(values, key, value); err != nil {
+			return nil, fmt.Errorf("第 %d 行: %w", root.Content[index].Line, err)
+		}
+	}
+	return values, nil
+}
+
+// octalKeys are settings written in octal. A TOML integer such as 0o600 has
+// lost its base once decoded, so it is written back in octal for them.
+var octalKeys = map[string]bool{
+	"LISTEN_SOCKET_MODE": true,
+}
+
+// parseTOML decodes a TOML document whose settings are all top-level. Values
+// are kept as text the way the environment would spell them; tables, floats
+// and dates have no setting to go to and are rejected.
+func parseTOML(content []byte) (map[string]string, error) {
+	var document map[string]any
+	metadata, err := toml.Decode(string(content), &document)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]string)
+	seen := make(map[string]bool)
+	// Keys come in document order; a dotted key lists no parent table of its
+	// own, so each path is reduced to its top-level name.
+	for _, path := range metadata.Keys() {
+		key := path[0]
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		value, err := tomlValue(key, document[key], true)
+		if err == nil {
+			err = addValue(values, key, value)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+// tomlValue formats one decoded value. Arrays are only allowed at the top
+// level and become comma-separated values.
+func tomlValue(key string, value any, allowArray bool) (string, error) {
+	switch value := value.(type) {
+	case string:
+		return value, nil
+	case bool:
+		return strconv.FormatBool(value), nil
+	case int64:
+		if octalKeys[strings.ToUpper(key)] {
+			return fmt.Sprintf("%04o", value), nil
+		}
+		return strconv.FormatInt(value, 10), nil
+	case []any:
+		if !allowArray {
+			return "", fmt.Errorf("%s 的列表只能包含标量", key)
+		}
+		items := make([]string, 0, len(value))
+		for _, item := range value {
+			text, err := tomlValue(key, item, false)
+			if err != nil {
+				return "", err
+			}
+			items = append(items, text)
+		}
+		return strings.Join(items, ","), nil
+	case map[string]any, []map[string]any:
+		return "", fmt.Errorf("%s 不支持嵌套", key)
+	case float64:
+		return "", fmt.Errorf("%s 不支持浮点数", key)
+	default:
+		return "", fmt.Errorf("%s 不支持日期时间", key)
+	}
+}
@@ -0,0 +1,170 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Setting sources, as shown by `nomadbank config print`.
+const (
+	SourceDefault = "默认值"
+	SourceFile    = "配置文件"
+	SourceEnv     = "环境变量"
+	SourceFlag    = "命令行参数"
+)
+
+// secretKeys are redacted when printed. Each may also be read from the file
+// named by its *_FILE variant, in the environment or the config file, so it
+// can come from a Docker secret instead of a plain variable.
+var secretKeys = map[string]bool{
+	"METRICS_TOKEN": true,
+}
+
+const redacted = "******"
+
+// Setting is one effective value and where it came from.
+type Setting struct {
+	Key    string
+	Value  string
+	Source string
+}
+
+// Settings lists every value Load read, in a stable order, with secrets
+// redacted. It is empty for a Config built in code.
+func (c Config) Settings() []Setting {
+	settings := slices.Clone(c.settings)
+	for index, setting := range settings {
+		if secretKeys[setting.Key] && setting.Value != "" {
+			settings[index].Value = redacted
+		}
+	}
+	return settings
+}
+
+// loader resolves settings by key through the configured layers. The first
+// error is kept and reported by finish, so Load reads like a field list.
+type loader struct {
+	flags    map[string]string
+	file     map[string]string
+	fileName string
+	used     map[string]bool
+	settings []Setting
+	err      error
+}
+
+func newLoader(options Options) (*loader, error) {
+	l := &loader{flags: options.Flags, fileName: options.File, used: make(map[string]bool)}
+	if options.File != "" {
+		values, err := readFile(options.File)
+		if err != nil {
+			return nil, err
+		}
+		l.file = values
+	}
+	return l, nil
+}
+
+func (l *loader) fail(err error) {
+	if l.err == nil {
+		l.err = err
+	}
+}
+
+// finish reports the first error, including config file keys that no
+// setting read, which are most likely typos.
+func (l *loader) finish() error {
+	if l.err != nil {
+		return l.err
+	}
+	var unknown []string
+	for key := range l.file {
+		if !l.used[key] {
+			unknown = append(unknown, strings.ToLower(key))
+		}
+	}
+	if len(unknown) > 0 {
+		slices.Sort(unknown)
+		return fmt.Errorf("配置文件 %s 包含未知配置项: %s", l.fileName, strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// lookup applies the precedence flags > env > file. Empty environment
+// variables count as unset, as before config files existed.
+func (l *loader) lookup(key string) (string, string, bool) {
+	l.used[key] = true
+	if secretKeys[key] {
+		l.used[key+"_FILE"] = true
+	}
+	if value, ok := l.flags[key]; ok {
+		return value, SourceFlag, true
+	}
+	if value := strings.TrimSpace(os.Getenv(key)); value != "" {
+		return value, SourceEnv, true
+	}
+	if secretKeys[key] {
+		if name := strings.TrimSpace(os.Getenv(key + "_FILE")); name != "" {
+			return l.readSecret(key, name), SourceEnv + " " + key + "_FILE", true
+		}
+	}
+	if value, ok := l.file[key]; ok {
+		return value, SourceFile, true
+	}
+	if secretKeys[key] {
+		if name, ok := l.file[key+"_FILE"]; ok {
+			return l.readSecret(key, name), SourceFile + " " + strings.ToLower(key) + "_file", true
+		}
+	}
+	return "", "", false
+}
+
+func (l *loader) readSecret(key, name string) string {
+	content, err := os.ReadFile(name)
+	if err != nil {
+		l.fail(fmt.Errorf("读取 %s_FILE: %w", key, err))
+		return ""
+	}
+	return strings.TrimSpace(string(content))
+}
+
+func (l *loader) string(key, fallback string) string {
+	value, source, ok := l.lookup(key)
+	if !ok {
+		value, source = fallback, SourceDefault
+	}
+	l.settings = append(l.settings, Setting{Key: key, Value: value, Source: source})
+	return value
+}
+
+func (l *loader) int(key string, fallback int) int {
+	value := l.string(key, strconv.Itoa(fallback))
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		l.fail(fmt.Errorf("%s 必须是整数: %q", key, value))
+		return fallback
+	}
+	return parsed
+}
+
+// fileMode reads octal permissions such as 0660; TOML's 0o660 also works.
+func (l *loader) fileMode(key string, fallback os.FileMode) os.FileMode {
+	value := l.string(key, fmt.Sprintf("%04o", uint32(fallback)))
+	parsed, err := strconv.ParseUint(strings.TrimPrefix(value, "0o"), 8, 32)
+	if err != nil {
+		l.fail(fmt.Errorf("%s 必须是八进制权限，例如 0660: %q", key, value))
+		return fallback
+	}
+	return os.FileMode(parsed)
+}
+
+func (l *loader) trustedProxies(key string) []*net.IPNet {
+	ranges, err := parseTrustedProxies(l.string(key, ""))
+	if err != nil {
+		l.fail(err)
+	}
+	return ranges
+}
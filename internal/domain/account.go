@@ -0,0 +1,33 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	ErrInvalidAccountName = errors.New("账户名称需为 1～100 个字符")
+	ErrInvalidGroupName   = errors.New("分组名称不能超过 50 个字符")
+)
+
+// Length limits of an account name and group, in characters.
+const (
+	MaxAccountNameRunes = 100
+	MaxGroupNameRunes   = 50
+)
+
+// NormalizeAccountNames trims an account name and group and checks their
+// lengths, returning ErrInvalidAccountName or ErrInvalidGroupName. The API
+// and the command line both create accounts through it.
+func NormalizeAccountNames(name, group string) (string, string, error) {
+	name = strings.TrimSpace(name)
+	group = strings.TrimSpace(group)
+	if count := utf8.RuneCountInString(name); count < 1 || count > MaxAccountNameRunes {
+		return "", "", ErrInvalidAccountName
+	}
+	if utf8.RuneCountInString(group) > MaxGroupNameRunes {
+		return "", "", ErrInvalidGroupName
+	}
+	return name, group, nil
+}
@@ -0,0 +1,29 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeAccountNames(t *testing.T) {
+	name, group, err := NormalizeAccountNames("  工资卡 ", " 主账户 ")
+	if err != nil || name != "工资卡" || group != "主账户" {
+		t.Fatalf("unexpected result %q %q %v", name, group, err)
+	}
+	for _, test := range []struct {
+		name, group string
+		want        error
+	}{
+		{name: "   ", want: ErrInvalidAccountName},
+		{name: strings.Repeat("账", MaxAccountNameRunes+1), want: ErrInvalidAccountName},
+		{name: "账户", group: strings.Repeat("组", MaxGroupNameRunes+1), want: ErrInvalidGroupName},
+	} {
+		if _, _, err := NormalizeAccountNames(test.name, test.group); !errors.Is(err, test.want) {
+			t.Errorf("NormalizeAccountNames(%q, %q) = %v, want %v", test.name, test.group, err, test.want)
+		}
+	}
+	if _, _, err := NormalizeAccountNames(strings.Repeat("账", MaxAccountNameRunes), strings.Repeat("组", MaxGroupNameRunes)); err != nil {
+		t.Fatalf("names at the limits were rejected: %v", err)
+	}
+}
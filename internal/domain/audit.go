@@ -0,0 +1,79 @@
+package domain
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// AuditAction names a recorded mutation as "<resource type>.<verb>".
+type AuditAction string
+
+const (
+	AuditOwnerSetup          AuditAction = "owner.setup"
+	AuditOwnerUpdate         AuditAction = "owner.update"
+	AuditOwnerPasswordChange AuditAction = "owner.password_change"
+	AuditOwnerTOTPEnable     AuditAction = "owner.totp_enable"
+	AuditOwnerTOTPDisable    AuditAction = "owner.totp_disable"
+	AuditOwnerRecoveryCodes  AuditAction = "owner.recovery_codes_regenerate"
+	AuditSessionLogin        AuditAction = "session.login"
+	AuditSessionLogout       AuditAction = "session.logout"
+	AuditSessionRevoke       AuditAction = "session.revoke"
+	AuditSessionRevokeOthers AuditAction = "session.revoke_others"
+	AuditAPITokenCreate      AuditAction = "api_token.create"
+	AuditAPITokenDelete      AuditAction = "api_token.delete"
+	AuditNotificationsUpdate AuditAction = "notification_settings.update"
+	AuditEmailDigestUpdate   AuditAction = "email_digest.update"
+	AuditWebhookCreate       AuditAction = "webhook.create"
+	AuditWebhookUpdate       AuditAction = "webhook.update"
+	AuditWebhookDelete       AuditAction = "webhook.delete"
+	AuditAccountCreate       AuditAction = "account.create"
+	AuditAccountUpdate       AuditAction = "account.update"
+	AuditAccountDelete       AuditAction = "account.delete"
+	AuditStrategyCreate      AuditAction = "strategy.create"
+	AuditStrategyUpdate      AuditAction = "strategy.update"
+	AuditStrategyDelete      AuditAction = "strategy.delete"
+	AuditTaskBatchCreate     AuditAction = "task_batch.create"
+	AuditTaskBatchDelete     AuditAction = "task_batch.delete"
+	AuditTaskComplete        AuditAction = "task.complete"
+)
+
+// ResourceType is the part of the action before the dot, e.g. "account".
+func (a AuditAction) ResourceType() string {
+	resourceType, _, _ := strings.Cut(string(a), ".")
+	return resourceType
+}
+
+type AuditActorType string
+
+const (
+	AuditActorOwner    AuditActorType = "owner"
+	AuditActorAPIToken AuditActorType = "api_token"
+	// AuditActorTaskLink is the owner acting through a completion link.
+	AuditActorTaskLink AuditActorType = "task_link"
+	// AuditActorCLI is the owner acting through an offline subcommand.
+	AuditActorCLI AuditActorType = "cli"
+)
+
+// AuditEvent is an append-only record of a mutation. Before and After hold
+// the JSON of the resource around the change and are null when there is no
+// such state, e.g. Before for a creation.
+type AuditEvent struct {
+	ID           int64           `json:"id"`
+	Action       AuditAction     `json:"action"`
+	ResourceType string          `json:"resource_type"`
+	ResourceID   *int64          `json:"resource_id"`
+	ActorType    AuditActorType  `json:"actor_type"`
+	ActorName    string          `json:"actor_name"`
+	IP           string          `json:"ip"`
+	Before       json.RawMessage `json:"before"`
+	After        json.RawMessage `json:"after"`
+	CreatedAt    time.Time       `json:"created_at"`
+}
+
+type AuditPage struct {
+	Items    []AuditEvent `json:"items"`
+	Total    int64        `json:"total"`
+	Page     int          `json:"page"`
+	PageSize int          `json:"page_size"`
+}
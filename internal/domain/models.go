@@ -10,11 +10,53 @@ type Owner struct {
 	Timezone    string `json:"timezone"`
 }
 
+type APITokenScope string
+
+const (
+	APITokenScopeReadOnly  APITokenScope = "read_only"
+	APITokenScopeReadWrite APITokenScope = "read_write"
+)
+
+// APIToken describes a personal access token. The secret itself is only
+// returned once, when the token is created.
+type APIToken struct {
+	ID         int64         `json:"id"`
+	Name       string        `json:"name"`
+	Scope      APITokenScope `json:"scope"`
+	Prefix     string        `json:"prefix"`
+	ExpiresAt  time.Time     `json:"expires_at"`
+	LastUsedAt *time.Time    `json:"last_used_at"`
+	CreatedAt  time.Time     `json:"created_at"`
+}
+
+// Session describes a browser login. Current marks the session that made the
+// request.
+type Session struct {
+	ID         int64     `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	CreatedAt  time.Time `json:"created_at"`
+	Current    bool      `json:"current"`
+}
+
+// LoginAttempt is one password login, kept for a while so the owner can spot
+// guessing.
+type LoginAttempt struct {
+	ID        int64     `json:"id"`
+	Succeeded bool      `json:"succeeded"`
+	IP        string    `json:"ip"`
+	UserAgent string    `json:"user_agent"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
 type Account struct {
 	ID        int64     `json:"id"`
 	Name      string    `json:"name"`
 	GroupName string    `json:"group_name"`
 	Active    bool      `json:"active"`
+	Version   int64     `json:"version"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
@@ -30,6 +72,7 @@ type Strategy struct {
 	AmountMinCents   int64     `json:"amount_min_cents"`
 	AmountMaxCents   int64     `json:"amount_max_cents"`
 	DailyLimit       int       `json:"daily_limit"`
+	Version          int64     `json:"version"`
 	CreatedAt        time.Time `json:"created_at"`
 	UpdatedAt        time.Time `json:"updated_at"`
 }
@@ -62,6 +105,8 @@ type Task struct {
 	ToAccountName   string     `json:"to_account_name"`
 	AmountCents     int64      `json:"amount_cents"`
 	Status          TaskStatus `json:"status"`
+	Overdue         bool       `json:"overdue"`
+	DaysOverdue     int        `json:"days_overdue"`
 	CompletedAt     *time.Time `json:"completed_at"`
 	CreatedAt       time.Time  `json:"created_at"`
 }
@@ -85,6 +130,7 @@ type Dashboard struct {
 	TotalAccounts  int64  `json:"total_accounts"`
 	ActiveAccounts int64  `json:"active_accounts"`
 	PendingTasks   int64  `json:"pending_tasks"`
+	OverdueTasks   int64  `json:"overdue_tasks"`
 	CompletedTasks int64  `json:"completed_tasks"`
 	Strategies     int64  `json:"strategies"`
 	Upcoming       []Task `json:"upcoming"`
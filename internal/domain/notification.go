@@ -0,0 +1,92 @@
+package domain
+
+import "time"
+
+// ReminderKind distinguishes the reminders sent for one task.
+type ReminderKind string
+
+const (
+	// ReminderUpcoming is sent once the task is within the lead time.
+	ReminderUpcoming ReminderKind = "upcoming"
+	// ReminderOverdue is sent once the task's day has passed.
+	ReminderOverdue ReminderKind = "overdue"
+)
+
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliverySending   DeliveryStatus = "sending"
+	DeliverySent      DeliveryStatus = "sent"
+	DeliveryFailed    DeliveryStatus = "failed"
+	DeliveryCancelled DeliveryStatus = "cancelled"
+)
+
+// NotificationSettings configures task reminders. The webhook URL and secret
+// are credentials; the API returns them masked.
+type NotificationSettings struct {
+	Enabled       bool
+	LeadMinutes   int
+	WebhookURL    string
+	WebhookSecret string
+	UpdatedAt     time.Time
+}
+
+// NotificationDelivery is one queued reminder and its delivery state.
+type NotificationDelivery struct {
+	ID            int64          `json:"id"`
+	TaskID        int64          `json:"task_id"`
+	Kind          ReminderKind   `json:"kind"`
+	Status        DeliveryStatus `json:"status"`
+	Attempts      int            `json:"attempts"`
+	NextAttemptAt time.Time      `json:"next_attempt_at"`
+	LastError     string         `json:"last_error"`
+	SentAt        *time.Time     `json:"sent_at"`
+	CreatedAt     time.Time      `json:"created_at"`
+}
+
+// SMTPSecurity selects how the digest connects to the mail server.
+type SMTPSecurity string
+
+const (
+	// SMTPStartTLS upgrades a plain connection and fails if the server
+	// cannot, so credentials never cross the network in clear text.
+	SMTPStartTLS SMTPSecurity = "starttls"
+	// SMTPTLS connects with TLS from the start, usually on port 465.
+	SMTPTLS SMTPSecurity = "tls"
+	// SMTPNone is for relays on the same host or network.
+	SMTPNone SMTPSecurity = "none"
+)
+
+func (s SMTPSecurity) Valid() bool {
+	return s == SMTPStartTLS || s == SMTPTLS || s == SMTPNone
+}
+
+// EmailDigestSettings configures the daily email. SMTPPassword is
+// write-only: the API reports whether it is set but never returns it.
+// LastSentOn is the owner's local date of the last digest, as YYYY-MM-DD.
+type EmailDigestSettings struct {
+	Enabled       bool
+	SendMinutes   int
+	Recipient     string
+	Sender        string
+	SMTPHost      string
+	SMTPPort      int
+	SMTPSecurity  SMTPSecurity
+	SMTPUsername  string
+	SMTPPassword  string
+	DormancyDays  int
+	LastSentOn    string
+	LastAttemptAt *time.Time
+	LastError     string
+	UpdatedAt     time.Time
+}
+
+// IdleAccount is an active account and when money last moved through it:
+// its latest completed task, or its creation if it has none.
+type IdleAccount struct {
+	ID           int64     `json:"id"`
+	Name         string    `json:"name"`
+	GroupName    string    `json:"group_name"`
+	LastActiveAt time.Time `json:"last_active_at"`
+}
@@ -0,0 +1,26 @@
+package domain
+
+import "time"
+
+// OverdueCutoff returns the start of the current day in now's location.
+// Pending tasks scheduled before it are overdue; a task due later today is not.
+func OverdueCutoff(now time.Time) time.Time {
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+}
+
+// MarkOverdue sets Overdue and DaysOverdue using calendar days in now's
+// location, which should be the owner's timezone.
+func (t *Task) MarkOverdue(now time.Time) {
+	t.Overdue = false
+	t.DaysOverdue = 0
+	if t.Status != TaskStatusPending {
+		return
+	}
+	scheduled := t.ScheduledAt.In(now.Location())
+	scheduledDate := time.Date(scheduled.Year(), scheduled.Month(), scheduled.Day(), 0, 0, 0, 0, time.UTC)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if days := int(today.Sub(scheduledDate).Hours() / 24); days > 0 {
+		t.Overdue = true
+		t.DaysOverdue = days
+	}
+}
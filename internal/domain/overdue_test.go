@@ -0,0 +1,41 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMarkOverdueUsesOwnerCalendarDays(t *testing.T) {
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 2026-03-10 00:30 in Shanghai is still 2026-03-09 in UTC.
+	now := time.Date(2026, time.March, 10, 0, 30, 0, 0, shanghai)
+	tests := []struct {
+		name        string
+		scheduledAt time.Time
+		status      TaskStatus
+		overdue     bool
+		days        int
+	}{
+		{"earlier today", time.Date(2026, time.March, 10, 0, 10, 0, 0, shanghai), TaskStatusPending, false, 0},
+		{"yesterday evening", time.Date(2026, time.March, 9, 23, 0, 0, 0, shanghai), TaskStatusPending, true, 1},
+		{"last week", time.Date(2026, time.March, 3, 9, 0, 0, 0, shanghai), TaskStatusPending, true, 7},
+		{"completed late", time.Date(2026, time.March, 3, 9, 0, 0, 0, shanghai), TaskStatusCompleted, false, 0},
+		{"tomorrow", time.Date(2026, time.March, 11, 9, 0, 0, 0, shanghai), TaskStatusPending, false, 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			task := Task{ScheduledAt: test.scheduledAt.UTC(), Status: test.status}
+			task.MarkOverdue(now)
+			if task.Overdue != test.overdue || task.DaysOverdue != test.days {
+				t.Fatalf("got overdue=%t days=%d, want %t %d", task.Overdue, task.DaysOverdue, test.overdue, test.days)
+			}
+			if cutoff := OverdueCutoff(now); test.status == TaskStatusPending &&
+				test.scheduledAt.Before(cutoff) != test.overdue {
+				t.Fatalf("cutoff %s disagrees with MarkOverdue", cutoff)
+			}
+		})
+	}
+}
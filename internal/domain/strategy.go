@@ -0,0 +1,44 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	ErrInvalidStrategyName = errors.New("策略名称需为 1～100 个字符")
+	ErrInvalidInterval     = errors.New("间隔天数需在 1～365 之间，且最大值不能小于最小值")
+	ErrInvalidTimeRange    = errors.New("执行时段无效")
+	ErrInvalidAmountRange  = errors.New("金额范围无效")
+	ErrInvalidDailyLimit   = errors.New("每日任务上限需在 1～100 之间")
+)
+
+// Limits of a strategy's rules.
+const (
+	MaxStrategyNameRunes = 100
+	MaxIntervalDays      = 365
+	MinutesPerDay        = 1440
+	MaxAmountCents       = 100_000_000
+	MaxDailyLimit        = 100
+)
+
+// NormalizeStrategy trims a strategy's name and checks its rules, returning
+// one of the ErrInvalid strategy errors. The API and the simulate command
+// both accept strategies through it.
+func NormalizeStrategy(strategy Strategy) (Strategy, error) {
+	strategy.Name = strings.TrimSpace(strategy.Name)
+	switch {
+	case utf8.RuneCountInString(strategy.Name) < 1 || utf8.RuneCountInString(strategy.Name) > MaxStrategyNameRunes:
+		return Strategy{}, ErrInvalidStrategyName
+	case strategy.IntervalMinDays < 1 || strategy.IntervalMaxDays < strategy.IntervalMinDays || strategy.IntervalMaxDays > MaxIntervalDays:
+		return Strategy{}, ErrInvalidInterval
+	case strategy.TimeStartMinutes < 0 || strategy.TimeEndMinutes > MinutesPerDay || strategy.TimeEndMinutes <= strategy.TimeStartMinutes:
+		return Strategy{}, ErrInvalidTimeRange
+	case strategy.AmountMinCents < 1 || strategy.AmountMaxCents < strategy.AmountMinCents || strategy.AmountMaxCents > MaxAmountCents:
+		return Strategy{}, ErrInvalidAmountRange
+	case strategy.DailyLimit < 1 || strategy.DailyLimit > MaxDailyLimit:
+		return Strategy{}, ErrInvalidDailyLimit
+	}
+	return strategy, nil
+}
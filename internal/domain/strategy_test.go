@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeStrategy(t *testing.T) {
+	valid := Strategy{
+		Name:             " 默认策略 ",
+		IntervalMinDays:  1,
+		IntervalMaxDays:  MaxIntervalDays,
+		TimeStartMinutes: 0,
+		TimeEndMinutes:   MinutesPerDay,
+		AmountMinCents:   1,
+		AmountMaxCents:   MaxAmountCents,
+		DailyLimit:       MaxDailyLimit,
+	}
+	strategy, err := NormalizeStrategy(valid)
+	if err != nil || strategy.Name != "默认策略" {
+		t.Fatalf("unexpected result %+v %v", strategy, err)
+	}
+	for _, test := range []struct {
+		name   string
+		modify func(*Strategy)
+		want   error
+	}{
+		{name: "blank name", modify: func(s *Strategy) { s.Name = "  " }, want: ErrInvalidStrategyName},
+		{name: "long name", modify: func(s *Strategy) { s.Name = strings.Repeat("策", MaxStrategyNameRunes+1) }, want: ErrInvalidStrategyName},
+		{name: "zero interval", modify: func(s *Strategy) { s.IntervalMinDays = 0 }, want: ErrInvalidInterval},
+		{name: "inverted interval", modify: func(s *Strategy) { s.IntervalMinDays, s.IntervalMaxDays = 5, 4 }, want: ErrInvalidInterval},
+		{name: "long interval", modify: func(s *Strategy) { s.IntervalMaxDays = MaxIntervalDays + 1 }, want: ErrInvalidInterval},
+		{name: "empty window", modify: func(s *Strategy) { s.TimeStartMinutes, s.TimeEndMinutes = 600, 600 }, want: ErrInvalidTimeRange},
+		{name: "window past midnight", modify: func(s *Strategy) { s.TimeEndMinutes = MinutesPerDay + 1 }, want: ErrInvalidTimeRange},
+		{name: "zero amount", modify: func(s *Strategy) { s.AmountMinCents = 0 }, want: ErrInvalidAmountRange},
+		{name: "large amount", modify: func(s *Strategy) { s.AmountMaxCents = MaxAmountCents + 1 }, want: ErrInvalidAmountRange},
+		{name: "zero daily limit", modify: func(s *Strategy) { s.DailyLimit = 0 }, want: ErrInvalidDailyLimit},
+		{name: "large daily limit", modify: func(s *Strategy) { s.DailyLimit = MaxDailyLimit + 1 }, want: ErrInvalidDailyLimit},
+	} {
+		strategy := valid
+		test.modify(&strategy)
+		if _, err := NormalizeStrategy(strategy); !errors.Is(err, test.want) {
+			t.Errorf("%s: NormalizeStrategy = %v, want %v", test.name, err, test.want)
+		}
+	}
+}
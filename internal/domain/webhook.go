@@ -0,0 +1,75 @@
+package domain
+
+import (
+	"encoding/json"
+	"slices"
+	"time"
+)
+
+// WebhookEvent is an event type a webhook can subscribe to. The names match
+// the event bus types they come from, apart from task.overdue, which the
+// dispatcher detects, and webhook.test, which is only sent on request.
+type WebhookEvent string
+
+const (
+	WebhookTaskCompleted  WebhookEvent = "task.completed"
+	WebhookTaskOverdue    WebhookEvent = "task.overdue"
+	WebhookBatchCreated   WebhookEvent = "batch.created"
+	WebhookAccountUpdated WebhookEvent = "account.updated"
+	WebhookTest           WebhookEvent = "webhook.test"
+)
+
+// WebhookEvents lists the events a webhook can subscribe to.
+var WebhookEvents = []WebhookEvent{
+	WebhookTaskCompleted,
+	WebhookTaskOverdue,
+	WebhookBatchCreated,
+	WebhookAccountUpdated,
+}
+
+func (e WebhookEvent) Subscribable() bool {
+	return slices.Contains(WebhookEvents, e)
+}
+
+// Webhook is an event subscription. URL and Secret are credentials; the API
+// masks the URL and returns the secret only when the webhook is created.
+type Webhook struct {
+	ID        int64
+	Name      string
+	URL       string
+	Secret    string
+	Events    []WebhookEvent
+	Active    bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Subscribes reports whether the webhook wants event.
+func (w Webhook) Subscribes(event WebhookEvent) bool {
+	return w.Active && slices.Contains(w.Events, event)
+}
+
+// WebhookDelivery is one event queued for one webhook. DeliveryID is sent
+// with every attempt so receivers can drop duplicates.
+type WebhookDelivery struct {
+	ID            int64            `json:"id"`
+	WebhookID     int64            `json:"webhook_id"`
+	DeliveryID    string           `json:"delivery_id"`
+	Event         WebhookEvent     `json:"event"`
+	ResourceID    *int64           `json:"resource_id"`
+	Payload       json.RawMessage  `json:"payload"`
+	Status        DeliveryStatus   `json:"status"`
+	Attempts      int              `json:"attempts"`
+	NextAttemptAt time.Time        `json:"next_attempt_at"`
+	CreatedAt     time.Time        `json:"created_at"`
+	Log           []WebhookAttempt `json:"log"`
+}
+
+// WebhookAttempt is one HTTP request for a delivery. ResponseStatus is nil
+// when no response arrived.
+type WebhookAttempt struct {
+	AttemptedAt    time.Time `json:"attempted_at"`
+	ResponseStatus *int      `json:"response_status"`
+	Error          string    `json:"error"`
+	DurationMS     int64     `json:"duration_ms"`
+}
@@ -0,0 +1,103 @@
+// Package event is an in-process publish/subscribe bus for notifying
+// connected clients and background workers about committed changes.
+package event
+
+import (
+	"sync"
+	"time"
+)
+
+type Type string
+
+const (
+	AccountCreated  Type = "account.created"
+	AccountUpdated  Type = "account.updated"
+	AccountDeleted  Type = "account.deleted"
+	StrategyCreated Type = "strategy.created"
+	StrategyUpdated Type = "strategy.updated"
+	StrategyDeleted Type = "strategy.deleted"
+	BatchCreated    Type = "batch.created"
+	BatchDeleted    Type = "batch.deleted"
+	TaskCompleted   Type = "task.completed"
+)
+
+// Event identifies what changed; subscribers reload the resource themselves
+// instead of trusting a payload that may already be stale.
+type Event struct {
+	Sequence   uint64    `json:"sequence"`
+	Type       Type      `json:"type"`
+	ResourceID int64     `json:"resource_id"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+const subscriberBuffer = 32
+
+type Bus struct {
+	mu          sync.Mutex
+	sequence    uint64
+	closed      bool
+	subscribers map[chan Event]struct{}
+	now         func() time.Time
+}
+
+func NewBus() *Bus {
+	return &Bus{subscribers: make(map[chan Event]struct{}), now: time.Now}
+}
+
+// Publish never blocks the caller. A subscriber that falls behind by more
+// than its buffer misses events; clients recover by refetching.
+func (b *Bus) Publish(eventType Type, resourceID int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+	b.sequence++
+	published := Event{
+		Sequence:   b.sequence,
+		Type:       eventType,
+		ResourceID: resourceID,
+		OccurredAt: b.now().UTC(),
+	}
+	for subscriber := range b.subscribers {
+		select {
+		case subscriber <- published:
+		default:
+		}
+	}
+}
+
+// Subscribe returns a channel of future events and a function that must be
+// called to release it. The channel is closed when the bus closes.
+func (b *Bus) Subscribe() (<-chan Event, func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	subscriber := make(chan Event, subscriberBuffer)
+	if b.closed {
+		close(subscriber)
+		return subscriber, func() {}
+	}
+	b.subscribers[subscriber] = struct{}{}
+	return subscriber, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := b.subscribers[subscriber]; ok {
+			delete(b.subscribers, subscriber)
+			close(subscriber)
+		}
+	}
+}
+
+// Close ends every subscription so long-lived streams return during shutdown.
+func (b *Bus) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+	b.closed = true
+	for subscriber := range b.subscribers {
+		delete(b.subscribers, subscriber)
+		close(subscriber)
+	}
+}
@@ -0,0 +1,43 @@
+package event
+
+import "testing"
+
+func TestBusDeliversToSubscribersUntilClosed(t *testing.T) {
+	bus := NewBus()
+	first, unsubscribeFirst := bus.Subscribe()
+	second, unsubscribeSecond := bus.Subscribe()
+	defer unsubscribeSecond()
+
+	bus.Publish(AccountCreated, 7)
+	for _, subscriber := range []<-chan Event{first, second} {
+		received := <-subscriber
+		if received.Type != AccountCreated || received.ResourceID != 7 || received.Sequence != 1 {
+			t.Fatalf("unexpected event: %+v", received)
+		}
+	}
+
+	unsubscribeFirst()
+	unsubscribeFirst()
+	if _, ok := <-first; ok {
+		t.Fatal("unsubscribed channel is still open")
+	}
+
+	bus.Close()
+	if _, ok := <-second; ok {
+		t.Fatal("closing the bus did not end the subscription")
+	}
+	bus.Publish(TaskCompleted, 1)
+}
+
+func TestBusPublishDoesNotBlockOnSlowSubscriber(t *testing.T) {
+	bus := NewBus()
+	subscriber, unsubscribe := bus.Subscribe()
+	defer unsubscribe()
+
+	for index := 0; index < subscriberBuffer*2; index++ {
+		bus.Publish(TaskCompleted, int64(index))
+	}
+	if len(subscriber) != subscriberBuffer {
+		t.Fatalf("expected a full buffer of %d events, got %d", subscriberBuffer, len(subscriber))
+	}
+}
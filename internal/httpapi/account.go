@@ -1,14 +1,15 @@
 package httpapi
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
-	"strings"
-	"unicode/utf8"
 
 	"github.com/labstack/echo/v4"
 
 	"github.com/CoxxA/nomadbank/v2/internal/domain"
+	"github.com/CoxxA/nomadbank/v2/internal/event"
+	"github.com/CoxxA/nomadbank/v2/internal/sqlite"
 )
 
 type accountRequest struct {
@@ -34,6 +35,7 @@ func (s *Server) getAccount(c echo.Context) error {
 	if err != nil {
 		return mapStoreError(err, "账户不存在")
 	}
+	setEntityTag(c, account.Version)
 	return c.JSON(http.StatusOK, account)
 }
 
@@ -46,9 +48,22 @@ func (s *Server) createAccount(c echo.Context) error {
 	if err != nil {
 		return err
 	}
-	if err := s.store.CreateAccount(c.Request().Context(), &account); err != nil {
+	ctx := c.Request().Context()
+	err = s.store.WithTx(ctx, func(tx *sqlite.Store) error {
+		if err := tx.CreateAccount(ctx, &account); err != nil {
+			return err
+		}
+		if err := s.webhooks.Queue(ctx, tx, event.AccountCreated, account.ID); err != nil {
+			return err
+		}
+		return s.audit(c, tx, domain.AuditAccountCreate, account.ID, nil, account)
+	})
+	if err != nil {
 		return mapStoreError(err, "账户不存在")
 	}
+	s.events.Publish(event.AccountCreated, account.ID)
+	setEntityTag(c, account.Version)
+	s.setLocation(c, "accounts", account.ID)
 	return c.JSON(http.StatusCreated, account)
 }
 
@@ -61,6 +76,9 @@ func (s *Server) updateAccount(c echo.Context) error {
 	if err != nil {
 		return mapStoreError(err, "账户不存在")
 	}
+	if err := checkIfMatch(c, existing.Version); err != nil {
+		return err
+	}
 	var request accountRequest
 	if err := c.Bind(&request); err != nil {
 		return badRequest("invalid_json", "请求格式错误")
@@ -69,9 +87,21 @@ func (s *Server) updateAccount(c echo.Context) error {
 	if err != nil {
 		return err
 	}
-	if err := s.store.UpdateAccount(c.Request().Context(), &account); err != nil {
+	ctx := c.Request().Context()
+	err = s.store.WithTx(ctx, func(tx *sqlite.Store) error {
+		if err := tx.UpdateAccount(ctx, &account); err != nil {
+			return err
+		}
+		if err := s.webhooks.Queue(ctx, tx, event.AccountUpdated, account.ID); err != nil {
+			return err
+		}
+		return s.audit(c, tx, domain.AuditAccountUpdate, account.ID, existing, account)
+	})
+	if err != nil {
 		return mapStoreError(err, "账户不存在")
 	}
+	s.events.Publish(event.AccountUpdated, account.ID)
+	setEntityTag(c, account.Version)
 	return c.JSON(http.StatusOK, account)
 }
 
@@ -80,9 +110,32 @@ func (s *Server) deleteAccount(c echo.Context) error {
 	if err != nil {
 		return err
 	}
-	if err := s.store.DeleteAccount(c.Request().Context(), id); err != nil {
+	existing, err := s.store.GetAccount(c.Request().Context(), id)
+	if err != nil {
+		return mapStoreError(err, "账户不存在")
+	}
+	// Without If-Match the delete is unconditional.
+	var version int64
+	if c.Request().Header.Get("If-Match") != "" {
+		if err := checkIfMatch(c, existing.Version); err != nil {
+			return err
+		}
+		version = existing.Version
+	}
+	ctx := c.Request().Context()
+	err = s.store.WithTx(ctx, func(tx *sqlite.Store) error {
+		if err := tx.DeleteAccount(ctx, id, version); err != nil {
+			return err
+		}
+		if err := s.webhooks.Queue(ctx, tx, event.AccountDeleted, id); err != nil {
+			return err
+		}
+		return s.audit(c, tx, domain.AuditAccountDelete, id, existing, nil)
+	})
+	if err != nil {
 		return mapStoreError(err, "账户不存在")
 	}
+	s.events.Publish(event.AccountDeleted, id)
 	return c.NoContent(http.StatusNoContent)
 }
 
@@ -90,13 +143,12 @@ func accountFromRequest(request accountRequest, existing *domain.Account) (domai
 	if request.Name == nil || request.GroupName == nil || request.Active == nil {
 		return domain.Account{}, badRequest("missing_fields", "name、group_name 和 active 均为必填项")
 	}
-	name := strings.TrimSpace(*request.Name)
-	groupName := strings.TrimSpace(*request.GroupName)
-	if utf8.RuneCountInString(name) < 1 || utf8.RuneCountInString(name) > 100 {
-		return domain.Account{}, badRequest("invalid_account_name", "账户名称需为 1～100 个字符")
-	}
-	if utf8.RuneCountInString(groupName) > 50 {
-		return domain.Account{}, badRequest("invalid_group_name", "分组名称不能超过 50 个字符")
+	name, groupName, err := domain.NormalizeAccountNames(*request.Name, *request.GroupName)
+	switch {
+	case errors.Is(err, domain.ErrInvalidAccountName):
+		return domain.Account{}, badRequest("invalid_account_name", err.Error())
+	case errors.Is(err, domain.ErrInvalidGroupName):
+		return domain.Account{}, badRequest("invalid_group_name", err.Error())
 	}
 	account := domain.Account{Name: name, GroupName: groupName, Active: *request.Active}
 	if existing != nil {
@@ -0,0 +1,91 @@
+package httpapi
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/CoxxA/nomadbank/v2/internal/auth"
+	"github.com/CoxxA/nomadbank/v2/internal/domain"
+	"github.com/CoxxA/nomadbank/v2/internal/sqlite"
+)
+
+type apiTokenRequest struct {
+	Name          string               `json:"name"`
+	Scope         domain.APITokenScope `json:"scope"`
+	ExpiresInDays int                  `json:"expires_in_days"`
+}
+
+type createdAPIToken struct {
+	domain.APIToken
+	Token string `json:"token"`
+}
+
+func (s *Server) listAPITokens(c echo.Context) error {
+	tokens, err := s.store.ListAPITokens(c.Request().Context())
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, tokens)
+}
+
+func (s *Server) createAPIToken(c echo.Context) error {
+	var request apiTokenRequest
+	if err := c.Bind(&request); err != nil {
+		return badRequest("invalid_json", "请求格式错误")
+	}
+	var (
+		token  domain.APIToken
+		secret string
+	)
+	ctx := c.Request().Context()
+	err := s.store.WithTx(ctx, func(tx *sqlite.Store) error {
+		var err error
+		token, secret, err = s.authService.InTx(tx).CreateAPIToken(ctx, auth.APITokenInput{
+			Name:          request.Name,
+			Scope:         request.Scope,
+			ExpiresInDays: request.ExpiresInDays,
+		})
+		if err != nil {
+			return err
+		}
+		return s.audit(c, tx, domain.AuditAPITokenCreate, token.ID, nil, token)
+	})
+	if err != nil {
+		if errors.Is(err, auth.ErrInvalidInput) {
+			return badRequest("invalid_api_token", "名称需为 1～60 个字符；scope 必须是 read_only 或 read_write；有效期需在 1～365 天之间")
+		}
+		return err
+	}
+	return c.JSON(http.StatusCreated, createdAPIToken{APIToken: token, Token: secret})
+}
+
+func (s *Server) deleteAPIToken(c echo.Context) error {
+	id, err := parseID(c.Param("id"))
+	if err != nil {
+		return err
+	}
+	ctx := c.Request().Context()
+	err = s.store.WithTx(ctx, func(tx *sqlite.Store) error {
+		if err := tx.DeleteAPIToken(ctx, id); err != nil {
+			return err
+		}
+		return s.audit(c, tx, domain.AuditAPITokenDelete, id, nil, nil)
+	})
+	if err != nil {
+		return mapStoreError(err, "API 令牌不存在")
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// requireBrowserSession keeps credential management out of reach of API
+// tokens, so a leaked token cannot mint new tokens or change the password.
+func requireBrowserSession(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if _, ok := c.Get("api_token").(domain.APIToken); ok {
+			return apiError(http.StatusForbidden, "session_required", "该操作需要在浏览器中登录后进行")
+		}
+		return next(c)
+	}
+}
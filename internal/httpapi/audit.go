@@ -0,0 +1,68 @@
+package httpapi
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/CoxxA/nomadbank/v2/internal/domain"
+	"github.com/CoxxA/nomadbank/v2/internal/sqlite"
+)
+
+func (s *Server) listAuditEvents(c echo.Context) error {
+	page, err := positiveQueryInt(c.QueryParam("page"), 1, 1, 100_000)
+	if err != nil {
+		return badRequest("invalid_page", "分页参数无效")
+	}
+	pageSize, err := positiveQueryInt(c.QueryParam("page_size"), 20, 1, 100)
+	if err != nil {
+		return badRequest("invalid_page_size", "每页数量需在 1～100 之间")
+	}
+	resourceID, err := optionalQueryInt64(c.QueryParam("resource_id"))
+	if err != nil {
+		return badRequest("invalid_resource_id", "资源 ID 无效")
+	}
+	result, err := s.store.ListAuditEvents(c.Request().Context(), sqlite.AuditFilter{
+		ResourceType: strings.TrimSpace(c.QueryParam("resource_type")),
+		ResourceID:   resourceID,
+		Action:       domain.AuditAction(strings.TrimSpace(c.QueryParam("action"))),
+		Page:         page,
+		PageSize:     pageSize,
+	})
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+// audit records a mutation through tx, the transaction that makes the
+// change, so the change and its audit event commit together; a failure to
+// record the event fails the request. A resourceID of zero means the action
+// has no single resource; before and after are encoded as JSON and may be nil.
+func (s *Server) audit(c echo.Context, tx *sqlite.Store, action domain.AuditAction, resourceID int64, before, after any) error {
+	event := domain.AuditEvent{
+		Action:    action,
+		ActorType: domain.AuditActorOwner,
+		IP:        c.RealIP(),
+		CreatedAt: time.Now(),
+	}
+	if resourceID > 0 {
+		event.ResourceID = &resourceID
+	}
+	if token, ok := c.Get("api_token").(domain.APIToken); ok {
+		event.ActorType = domain.AuditActorAPIToken
+		event.ActorName = token.Name
+	} else if owner, ok := c.Get("owner").(domain.Owner); ok {
+		event.ActorName = owner.Username
+		if _, ok := c.Get("task_link").(bool); ok {
+			event.ActorType = domain.AuditActorTaskLink
+		}
+	}
+	if err := tx.Audit(c.Request().Context(), event, before, after, s.config.AuditRetentionDays); err != nil {
+		return fmt.Errorf("记录审计事件: %w", err)
+	}
+	return nil
+}
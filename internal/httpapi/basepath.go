@@ -0,0 +1,52 @@
+package httpapi
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// stripBasePath serves the application below BASE_PATH while routes stay
+// registered at the root, so route templates in logs, metrics and the
+// OpenAPI contract do not change. Requests outside the prefix get 404, and
+// the bare prefix redirects to its trailing-slash form so that the page's
+// relative asset URLs resolve.
+func stripBasePath(base string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			request := c.Request()
+			if request.URL.Path == base {
+				target := base + "/"
+				if request.URL.RawQuery != "" {
+					target += "?" + request.URL.RawQuery
+				}
+				return c.Redirect(http.StatusMovedPermanently, target)
+			}
+			rest, ok := strings.CutPrefix(request.URL.Path, base)
+			if !ok || !strings.HasPrefix(rest, "/") {
+				return echo.ErrNotFound
+			}
+			request.URL.Path = rest
+			// BASE_PATH contains no escapes, so it prefixes RawPath verbatim.
+			request.URL.RawPath = strings.TrimPrefix(request.URL.RawPath, base)
+			return next(c)
+		}
+	}
+}
+
+// cookiePath scopes cookies to BASE_PATH, keeping them away from other
+// applications on the same host.
+func (s *Server) cookiePath() string {
+	if s.config.BasePath == "" {
+		return "/"
+	}
+	return s.config.BasePath
+}
+
+// setLocation points a 201 response at the created resource below BASE_PATH.
+func (s *Server) setLocation(c echo.Context, collection string, id int64) {
+	location := s.config.BasePath + "/api/v1/" + collection + "/" + strconv.FormatInt(id, 10)
+	c.Response().Header().Set(echo.HeaderLocation, location)
+}
@@ -0,0 +1,251 @@
+package httpapi
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/labstack/echo/v4"
+
+	"github.com/CoxxA/nomadbank/v2/docs/api"
+)
+
+// maxValidatedResponseBytes bounds the copy kept for response validation;
+// larger bodies are passed through unchecked.
+const maxValidatedResponseBytes = 1 << 20
+
+var errRouteNotInContract = errors.New("路由未在 OpenAPI 规范中声明")
+
+// contract matches requests to operations in the embedded OpenAPI document.
+// kin-openapi's routers insist on validating the whole document first, which
+// its OpenAPI 3.0 validator cannot do for 3.1 `type: [T, 'null']` schemas, so
+// matching is done here and only the request/response validators are reused.
+type contract struct {
+	doc    *openapi3.T
+	routes []contractRoute
+}
+
+type contractRoute struct {
+	method   string
+	template string
+	segments []string
+	route    *routers.Route
+}
+
+func loadContract() (*contract, error) {
+	loader := openapi3.NewLoader()
+	doc, err := loader.LoadFromData(api.OpenAPI)
+	if err != nil {
+		return nil, fmt.Errorf("加载 OpenAPI 规范: %w", err)
+	}
+	result := &contract{doc: doc}
+	for path, item := range doc.Paths.Map() {
+		for method, operation := range item.Operations() {
+			template := serverBase(doc, item, operation) + path
+			result.routes = append(result.routes, contractRoute{
+				method:   strings.ToUpper(method),
+				template: template,
+				segments: strings.Split(strings.Trim(template, "/"), "/"),
+				route: &routers.Route{
+					Spec:      doc,
+					Path:      path,
+					PathItem:  item,
+					Method:    strings.ToUpper(method),
+					Operation: operation,
+				},
+			})
+		}
+	}
+	// Literal segments must win over parameters, e.g. /me before /{id}.
+	sort.Slice(result.routes, func(i, j int) bool {
+		return strings.Count(result.routes[i].template, "{") < strings.Count(result.routes[j].template, "{")
+	})
+	return result, nil
+}
+
+func serverBase(doc *openapi3.T, item *openapi3.PathItem, operation *openapi3.Operation) string {
+	servers := doc.Servers
+	if len(item.Servers) > 0 {
+		servers = item.Servers
+	}
+	if operation.Servers != nil && len(*operation.Servers) > 0 {
+		servers = *operation.Servers
+	}
+	if len(servers) == 0 {
+		return ""
+	}
+	return strings.TrimSuffix(servers[0].URL, "/")
+}
+
+// operations lists every documented operation as "METHOD /full/path".
+func (c *contract) operations() []string {
+	operations := make([]string, 0, len(c.routes))
+	for _, route := range c.routes {
+		operations = append(operations, route.method+" "+route.template)
+	}
+	return operations
+}
+
+func (c *contract) find(method, path string) (*routers.Route, map[string]string, bool) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for _, candidate := range c.routes {
+		if candidate.method != method || len(candidate.segments) != len(segments) {
+			continue
+		}
+		params := make(map[string]string)
+		matched := true
+		for index, segment := range candidate.segments {
+			if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+				value, err := url.PathUnescape(segments[index])
+				if err != nil || value == "" {
+					matched = false
+					break
+				}
+				params[segment[1:len(segment)-1]] = value
+				continue
+			}
+			if segment != segments[index] {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return candidate.route, params, true
+		}
+	}
+	return nil, nil, false
+}
+
+// contractMiddleware validates API traffic against the contract. Request
+// violations are rejected when enforce is set; otherwise they are reported
+// only if the handler accepted the request, because a handler that rejects
+// bad input is behaving correctly while one that accepts it has drifted from
+// the contract. Response violations are always reported, never rewritten.
+func (s *Server) contractMiddleware(
+	contract *contract,
+	enforce bool,
+	report func(echo.Context, error),
+) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			request := c.Request()
+			if !strings.HasPrefix(request.URL.Path, "/api/") && !strings.HasPrefix(request.URL.Path, "/health") {
+				return next(c)
+			}
+			route, params, found := contract.find(request.Method, request.URL.Path)
+			if !found {
+				err := next(c)
+				if err != nil {
+					c.Error(err)
+				}
+				status := c.Response().Status
+				if status != http.StatusNotFound && status != http.StatusMethodNotAllowed {
+					report(c, errRouteNotInContract)
+				}
+				return err
+			}
+
+			requestInput := &openapi3filter.RequestValidationInput{
+				Request:    request,
+				PathParams: params,
+				Route:      route,
+				Options: &openapi3filter.Options{
+					// Authentication is enforced by requireSession, not by the contract.
+					AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+					MultiError:         true,
+				},
+			}
+			requestErr := openapi3filter.ValidateRequest(request.Context(), requestInput)
+			if requestErr != nil && enforce {
+				return apiError(http.StatusBadRequest, "contract_violation", requestErr.Error())
+			}
+
+			response := c.Response()
+			capture := &responseCapture{ResponseWriter: response.Writer, limit: maxValidatedResponseBytes}
+			response.Writer = capture
+			err := next(c)
+			if err != nil {
+				// Render the error now so its status and body can be validated.
+				// Returning it afterwards still lets outer middleware log it;
+				// the error handler skips responses that are already committed.
+				c.Error(err)
+			}
+			response.Writer = capture.ResponseWriter
+
+			if requestErr != nil && response.Status < http.StatusBadRequest {
+				report(c, fmt.Errorf("请求不符合规范但被接受: %w", requestErr))
+			}
+			if capture.skip(response.Header()) {
+				return err
+			}
+			responseErr := openapi3filter.ValidateResponse(context.WithoutCancel(request.Context()), &openapi3filter.ResponseValidationInput{
+				RequestValidationInput: requestInput,
+				Status:                 response.Status,
+				Header:                 response.Header(),
+				Body:                   io.NopCloser(bytes.NewReader(capture.body.Bytes())),
+				Options: &openapi3filter.Options{
+					IncludeResponseStatus: true,
+					MultiError:            true,
+				},
+			})
+			if responseErr != nil {
+				report(c, fmt.Errorf("响应不符合规范: %w", responseErr))
+			}
+			return err
+		}
+	}
+}
+
+// responseCapture copies up to limit bytes of what the handler writes without
+// delaying it, so streaming responses keep flushing while they are observed.
+type responseCapture struct {
+	http.ResponseWriter
+	limit    int
+	body     bytes.Buffer
+	overflow bool
+}
+
+func (w *responseCapture) Write(data []byte) (int, error) {
+	if !w.overflow {
+		if w.body.Len()+len(data) > w.limit {
+			w.overflow = true
+			w.body.Reset()
+		} else {
+			w.body.Write(data)
+		}
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseCapture) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *responseCapture) skip(header http.Header) bool {
+	return w.overflow || strings.HasPrefix(header.Get(echo.HeaderContentType), "text/event-stream")
+}
+
+// EnableContractValidation checks API traffic against the embedded OpenAPI
+// document and logs mismatches. With enforce, requests that violate the
+// contract are rejected with 400 contract_violation before reaching handlers.
+func (s *Server) EnableContractValidation(enforce bool) error {
+	contract, err := loadContract()
+	if err != nil {
+		return err
+	}
+	s.echo.Use(s.contractMiddleware(contract, enforce, func(c echo.Context, err error) {
+		slog.WarnContext(c.Request().Context(), "请求或响应不符合 OpenAPI 规范",
+			"method", c.Request().Method, "route", c.Path(), "error", err)
+	}))
+	return nil
+}
@@ -0,0 +1,123 @@
+package httpapi
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/CoxxA/nomadbank/v2/internal/domain"
+)
+
+const (
+	csrfCookieName = "nomadbank_csrf"
+	csrfHeaderName = "X-CSRF-Token"
+)
+
+// csrfToken binds the CSRF token to the session without storing it: only a
+// holder of the HttpOnly session cookie can compute it, yet the browser app
+// can read it from a separate, script-readable cookie and echo it back.
+func csrfToken(sessionToken string) string {
+	sum := sha256.Sum256([]byte("nomadbank-csrf:" + sessionToken))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// requireCSRF runs after requireSession. Requests authenticated with an API
+// token carry no ambient credentials and are exempt.
+func (s *Server) requireCSRF(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if _, ok := c.Get("api_token").(domain.APIToken); ok {
+			return next(c)
+		}
+		expected := csrfToken(sessionToken(c))
+		if safeMethod(c.Request().Method) {
+			// Sessions created before CSRF protection existed have no token
+			// cookie yet; hand one out on the next read.
+			if cookie, err := c.Cookie(csrfCookieName); err != nil || cookie.Value != expected {
+				s.setCSRFCookie(c, expected, time.Time{})
+			}
+			return next(c)
+		}
+		provided := c.Request().Header.Get(csrfHeaderName)
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(expected)) != 1 {
+			return apiError(http.StatusForbidden, "csrf_failed", "请求校验失败，请刷新页面后重试")
+		}
+		// Browsers send Origin on every unsafe request, so a cookie-authenticated
+		// write without it or a Referer did not come from the app.
+		if err := s.checkSameOrigin(c, true); err != nil {
+			return err
+		}
+		return next(c)
+	}
+}
+
+// requireSameOrigin protects the unauthenticated setup and login forms from
+// cross-site submission, which has no session to bind a token to. Scripts
+// that send neither Origin nor Referer may still log in.
+func (s *Server) requireSameOrigin(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if err := s.checkSameOrigin(c, false); err != nil {
+			return err
+		}
+		return next(c)
+	}
+}
+
+// checkSameOrigin rejects unsafe requests whose Origin, or Referer when Origin
+// is absent, names another host than requestHost. Only the host is compared
+// because the scheme seen behind a TLS-terminating proxy depends on forwarded
+// headers. Requests carrying neither header are rejected when required.
+func (s *Server) checkSameOrigin(c echo.Context, required bool) error {
+	if safeMethod(c.Request().Method) {
+		return nil
+	}
+	source := c.Request().Header.Get(echo.HeaderOrigin)
+	if source == "" {
+		source = c.Request().Header.Get("Referer")
+	}
+	if source == "" {
+		if required {
+			return apiError(http.StatusForbidden, "origin_required", "请求缺少来源信息，请在浏览器中刷新页面后重试")
+		}
+		return nil
+	}
+	parsed, err := url.Parse(source)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") ||
+		!strings.EqualFold(parsed.Host, s.requestHost(c)) {
+		return apiError(http.StatusForbidden, "origin_mismatch", "请求来源与当前站点不一致")
+	}
+	return nil
+}
+
+func safeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *Server) setCSRFCookie(c echo.Context, token string, expiresAt time.Time) {
+	cookie := &http.Cookie{
+		Name:     csrfCookieName,
+		Value:    token,
+		Path:     s.cookiePath(),
+		Secure:   s.requestIsSecure(c),
+		SameSite: http.SameSiteStrictMode,
+	}
+	switch {
+	case token == "":
+		cookie.MaxAge = -1
+		cookie.Expires = time.Unix(0, 0)
+	case !expiresAt.IsZero():
+		cookie.Expires = expiresAt
+		cookie.MaxAge = int(time.Until(expiresAt).Seconds())
+	}
+	c.SetCookie(cookie)
+}
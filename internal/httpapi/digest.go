@@ -0,0 +1,161 @@
+package httpapi
+
+import (
+	"net"
+	"net/http"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/CoxxA/nomadbank/v2/internal/domain"
+	"github.com/CoxxA/nomadbank/v2/internal/sqlite"
+)
+
+// emailDigestResponse never carries the SMTP password, only whether one is
+// set.
+type emailDigestResponse struct {
+	Enabled         bool                `json:"enabled"`
+	SendMinutes     int                 `json:"send_minutes"`
+	Recipient       string              `json:"recipient"`
+	Sender          string              `json:"sender"`
+	SMTPHost        string              `json:"smtp_host"`
+	SMTPPort        int                 `json:"smtp_port"`
+	SMTPSecurity    domain.SMTPSecurity `json:"smtp_security"`
+	SMTPUsername    string              `json:"smtp_username"`
+	SMTPPasswordSet bool                `json:"smtp_password_set"`
+	DormancyDays    int                 `json:"dormancy_days"`
+	LastSentOn      string              `json:"last_sent_on"`
+	LastAttemptAt   *time.Time          `json:"last_attempt_at"`
+	LastError       string              `json:"last_error"`
+	UpdatedAt       time.Time           `json:"updated_at"`
+}
+
+// emailDigestRequest keeps the stored password when smtp_password is
+// omitted; an empty string clears it.
+type emailDigestRequest struct {
+	Enabled      *bool                `json:"enabled"`
+	SendMinutes  *int                 `json:"send_minutes"`
+	Recipient    *string              `json:"recipient"`
+	Sender       *string              `json:"sender"`
+	SMTPHost     *string              `json:"smtp_host"`
+	SMTPPort     *int                 `json:"smtp_port"`
+	SMTPSecurity *domain.SMTPSecurity `json:"smtp_security"`
+	SMTPUsername *string              `json:"smtp_username"`
+	SMTPPassword *string              `json:"smtp_password"`
+	DormancyDays *int                 `json:"dormancy_days"`
+}
+
+func newEmailDigestResponse(settings domain.EmailDigestSettings) emailDigestResponse {
+	return emailDigestResponse{
+		Enabled:         settings.Enabled,
+		SendMinutes:     settings.SendMinutes,
+		Recipient:       settings.Recipient,
+		Sender:          settings.Sender,
+		SMTPHost:        settings.SMTPHost,
+		SMTPPort:        settings.SMTPPort,
+		SMTPSecurity:    settings.SMTPSecurity,
+		SMTPUsername:    settings.SMTPUsername,
+		SMTPPasswordSet: settings.SMTPPassword != "",
+		DormancyDays:    settings.DormancyDays,
+		LastSentOn:      settings.LastSentOn,
+		LastAttemptAt:   settings.LastAttemptAt,
+		LastError:       settings.LastError,
+		UpdatedAt:       settings.UpdatedAt,
+	}
+}
+
+func (s *Server) emailDigestSettings(c echo.Context) error {
+	settings, err := s.store.EmailDigestSettings(c.Request().Context())
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, newEmailDigestResponse(settings))
+}
+
+func (s *Server) updateEmailDigestSettings(c echo.Context) error {
+	var request emailDigestRequest
+	if err := c.Bind(&request); err != nil {
+		return badRequest("invalid_json", "请求格式错误")
+	}
+	if request.Enabled == nil || request.SendMinutes == nil || request.Recipient == nil || request.Sender == nil ||
+		request.SMTPHost == nil || request.SMTPPort == nil || request.SMTPSecurity == nil ||
+		request.SMTPUsername == nil || request.DormancyDays == nil {
+		return badRequest("missing_fields", "除 smtp_password 外均为必填项")
+	}
+	ctx := c.Request().Context()
+	settings, err := s.store.EmailDigestSettings(ctx)
+	if err != nil {
+		return err
+	}
+	before := newEmailDigestResponse(settings)
+	settings.Enabled = *request.Enabled
+	settings.SendMinutes = *request.SendMinutes
+	settings.Recipient = strings.TrimSpace(*request.Recipient)
+	settings.Sender = strings.TrimSpace(*request.Sender)
+	settings.SMTPHost = strings.TrimSpace(*request.SMTPHost)
+	settings.SMTPPort = *request.SMTPPort
+	settings.SMTPSecurity = *request.SMTPSecurity
+	settings.SMTPUsername = strings.TrimSpace(*request.SMTPUsername)
+	if request.SMTPPassword != nil {
+		settings.SMTPPassword = *request.SMTPPassword
+	}
+	settings.DormancyDays = *request.DormancyDays
+	if err := validateEmailDigest(settings); err != nil {
+		return err
+	}
+	var after emailDigestResponse
+	err = s.store.WithTx(ctx, func(tx *sqlite.Store) error {
+		if err := tx.UpdateEmailDigestSettings(ctx, &settings); err != nil {
+			return err
+		}
+		after = newEmailDigestResponse(settings)
+		return s.audit(c, tx, domain.AuditEmailDigestUpdate, 0, before, after)
+	})
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, after)
+}
+
+func validateEmailDigest(settings domain.EmailDigestSettings) error {
+	switch {
+	case settings.SendMinutes < 0 || settings.SendMinutes > 1439:
+		return badRequest("invalid_send_minutes", "发送时间需在 0～1439 分钟之间")
+	case settings.DormancyDays < 60 || settings.DormancyDays > 3650:
+		return badRequest("invalid_dormancy_days", "休眠天数需在 60～3650 之间")
+	case !validMailAddress(settings.Recipient):
+		return badRequest("invalid_recipient", "收件人必须是有效的邮箱地址")
+	case !validMailAddress(settings.Sender):
+		return badRequest("invalid_sender", "发件人必须是有效的邮箱地址")
+	case len(settings.SMTPHost) > 253 || (strings.ContainsAny(settings.SMTPHost, " \t/:@") && net.ParseIP(settings.SMTPHost) == nil):
+		return badRequest("invalid_smtp_host", "SMTP 服务器只需填写主机名或 IP，不含端口")
+	case settings.SMTPPort < 1 || settings.SMTPPort > 65535:
+		return badRequest("invalid_smtp_port", "SMTP 端口需在 1～65535 之间")
+	case !settings.SMTPSecurity.Valid():
+		return badRequest("invalid_smtp_security", "加密方式只能是 starttls、tls 或 none")
+	case len(settings.SMTPUsername) > 256 || len(settings.SMTPPassword) > 256:
+		return badRequest("invalid_smtp_credentials", "SMTP 用户名和密码不能超过 256 个字符")
+	case settings.SMTPSecurity == domain.SMTPNone && settings.SMTPUsername != "" && !loopbackHost(settings.SMTPHost):
+		return badRequest("insecure_smtp_auth", "不加密的连接只能向本机 SMTP 服务器发送用户名和密码")
+	case settings.Enabled && (settings.SMTPHost == "" || settings.Recipient == "" || settings.Sender == ""):
+		return badRequest("smtp_required", "启用每日摘要前需要设置 SMTP 服务器、发件人和收件人")
+	}
+	return nil
+}
+
+// validMailAddress accepts an empty value or one address, optionally with a
+// display name.
+func validMailAddress(value string) bool {
+	if value == "" {
+		return true
+	}
+	_, err := mail.ParseAddress(value)
+	return err == nil && len(value) <= 320
+}
+
+// loopbackHost matches the hosts net/smtp sends a password to without TLS.
+func loopbackHost(host string) bool {
+	return host == "localhost" || host == "127.0.0.1" || host == "::1"
+}
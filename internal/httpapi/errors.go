@@ -6,12 +6,15 @@ import (
 
 	"github.com/labstack/echo/v4"
 
+	"github.com/CoxxA/nomadbank/v2/internal/logging"
 	"github.com/CoxxA/nomadbank/v2/internal/sqlite"
 )
 
 type ErrorResponse struct {
 	Code    string `json:"code"`
 	Message string `json:"message"`
+	// RequestID is only set for internal errors, to find them in the log.
+	RequestID string `json:"request_id,omitempty"`
 }
 
 type APIError struct {
@@ -52,6 +55,8 @@ func mapStoreError(err error, notFoundMessage string) error {
 		return conflict("conflict", "名称已经存在")
 	case errors.Is(err, sqlite.ErrInUse):
 		return conflict("resource_in_use", "该记录已被任务引用，不能删除")
+	case errors.Is(err, sqlite.ErrStale):
+		return preconditionFailed()
 	default:
 		return err
 	}
@@ -75,9 +80,10 @@ func errorHandler(err error, c echo.Context) {
 		_ = c.JSON(echoError.Code, ErrorResponse{Code: "http_error", Message: message})
 		return
 	}
-	c.Logger().Error(err)
+	// The request logger records err together with the request ID.
 	_ = c.JSON(http.StatusInternalServerError, ErrorResponse{
-		Code:    "internal_error",
-		Message: "服务器内部错误",
+		Code:      "internal_error",
+		Message:   "服务器内部错误",
+		RequestID: logging.RequestID(c.Request().Context()),
 	})
 }
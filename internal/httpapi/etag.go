@@ -0,0 +1,41 @@
+package httpapi
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// entityTag derives a strong ETag from a row version. Versions only grow, so
+// the tag changes on every successful write even within the same second.
+func entityTag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+func setEntityTag(c echo.Context, version int64) {
+	c.Response().Header().Set("ETag", entityTag(version))
+}
+
+// checkIfMatch enforces If-Match when the client sent one. Requests without the
+// header keep last-writer-wins semantics for older clients and scripts.
+func checkIfMatch(c echo.Context, version int64) error {
+	header := strings.TrimSpace(c.Request().Header.Get("If-Match"))
+	if header == "" {
+		return nil
+	}
+	current := entityTag(version)
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		// Weak tags never match under the strong comparison RFC 9110 requires.
+		if candidate == "*" || candidate == current {
+			return nil
+		}
+	}
+	return preconditionFailed()
+}
+
+func preconditionFailed() error {
+	return apiError(http.StatusPreconditionFailed, "precondition_failed", "记录已在其他页面修改，请刷新后重试")
+}
@@ -0,0 +1,78 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	eventHeartbeatInterval = 25 * time.Second
+	eventWriteTimeout      = 10 * time.Second
+)
+
+// streamEvents keeps a Server-Sent Events stream open. Each message only
+// names the changed resource; clients invalidate their caches and refetch.
+func (s *Server) streamEvents(c echo.Context) error {
+	ctx := c.Request().Context()
+	events, unsubscribe := s.events.Subscribe()
+	defer unsubscribe()
+
+	response := c.Response()
+	controller := http.NewResponseController(response)
+	response.Header().Set(echo.HeaderContentType, "text/event-stream")
+	response.Header().Set(echo.HeaderCacheControl, "no-cache")
+	// Ask nginx-style proxies not to buffer the stream.
+	response.Header().Set("X-Accel-Buffering", "no")
+	response.WriteHeader(http.StatusOK)
+	if err := writeEventChunk(controller, response, "retry: 5000\n\n"); err != nil {
+		return nil
+	}
+
+	heartbeat := time.NewTicker(eventHeartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case published, ok := <-events:
+			if !ok {
+				return nil
+			}
+			payload, err := json.Marshal(published)
+			if err != nil {
+				return err
+			}
+			chunk := fmt.Sprintf("id: %d\nevent: %s\ndata: %s\n\n", published.Sequence, published.Type, payload)
+			if err := writeEventChunk(controller, response, chunk); err != nil {
+				return nil
+			}
+		case <-heartbeat.C:
+			// End streams whose session or token was revoked after they connected.
+			if _, _, err := s.authenticate(c); err != nil {
+				return nil
+			}
+			if err := writeEventChunk(controller, response, ": keepalive\n\n"); err != nil {
+				return nil
+			}
+		}
+	}
+}
+
+// writeEventChunk extends the write deadline for each message because the
+// server-wide WriteTimeout would otherwise cut every stream after 30 seconds.
+func writeEventChunk(controller *http.ResponseController, writer io.Writer, chunk string) error {
+	err := controller.SetWriteDeadline(time.Now().Add(eventWriteTimeout))
+	if err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	if _, err := io.WriteString(writer, chunk); err != nil {
+		return err
+	}
+	return controller.Flush()
+}
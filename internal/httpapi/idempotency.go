@@ -0,0 +1,132 @@
+package httpapi
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/CoxxA/nomadbank/v2/internal/sqlite"
+)
+
+const (
+	idempotencyHeader    = "Idempotency-Key"
+	idempotencyRetention = 24 * time.Hour
+	maxIdempotencyKeyLen = 255
+	// Responses larger than this are not stored; the key is released instead.
+	maxIdempotentResponseBytes = 1 << 20
+	// The handler's context is cancelled after idempotentTimeout, so its
+	// transaction can no longer commit. A reservation still open after
+	// idempotencyTakeover therefore belongs to a request that will never
+	// record its outcome, e.g. because the process died, and a retry may take
+	// it over. The margin leaves time to record the outcome after the deadline.
+	idempotentTimeout   = writeTimeout
+	idempotencyTakeover = 2 * idempotentTimeout
+)
+
+// idempotent replays the stored response when a creating request is repeated
+// with the same Idempotency-Key, e.g. after a double-submitted form or a
+// client retry. Only successful responses are stored, so a failed request can
+// be retried with the same key. Requests without the header are unaffected.
+func (s *Server) idempotent(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		key := c.Request().Header.Get(idempotencyHeader)
+		if key == "" {
+			return next(c)
+		}
+		if len(key) > maxIdempotencyKeyLen || !printableASCII(key) {
+			return badRequest("invalid_idempotency_key", "Idempotency-Key 需为不超过 255 个字符的可打印 ASCII 字符串")
+		}
+
+		request := c.Request()
+		body, err := io.ReadAll(request.Body)
+		if err != nil {
+			return err
+		}
+		request.Body = io.NopCloser(bytes.NewReader(body))
+		requestHash := idempotencyHash(request.Method, request.URL.Path, body)
+
+		ctx := request.Context()
+		now := time.Now()
+		stored, reserved, err := s.store.ReserveIdempotencyKey(ctx, key, requestHash, now,
+			now.Add(-idempotencyRetention), now.Add(-idempotencyTakeover))
+		if err != nil {
+			return err
+		}
+		if !reserved {
+			switch {
+			case !bytes.Equal(stored.RequestHash, requestHash):
+				return apiError(http.StatusUnprocessableEntity, "idempotency_key_reused", "该 Idempotency-Key 已用于不同的请求")
+			case stored.Status == 0:
+				return conflict("idempotency_in_progress", "相同的请求正在处理，请稍后重试")
+			}
+			header := c.Response().Header()
+			header.Set("Idempotent-Replayed", "true")
+			if stored.ETag != "" {
+				header.Set("ETag", stored.ETag)
+			}
+			if stored.Location != "" {
+				header.Set(echo.HeaderLocation, stored.Location)
+			}
+			return c.Blob(stored.Status, stored.ContentType, stored.Body)
+		}
+
+		// WriteTimeout only stops the answer from reaching the client; the
+		// deadline is what keeps a slow handler from committing after its
+		// reservation could have been taken over.
+		handlerCtx, cancel := context.WithTimeout(ctx, idempotentTimeout)
+		defer cancel()
+		c.SetRequest(request.WithContext(handlerCtx))
+
+		response := c.Response()
+		capture := &responseCapture{ResponseWriter: response.Writer, limit: maxIdempotentResponseBytes}
+		response.Writer = capture
+		handlerErr := next(c)
+		if handlerErr != nil {
+			c.Error(handlerErr)
+		}
+		response.Writer = capture.ResponseWriter
+
+		// The client may have gone away; the outcome still has to be recorded.
+		ctx = context.WithoutCancel(ctx)
+		if response.Status >= 200 && response.Status < 300 && !capture.overflow {
+			header := response.Header()
+			err = s.store.CompleteIdempotencyKey(ctx, key, now, sqlite.IdempotentResponse{
+				Status:      response.Status,
+				ContentType: header.Get(echo.HeaderContentType),
+				ETag:        header.Get("ETag"),
+				Location:    header.Get(echo.HeaderLocation),
+				Body:        capture.body.Bytes(),
+			})
+		} else {
+			err = s.store.ReleaseIdempotencyKey(ctx, key, now)
+		}
+		if err != nil {
+			slog.ErrorContext(ctx, "记录 Idempotency-Key", "error", err)
+		}
+		return handlerErr
+	}
+}
+
+// idempotencyHash identifies a request, so a key reused for another request
+// can be told apart from a retry.
+func idempotencyHash(method, path string, body []byte) []byte {
+	hash := sha256.New()
+	_, _ = io.WriteString(hash, method+" "+path+"\n")
+	_, _ = hash.Write(body)
+	return hash.Sum(nil)
+}
+
+func printableASCII(value string) bool {
+	for index := 0; index < len(value); index++ {
+		if value[index] < 0x20 || value[index] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
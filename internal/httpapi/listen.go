@@ -0,0 +1,62 @@
+package httpapi
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"time"
+)
+
+// listenUnix binds a unix socket with the configured permissions. A socket
+// file left behind by a crashed process is replaced, but one that still
+// accepts connections or any other kind of file is not.
+func listenUnix(path string, mode os.FileMode) (net.Listener, error) {
+	info, err := os.Lstat(path)
+	switch {
+	case err == nil:
+		if info.Mode().Type() != fs.ModeSocket {
+			return nil, fmt.Errorf("%s 已存在且不是套接字", path)
+		}
+		if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("%s 已被其他进程监听", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("删除残留套接字: %w", err)
+		}
+	case !errors.Is(err, fs.ErrNotExist):
+		return nil, err
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		_ = listener.Close()
+		return nil, fmt.Errorf("设置套接字权限: %w", err)
+	}
+	return listener, nil
+}
+
+// listen opens the LISTEN socket. Echo listens itself on TCP addresses; a
+// unix socket is handed over ready-made, wrapped in TLS when enabled.
+func (s *Server) listen() error {
+	path, ok := s.config.UnixSocket()
+	if !ok {
+		s.echo.Server.Addr = s.config.Address()
+		return nil
+	}
+	listener, err := listenUnix(path, s.config.SocketMode)
+	if err != nil {
+		return err
+	}
+	if s.echo.Server.TLSConfig != nil {
+		s.echo.TLSListener = tls.NewListener(listener, s.echo.Server.TLSConfig)
+	} else {
+		s.echo.Listener = listener
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package httpapi
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+
+	"github.com/CoxxA/nomadbank/v2/internal/logging"
+)
+
+const maxRequestIDLength = 128
+
+// requestID reuses a well-formed X-Request-ID from a proxy, or creates one,
+// returns it in the response and attaches it to the request context so log
+// records made while handling the request carry it.
+func requestID(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		id := c.Request().Header.Get(echo.HeaderXRequestID)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		c.Response().Header().Set(echo.HeaderXRequestID, id)
+		c.SetRequest(c.Request().WithContext(logging.WithRequestID(c.Request().Context(), id)))
+		return next(c)
+	}
+}
+
+// validRequestID accepts printable ASCII without spaces, so a client cannot
+// forge log structure through the ID.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for index := 0; index < len(id); index++ {
+		if id[index] <= ' ' || id[index] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	var raw [16]byte
+	_, _ = rand.Read(raw[:])
+	return hex.EncodeToString(raw[:])
+}
+
+// requestLogger writes one record per request. The route template is logged
+// instead of the URI, which may carry filter values. Errors are rendered
+// here so the logged status is the one sent.
+func requestLogger() echo.MiddlewareFunc {
+	return middleware.RequestLoggerWithConfig(middleware.RequestLoggerConfig{
+		LogStatus:    true,
+		LogMethod:    true,
+		LogRoutePath: true,
+		LogLatency:   true,
+		LogRemoteIP:  true,
+		LogError:     true,
+		HandleError:  true,
+		LogValuesFunc: func(c echo.Context, values middleware.RequestLoggerValues) error {
+			level := slog.LevelInfo
+			if values.Status >= http.StatusInternalServerError {
+				level = slog.LevelError
+			}
+			attrs := []slog.Attr{
+				slog.String("method", values.Method),
+				slog.String("route", values.RoutePath),
+				slog.Int("status", values.Status),
+				slog.Duration("latency", values.Latency),
+				slog.String("remote_ip", values.RemoteIP),
+			}
+			if values.Error != nil {
+				attrs = append(attrs, slog.String("error", values.Error.Error()))
+			}
+			slog.LogAttrs(c.Request().Context(), level, "HTTP 请求", attrs...)
+			return nil
+		},
+	})
+}
+
+func logPanic(c echo.Context, err error, stack []byte) error {
+	slog.ErrorContext(c.Request().Context(), "处理请求时发生 panic", "error", err, "stack", string(stack))
+	return err
+}
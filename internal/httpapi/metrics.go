@@ -0,0 +1,161 @@
+package httpapi
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"log/slog"
+	"net/http"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/CoxxA/nomadbank/v2/internal/domain"
+	"github.com/CoxxA/nomadbank/v2/internal/metrics"
+	"github.com/CoxxA/nomadbank/v2/internal/sqlite"
+)
+
+type serverMetrics struct {
+	registry        *metrics.Registry
+	requests        *metrics.Counter
+	requestDuration *metrics.Histogram
+	queryDuration   *metrics.Histogram
+	tasks           *metrics.Gauge
+	accounts        *metrics.Gauge
+	buildInfo       *metrics.Gauge
+}
+
+func newServerMetrics() *serverMetrics {
+	registry := metrics.NewRegistry()
+	return &serverMetrics{
+		registry: registry,
+		requests: registry.Counter("nomadbank_http_requests_total",
+			"HTTP requests by method, route template and status.", "method", "route", "status"),
+		requestDuration: registry.Histogram("nomadbank_http_request_duration_seconds",
+			"HTTP request latency by method and route template.", metrics.DefaultBuckets, "method", "route"),
+		queryDuration: registry.Histogram("nomadbank_db_query_duration_seconds",
+			"SQLite statement latency by leading keyword.", metrics.DefaultBuckets, "statement"),
+		tasks: registry.Gauge("nomadbank_tasks",
+			"Tasks by status; overdue tasks are also counted as pending.", "status"),
+		accounts: registry.Gauge("nomadbank_accounts",
+			"Accounts by state.", "state"),
+		buildInfo: registry.Gauge("nomadbank_build_info",
+			"Always 1; labelled with the running build.", "version", "commit", "go_version"),
+	}
+}
+
+// instrument records every request under its route template, so IDs in the
+// URL do not create new series. The request logger inside it has already
+// rendered handler errors; anything else is rendered here so the status is
+// known.
+func (s *Server) instrument(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		started := time.Now()
+		if err := next(c); err != nil {
+			c.Error(err)
+		}
+		route := c.Path()
+		if route == "" {
+			route = "unmatched"
+		}
+		method := metricMethod(c.Request().Method)
+		s.metrics.requests.Inc(method, route, strconv.Itoa(c.Response().Status))
+		s.metrics.requestDuration.Observe(time.Since(started).Seconds(), method, route)
+		return nil
+	}
+}
+
+// metricMethod folds methods this API never routes into one label value.
+func metricMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return method
+	default:
+		return "OTHER"
+	}
+}
+
+// EnableMetrics starts timing database statements and publishes the build.
+// Without METRICS_ADDRESS the endpoint is served at /metrics on the main
+// listener; otherwise the caller serves MetricsHandler on that address.
+func (s *Server) EnableMetrics(version, commit string) {
+	s.metrics.buildInfo.Set(1, version, commit, runtime.Version())
+	s.store.ObserveQueries(func(statement string, elapsed time.Duration) {
+		s.metrics.queryDuration.Observe(elapsed.Seconds(), statement)
+	})
+	s.metricsEnabled = true
+}
+
+// MetricsHandler serves only GET /metrics, for the METRICS_ADDRESS listener.
+func (s *Server) MetricsHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /metrics", func(w http.ResponseWriter, r *http.Request) {
+		if !s.metricsAuthorized(r) {
+			w.Header().Set(echo.HeaderWWWAuthenticate, `Bearer realm="metrics"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		s.writeMetrics(w, r)
+	})
+	return mux
+}
+
+func (s *Server) serveMetrics(c echo.Context) error {
+	if !s.metricsEnabled || s.config.MetricsAddress != "" {
+		return notFound("指标端点未在此地址开放")
+	}
+	if !s.metricsAuthorized(c.Request()) {
+		c.Response().Header().Set(echo.HeaderWWWAuthenticate, `Bearer realm="metrics"`)
+		return apiError(http.StatusUnauthorized, "unauthorized", "指标令牌无效")
+	}
+	s.writeMetrics(c.Response(), c.Request())
+	return nil
+}
+
+// metricsAuthorized accepts any request when no METRICS_TOKEN is set, which
+// config only allows together with a separate METRICS_ADDRESS.
+func (s *Server) metricsAuthorized(r *http.Request) bool {
+	if s.config.MetricsToken == "" {
+		return true
+	}
+	token, ok := strings.CutPrefix(r.Header.Get(echo.HeaderAuthorization), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(s.config.MetricsToken)) == 1
+}
+
+func (s *Server) writeMetrics(w http.ResponseWriter, r *http.Request) {
+	if err := s.refreshGauges(r.Context()); err != nil {
+		slog.ErrorContext(r.Context(), "刷新指标", "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set(echo.HeaderContentType, metrics.ContentType)
+	_ = s.metrics.registry.Write(w)
+}
+
+// refreshGauges reads task and account counts at scrape time. Overdue uses
+// the owner's timezone, like the dashboard.
+func (s *Server) refreshGauges(ctx context.Context) error {
+	location := time.UTC
+	credentials, err := s.store.OwnerCredentials(ctx)
+	switch {
+	case err == nil:
+		if location, err = time.LoadLocation(credentials.Owner.Timezone); err != nil {
+			return err
+		}
+	case !errors.Is(err, sqlite.ErrNotFound):
+		return err
+	}
+	dashboard, err := s.store.Dashboard(ctx, domain.OverdueCutoff(time.Now().In(location)))
+	if err != nil {
+		return err
+	}
+	s.metrics.tasks.Set(float64(dashboard.PendingTasks), string(domain.TaskStatusPending))
+	s.metrics.tasks.Set(float64(dashboard.OverdueTasks), "overdue")
+	s.metrics.tasks.Set(float64(dashboard.CompletedTasks), string(domain.TaskStatusCompleted))
+	s.metrics.accounts.Set(float64(dashboard.ActiveAccounts), "active")
+	s.metrics.accounts.Set(float64(dashboard.TotalAccounts-dashboard.ActiveAccounts), "inactive")
+	return nil
+}
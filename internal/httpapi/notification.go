@@ -0,0 +1,117 @@
+package httpapi
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/CoxxA/nomadbank/v2/internal/domain"
+	"github.com/CoxxA/nomadbank/v2/internal/notify"
+	"github.com/CoxxA/nomadbank/v2/internal/sqlite"
+)
+
+// maxLeadMinutes allows reminders up to a week ahead.
+const maxLeadMinutes = 7 * 24 * 60
+
+// notificationSettingsResponse carries the webhook URL and secret masked;
+// they are credentials and are never returned in full.
+type notificationSettingsResponse struct {
+	Enabled       bool      `json:"enabled"`
+	LeadMinutes   int       `json:"lead_minutes"`
+	WebhookURL    string    `json:"webhook_url"`
+	WebhookSecret string    `json:"webhook_secret"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// notificationSettingsRequest keeps the webhook URL or secret when omitted,
+// since clients only ever see them masked. An empty string clears them.
+type notificationSettingsRequest struct {
+	Enabled       *bool   `json:"enabled"`
+	LeadMinutes   *int    `json:"lead_minutes"`
+	WebhookURL    *string `json:"webhook_url"`
+	WebhookSecret *string `json:"webhook_secret"`
+}
+
+func maskNotificationSettings(settings domain.NotificationSettings) notificationSettingsResponse {
+	return notificationSettingsResponse{
+		Enabled:       settings.Enabled,
+		LeadMinutes:   settings.LeadMinutes,
+		WebhookURL:    notify.MaskURL(settings.WebhookURL),
+		WebhookSecret: notify.MaskSecret(settings.WebhookSecret),
+		UpdatedAt:     settings.UpdatedAt,
+	}
+}
+
+func (s *Server) notificationSettings(c echo.Context) error {
+	settings, err := s.store.NotificationSettings(c.Request().Context())
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, maskNotificationSettings(settings))
+}
+
+func (s *Server) updateNotificationSettings(c echo.Context) error {
+	var request notificationSettingsRequest
+	if err := c.Bind(&request); err != nil {
+		return badRequest("invalid_json", "请求格式错误")
+	}
+	if request.Enabled == nil || request.LeadMinutes == nil {
+		return badRequest("missing_fields", "enabled 和 lead_minutes 均为必填项")
+	}
+	if *request.LeadMinutes < 0 || *request.LeadMinutes > maxLeadMinutes {
+		return badRequest("invalid_lead_minutes", "提前提醒时间需在 0～10080 分钟之间")
+	}
+	ctx := c.Request().Context()
+	settings, err := s.store.NotificationSettings(ctx)
+	if err != nil {
+		return err
+	}
+	before := maskNotificationSettings(settings)
+	settings.Enabled = *request.Enabled
+	settings.LeadMinutes = *request.LeadMinutes
+	if request.WebhookURL != nil {
+		settings.WebhookURL = strings.TrimSpace(*request.WebhookURL)
+		if settings.WebhookURL != "" && !validWebhookURL(settings.WebhookURL) {
+			return badRequest("invalid_webhook_url", "Webhook 地址必须是 http 或 https 的完整 URL")
+		}
+	}
+	if request.WebhookSecret != nil {
+		settings.WebhookSecret = strings.TrimSpace(*request.WebhookSecret)
+		if len(settings.WebhookSecret) > 256 {
+			return badRequest("invalid_webhook_secret", "Webhook 密钥不能超过 256 个字符")
+		}
+	}
+	if settings.Enabled && settings.WebhookURL == "" {
+		return badRequest("webhook_required", "启用提醒前需要设置 Webhook 地址")
+	}
+	var after notificationSettingsResponse
+	err = s.store.WithTx(ctx, func(tx *sqlite.Store) error {
+		if err := tx.UpdateNotificationSettings(ctx, &settings); err != nil {
+			return err
+		}
+		after = maskNotificationSettings(settings)
+		return s.audit(c, tx, domain.AuditNotificationsUpdate, 0, before, after)
+	})
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, after)
+}
+
+func validWebhookURL(raw string) bool {
+	parsed, err := url.Parse(raw)
+	return err == nil && len(raw) <= 2048 && parsed.Host != "" &&
+		(parsed.Scheme == "http" || parsed.Scheme == "https")
+}
+
+// listNotificationDeliveries shows the 50 most recently queued reminders.
+func (s *Server) listNotificationDeliveries(c echo.Context) error {
+	deliveries, err := s.store.ListDeliveries(c.Request().Context(), 50)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, deliveries)
+}
@@ -0,0 +1,107 @@
+package httpapi
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// proxyTrust decides which connections may set forwarding headers: peers
+// in TRUSTED_PROXIES and, when listening on a unix socket, every socket
+// peer, since only local processes with access to the socket file can
+// connect and such peers have no IP address to match anyway.
+type proxyTrust struct {
+	ranges []*net.IPNet
+	socket bool
+}
+
+func (p proxyTrust) trusts(ip net.IP) bool {
+	for _, ipRange := range p.ranges {
+		if ipRange.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p proxyTrust) trustsPeer(remoteAddr string) bool {
+	if ip := net.ParseIP(directIP(remoteAddr)); ip != nil {
+		return p.trusts(ip)
+	}
+	return p.socket
+}
+
+// clientIP believes forwarding headers only when the connection comes from
+// a trusted proxy, and then only the hops appended by trusted proxies: the
+// client address is the nearest untrusted one in X-Forwarded-For, or
+// X-Real-IP when no X-Forwarded-For is sent. Otherwise the connection
+// address is used, so clients cannot pick their rate-limit key.
+func (p proxyTrust) clientIP(r *http.Request) string {
+	direct := directIP(r.RemoteAddr)
+	if !p.trustsPeer(r.RemoteAddr) {
+		return direct
+	}
+	if forwarded := r.Header.Values(echo.HeaderXForwardedFor); len(forwarded) > 0 {
+		hops := strings.Split(strings.Join(forwarded, ","), ",")
+		for index := len(hops) - 1; index >= 0; index-- {
+			ip := parseHop(hops[index])
+			if ip == nil {
+				return direct
+			}
+			if index == 0 || !p.trusts(ip) {
+				return ip.String()
+			}
+		}
+	}
+	if ip := parseHop(r.Header.Get(echo.HeaderXRealIP)); ip != nil {
+		return ip.String()
+	}
+	return direct
+}
+
+func directIP(remoteAddr string) string {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return remoteAddr
+	}
+	return host
+}
+
+func parseHop(hop string) net.IP {
+	hop = strings.TrimSpace(hop)
+	return net.ParseIP(strings.TrimSuffix(strings.TrimPrefix(hop, "["), "]"))
+}
+
+// fromTrustedProxy reports whether the connection itself comes from a
+// trusted proxy.
+func (s *Server) fromTrustedProxy(r *http.Request) bool {
+	return s.proxies.trustsPeer(r.RemoteAddr)
+}
+
+// requestIsSecure decides whether cookies get the Secure flag. The
+// X-Forwarded-Proto header counts only when a trusted proxy sent it.
+func (s *Server) requestIsSecure(c echo.Context) bool {
+	request := c.Request()
+	if request.TLS != nil {
+		return true
+	}
+	return s.fromTrustedProxy(request) &&
+		strings.EqualFold(request.Header.Get(echo.HeaderXForwardedProto), "https")
+}
+
+// requestHost is the host browsers use for the app: the PUBLIC_URL host when
+// set, else X-Forwarded-Host from a trusted proxy, since proxies often
+// rewrite Host to the upstream address, else Host.
+func (s *Server) requestHost(c echo.Context) string {
+	if s.publicHost != "" {
+		return s.publicHost
+	}
+	request := c.Request()
+	if forwarded := request.Header.Get("X-Forwarded-Host"); forwarded != "" && s.fromTrustedProxy(request) {
+		first, _, _ := strings.Cut(forwarded, ",")
+		return strings.TrimSpace(first)
+	}
+	return request.Host
+}
@@ -3,6 +3,7 @@ package httpapi
 import (
 	"context"
 	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/labstack/echo/v4"
@@ -11,57 +12,78 @@ import (
 
 	"github.com/CoxxA/nomadbank/v2/internal/auth"
 	"github.com/CoxxA/nomadbank/v2/internal/config"
+	"github.com/CoxxA/nomadbank/v2/internal/event"
+	"github.com/CoxxA/nomadbank/v2/internal/notify"
 	"github.com/CoxxA/nomadbank/v2/internal/sqlite"
 	taskservice "github.com/CoxxA/nomadbank/v2/internal/task"
 )
 
 const sessionCookieName = "nomadbank_session"
 
+// writeTimeout bounds how long a handler has to answer.
+const writeTimeout = 30 * time.Second
+
 type Server struct {
 	echo        *echo.Echo
 	config      config.Config
 	store       *sqlite.Store
 	authService *auth.Service
 	taskService *taskservice.Service
+	events      *event.Bus
+	webhooks    *notify.Dispatcher
+	metrics     *serverMetrics
+	proxies     proxyTrust
+	// publicHost is the host of PUBLIC_URL, if set.
+	publicHost string
+	// metricsEnabled is set once by EnableMetrics before serving.
+	metricsEnabled bool
 }
 
 func New(config config.Config, store *sqlite.Store) *Server {
 	e := echo.New()
 	e.HideBanner = true
+	e.HidePort = true
 	e.HTTPErrorHandler = errorHandler
-	// Do not trust client-controlled forwarding headers for security decisions
-	// such as the authentication rate-limit key. A reverse proxy still works;
-	// in this single-user application its clients simply share the proxy bucket.
-	e.IPExtractor = echo.ExtractIPDirect()
+	// Forwarding headers decide the rate-limit key and recorded client IPs, so
+	// they are only read from TRUSTED_PROXIES or a unix socket peer. Without
+	// either, clients behind a proxy share its rate-limit bucket.
+	_, socket := config.UnixSocket()
+	proxies := proxyTrust{ranges: config.TrustedProxies, socket: socket}
+	e.IPExtractor = proxies.clientIP
 	e.Server.ReadHeaderTimeout = 5 * time.Second
 	e.Server.ReadTimeout = 15 * time.Second
-	e.Server.WriteTimeout = 30 * time.Second
+	e.Server.WriteTimeout = writeTimeout
 	e.Server.IdleTimeout = 60 * time.Second
-	e.Use(middleware.Recover())
-	e.Use(middleware.RequestLoggerWithConfig(middleware.RequestLoggerConfig{
-		LogStatus: true,
-		LogMethod: true,
-		LogURI:    true,
-		LogError:  true,
-		LogValuesFunc: func(c echo.Context, values middleware.RequestLoggerValues) error {
-			if values.Error != nil {
-				c.Logger().Errorf("%s %s %d: %v", values.Method, values.URI, values.Status, values.Error)
-				return nil
-			}
-			c.Logger().Infof("%s %s %d", values.Method, values.URI, values.Status)
-			return nil
-		},
-	}))
-	e.Use(middleware.BodyLimit("1M"))
-	e.Use(middleware.RemoveTrailingSlash())
-
 	server := &Server{
 		echo:        e,
 		config:      config,
 		store:       store,
 		authService: auth.NewService(store, config.SessionDays),
 		taskService: taskservice.NewService(store, nil),
+		events:      event.NewBus(),
+		webhooks:    notify.NewDispatcher(store),
+		metrics:     newServerMetrics(),
+		proxies:     proxies,
+	}
+	if publicURL, err := url.Parse(config.PublicURL); err == nil {
+		server.publicHost = publicURL.Host
 	}
+	if config.BasePath != "" {
+		e.Pre(stripBasePath(config.BasePath))
+	}
+	e.Use(requestID)
+	e.Use(server.instrument)
+	e.Use(requestLogger())
+	e.Use(middleware.RecoverWithConfig(middleware.RecoverConfig{
+		DisableStackAll:     true,
+		DisableErrorHandler: true,
+		LogErrorFunc:        logPanic,
+	}))
+	e.Use(middleware.BodyLimit("1M"))
+	e.Use(middleware.RemoveTrailingSlash())
+
+	// Close event streams first; otherwise Shutdown waits for them to time out.
+	e.Server.RegisterOnShutdown(server.events.Close)
 	server.registerRoutes()
 	return server
 }
@@ -69,8 +91,15 @@ func New(config config.Config, store *sqlite.Store) *Server {
 func (s *Server) registerRoutes() {
 	s.echo.GET("/health", s.health)
 	s.echo.GET("/health/ready", s.ready)
+	s.echo.GET("/metrics", s.serveMetrics)
+	// Completion links work without a session; the signed token is the
+	// credential.
+	s.echo.GET("/t/:token", s.taskLinkPage)
+	s.echo.POST("/t/:token", s.completeTaskByLink, s.requireSameOrigin)
 
 	api := s.echo.Group("/api/v1")
+	// The per-IP limiter only absorbs floods. Failed logins are counted in the
+	// database by auth.Service regardless of the client address.
 	authLimiter := middleware.RateLimiterWithConfig(middleware.RateLimiterConfig{
 		Store: middleware.NewRateLimiterMemoryStoreWithConfig(middleware.RateLimiterMemoryStoreConfig{
 			Rate:      rate.Limit(1),
@@ -82,42 +111,84 @@ func (s *Server) registerRoutes() {
 		},
 	})
 	api.GET("/setup", s.setupStatus)
-	api.POST("/setup", s.setup, authLimiter)
-	api.POST("/session", s.login, authLimiter)
+	api.POST("/setup", s.setup, s.requireSameOrigin, authLimiter)
+	api.POST("/session", s.login, s.requireSameOrigin, authLimiter)
 
 	protected := api.Group("")
-	protected.Use(s.requireSession)
-	protected.DELETE("/session", s.logout)
+	protected.Use(s.requireSession, s.requireCSRF)
+	protected.DELETE("/session", s.logout, requireBrowserSession)
 	protected.GET("/me", s.me)
 	protected.PUT("/me", s.updateOwner)
-	protected.PUT("/me/password", s.changePassword)
+	protected.PUT("/me/password", s.changePassword, requireBrowserSession)
+	protected.GET("/me/totp", s.totpStatus, requireBrowserSession)
+	protected.POST("/me/totp/enrollment", s.beginTOTPEnrollment, requireBrowserSession)
+	protected.POST("/me/totp/confirm", s.confirmTOTP, requireBrowserSession)
+	protected.POST("/me/totp/disable", s.disableTOTP, requireBrowserSession)
+	protected.POST("/me/totp/recovery-codes", s.regenerateRecoveryCodes, requireBrowserSession)
+	protected.GET("/me/login-activity", s.loginActivity, requireBrowserSession)
+	protected.GET("/sessions", s.listSessions, requireBrowserSession)
+	protected.DELETE("/sessions", s.deleteOtherSessions, requireBrowserSession)
+	protected.DELETE("/sessions/:id", s.deleteSession, requireBrowserSession)
+	protected.GET("/audit", s.listAuditEvents)
+	protected.GET("/api-tokens", s.listAPITokens, requireBrowserSession)
+	protected.POST("/api-tokens", s.createAPIToken, requireBrowserSession)
+	protected.DELETE("/api-tokens/:id", s.deleteAPIToken, requireBrowserSession)
+	protected.GET("/notifications", s.notificationSettings, requireBrowserSession)
+	protected.PUT("/notifications", s.updateNotificationSettings, requireBrowserSession)
+	protected.GET("/notifications/deliveries", s.listNotificationDeliveries, requireBrowserSession)
+	protected.GET("/notifications/email", s.emailDigestSettings, requireBrowserSession)
+	protected.PUT("/notifications/email", s.updateEmailDigestSettings, requireBrowserSession)
+	protected.GET("/webhooks", s.listWebhooks, requireBrowserSession)
+	protected.POST("/webhooks", s.createWebhook, requireBrowserSession)
+	protected.PUT("/webhooks/:id", s.updateWebhook, requireBrowserSession)
+	protected.DELETE("/webhooks/:id", s.deleteWebhook, requireBrowserSession)
+	protected.GET("/webhooks/:id/deliveries", s.listWebhookDeliveries, requireBrowserSession)
+	protected.POST("/webhooks/:id/test", s.testWebhook, requireBrowserSession)
 
 	protected.GET("/accounts", s.listAccounts)
-	protected.POST("/accounts", s.createAccount)
+	protected.POST("/accounts", s.createAccount, s.idempotent)
 	protected.GET("/accounts/:id", s.getAccount)
 	protected.PUT("/accounts/:id", s.updateAccount)
 	protected.DELETE("/accounts/:id", s.deleteAccount)
 
 	protected.GET("/strategies", s.listStrategies)
-	protected.POST("/strategies", s.createStrategy)
+	protected.POST("/strategies", s.createStrategy, s.idempotent)
 	protected.GET("/strategies/:id", s.getStrategy)
 	protected.PUT("/strategies/:id", s.updateStrategy)
 	protected.DELETE("/strategies/:id", s.deleteStrategy)
 
 	protected.GET("/task-batches", s.listTaskBatches)
-	protected.POST("/task-batches", s.createTaskBatch)
+	protected.POST("/task-batches", s.createTaskBatch, s.idempotent)
 	protected.DELETE("/task-batches/:id", s.deleteTaskBatch)
 	protected.GET("/tasks", s.listTasks)
 	protected.POST("/tasks/:id/complete", s.completeTask)
+	protected.POST("/tasks/:id/link", s.createTaskLink)
 	protected.GET("/dashboard", s.dashboard)
+	protected.GET("/events", s.streamEvents)
 }
 
 func (s *Server) Echo() *echo.Echo {
 	return s.echo
 }
 
+// Events exposes the bus that handlers publish committed changes to.
+func (s *Server) Events() *event.Bus {
+	return s.events
+}
+
+// Webhooks exposes the dispatcher that delivers Events to event webhooks.
+// It only delivers while Run is running.
+func (s *Server) Webhooks() *notify.Dispatcher {
+	return s.webhooks
+}
+
+// Start serves HTTPS once EnableTLS has configured it, and HTTP otherwise,
+// on a TCP address or a unix socket as LISTEN says.
 func (s *Server) Start() error {
-	return s.echo.Start(s.config.Address())
+	if err := s.listen(); err != nil {
+		return err
+	}
+	return s.echo.StartServer(s.echo.Server)
 }
 
 func (s *Server) Shutdown(ctx context.Context) error {
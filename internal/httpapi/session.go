@@ -2,7 +2,10 @@ package httpapi
 
 import (
 	"errors"
+	"fmt"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 	"unicode/utf8"
@@ -24,6 +27,7 @@ type setupRequest struct {
 type loginRequest struct {
 	Username string `json:"username"`
 	Password string `json:"password"`
+	Code     string `json:"code"`
 }
 
 type updateOwnerRequest struct {
@@ -49,11 +53,22 @@ func (s *Server) setup(c echo.Context) error {
 	if err := c.Bind(&request); err != nil {
 		return badRequest("invalid_json", "请求格式错误")
 	}
-	session, err := s.authService.Setup(c.Request().Context(), auth.SetupInput{
-		Username:    request.Username,
-		Password:    request.Password,
-		DisplayName: request.DisplayName,
-		Timezone:    request.Timezone,
+	var session auth.Session
+	ctx := c.Request().Context()
+	err := s.store.WithTx(ctx, func(tx *sqlite.Store) error {
+		var err error
+		session, err = s.authService.InTx(tx).Setup(ctx, auth.SetupInput{
+			Username:    request.Username,
+			Password:    request.Password,
+			DisplayName: request.DisplayName,
+			Timezone:    request.Timezone,
+		}, sessionClient(c))
+		if err != nil {
+			return err
+		}
+		// The request is now made by the new owner, which the audit event names.
+		c.Set("owner", session.Owner)
+		return s.audit(c, tx, domain.AuditOwnerSetup, 0, nil, session.Owner)
 	})
 	if err != nil {
 		switch {
@@ -74,20 +89,71 @@ func (s *Server) login(c echo.Context) error {
 	if err := c.Bind(&request); err != nil {
 		return badRequest("invalid_json", "请求格式错误")
 	}
-	session, err := s.authService.Login(c.Request().Context(), request.Username, request.Password)
+	// A refused login still commits the failed attempt it recorded; only a
+	// successful one is audited.
+	var (
+		session  auth.Session
+		loginErr error
+	)
+	ctx := c.Request().Context()
+	err := s.store.WithTx(ctx, func(tx *sqlite.Store) error {
+		session, loginErr = s.authService.InTx(tx).Login(ctx, auth.LoginInput{
+			Username: request.Username,
+			Password: request.Password,
+			Code:     request.Code,
+		}, sessionClient(c))
+		if loginErr != nil {
+			return nil
+		}
+		c.Set("owner", session.Owner)
+		return s.audit(c, tx, domain.AuditSessionLogin, 0, nil, nil)
+	})
+	if err == nil {
+		err = loginErr
+	}
+	var throttled *auth.LoginThrottledError
+	if errors.As(err, &throttled) {
+		return loginThrottled(c, throttled)
+	}
 	if err != nil {
-		if errors.Is(err, auth.ErrInvalidCredentials) {
+		switch {
+		case errors.Is(err, auth.ErrInvalidCredentials):
 			return apiError(http.StatusUnauthorized, "invalid_credentials", "用户名或密码错误")
+		case errors.Is(err, auth.ErrSecondFactorRequired):
+			return apiError(http.StatusUnauthorized, "totp_required", "请输入两步验证码或恢复码")
+		case errors.Is(err, auth.ErrInvalidSecondFactor):
+			return apiError(http.StatusUnauthorized, "invalid_totp", "两步验证码或恢复码错误")
+		default:
+			return err
 		}
-		return err
 	}
 	s.setSessionCookie(c, session)
 	return c.JSON(http.StatusOK, session.Owner)
 }
 
+func loginThrottled(c echo.Context, throttled *auth.LoginThrottledError) error {
+	wait := max(time.Until(throttled.RetryAt), time.Second)
+	seconds := int(math.Ceil(wait.Seconds()))
+	c.Response().Header().Set("Retry-After", strconv.Itoa(seconds))
+	if throttled.Locked {
+		minutes := int(math.Ceil(wait.Minutes()))
+		return apiError(http.StatusTooManyRequests, "login_locked",
+			fmt.Sprintf("登录失败次数过多，已临时锁定，请 %d 分钟后再试", minutes))
+	}
+	return apiError(http.StatusTooManyRequests, "login_throttled",
+		fmt.Sprintf("登录失败次数过多，请 %d 秒后再试", seconds))
+}
+
 func (s *Server) logout(c echo.Context) error {
 	token := sessionToken(c)
-	if err := s.authService.Logout(c.Request().Context(), token); err != nil {
+	ctx := c.Request().Context()
+	err := s.store.WithTx(ctx, func(tx *sqlite.Store) error {
+		if err := s.authService.InTx(tx).Logout(ctx, token); err != nil {
+			return err
+		}
+		return s.audit(c, tx, domain.AuditSessionLogout, 0, nil, nil)
+	})
+	if err != nil {
 		return err
 	}
 	s.clearSessionCookie(c)
@@ -125,9 +191,17 @@ func (s *Server) updateOwner(c echo.Context) error {
 	if utf8.RuneCountInString(displayName) > domain.MaxDisplayNameRunes {
 		return badRequest("invalid_display_name", "显示名称不能超过 80 个字符")
 	}
+	before := owner
 	owner.DisplayName = displayName
 	owner.Timezone = timezone
-	if err := s.store.UpdateOwner(c.Request().Context(), owner); err != nil {
+	ctx := c.Request().Context()
+	err := s.store.WithTx(ctx, func(tx *sqlite.Store) error {
+		if err := tx.UpdateOwner(ctx, owner); err != nil {
+			return err
+		}
+		return s.audit(c, tx, domain.AuditOwnerUpdate, 0, before, owner)
+	})
+	if err != nil {
 		return err
 	}
 	return c.JSON(http.StatusOK, owner)
@@ -138,11 +212,16 @@ func (s *Server) changePassword(c echo.Context) error {
 	if err := c.Bind(&request); err != nil {
 		return badRequest("invalid_json", "请求格式错误")
 	}
-	session, err := s.authService.ChangePassword(
-		c.Request().Context(),
-		request.CurrentPassword,
-		request.NewPassword,
-	)
+	var session auth.Session
+	ctx := c.Request().Context()
+	err := s.store.WithTx(ctx, func(tx *sqlite.Store) error {
+		var err error
+		session, err = s.authService.InTx(tx).ChangePassword(ctx, request.CurrentPassword, request.NewPassword, sessionClient(c))
+		if err != nil {
+			return err
+		}
+		return s.audit(c, tx, domain.AuditOwnerPasswordChange, 0, nil, nil)
+	})
 	if err != nil {
 		switch {
 		case errors.Is(err, auth.ErrInvalidInput):
@@ -157,21 +236,134 @@ func (s *Server) changePassword(c echo.Context) error {
 	return c.NoContent(http.StatusNoContent)
 }
 
+func (s *Server) listSessions(c echo.Context) error {
+	sessions, err := s.store.ListSessions(c.Request().Context(), sessionToken(c), time.Now())
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, sessions)
+}
+
+// deleteSession revokes a single session. Revoking the current one works
+// like logging out.
+func (s *Server) deleteSession(c echo.Context) error {
+	id, err := parseID(c.Param("id"))
+	if err != nil {
+		return err
+	}
+	var current bool
+	ctx := c.Request().Context()
+	err = s.store.WithTx(ctx, func(tx *sqlite.Store) error {
+		var err error
+		if current, err = tx.DeleteSessionByID(ctx, id, sessionToken(c)); err != nil {
+			return err
+		}
+		return s.audit(c, tx, domain.AuditSessionRevoke, id, nil, nil)
+	})
+	if err != nil {
+		return mapStoreError(err, "会话不存在")
+	}
+	if current {
+		s.clearSessionCookie(c)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (s *Server) deleteOtherSessions(c echo.Context) error {
+	var revoked int64
+	ctx := c.Request().Context()
+	err := s.store.WithTx(ctx, func(tx *sqlite.Store) error {
+		var err error
+		if revoked, err = tx.DeleteOtherSessions(ctx, sessionToken(c)); err != nil {
+			return err
+		}
+		return s.audit(c, tx, domain.AuditSessionRevokeOthers, 0, nil, nil)
+	})
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, map[string]int64{"revoked": revoked})
+}
+
+// loginActivityLimit is how many recent login attempts settings shows.
+const loginActivityLimit = 20
+
+type loginActivityResponse struct {
+	ConsecutiveFailures int                   `json:"consecutive_failures"`
+	RetryAt             *time.Time            `json:"retry_at"`
+	Locked              bool                  `json:"locked"`
+	Attempts            []domain.LoginAttempt `json:"attempts"`
+}
+
+func (s *Server) loginActivity(c echo.Context) error {
+	ctx := c.Request().Context()
+	throttle, err := s.authService.LoginThrottle(ctx)
+	if err != nil {
+		return err
+	}
+	attempts, err := s.authService.LoginActivity(ctx, loginActivityLimit)
+	if err != nil {
+		return err
+	}
+	response := loginActivityResponse{
+		ConsecutiveFailures: throttle.Failures,
+		Locked:              throttle.Locked,
+		Attempts:            attempts,
+	}
+	if !throttle.RetryAt.IsZero() {
+		response.RetryAt = &throttle.RetryAt
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+func sessionClient(c echo.Context) auth.Client {
+	return auth.Client{UserAgent: c.Request().UserAgent(), IP: c.RealIP()}
+}
+
 func (s *Server) requireSession(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
-		owner, err := s.authService.Authenticate(c.Request().Context(), sessionToken(c))
+		owner, token, err := s.authenticate(c)
 		if err != nil {
 			if errors.Is(err, auth.ErrInvalidSession) {
-				s.clearSessionCookie(c)
+				if token == nil {
+					s.clearSessionCookie(c)
+				}
 				return unauthorized()
 			}
 			return err
 		}
+		if token != nil {
+			if token.Scope == domain.APITokenScopeReadOnly && !safeMethod(c.Request().Method) {
+				return apiError(http.StatusForbidden, "insufficient_scope", "该 API 令牌只有只读权限")
+			}
+			c.Set("api_token", *token)
+		}
 		c.Set("owner", owner)
 		return next(c)
 	}
 }
 
+// authenticate accepts either an `Authorization: Bearer` API token or the
+// session cookie. A bearer header wins so scripts never fall back to a cookie.
+// The returned token is non-nil whenever a bearer header was supplied.
+func (s *Server) authenticate(c echo.Context) (domain.Owner, *domain.APIToken, error) {
+	if rawToken, ok := bearerToken(c); ok {
+		owner, token, err := s.authService.AuthenticateAPIToken(c.Request().Context(), rawToken)
+		return owner, &token, err
+	}
+	owner, err := s.authService.Authenticate(c.Request().Context(), sessionToken(c))
+	return owner, nil, err
+}
+
+func bearerToken(c echo.Context) (string, bool) {
+	header := c.Request().Header.Get(echo.HeaderAuthorization)
+	scheme, token, found := strings.Cut(header, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	return strings.TrimSpace(token), true
+}
+
 func sessionToken(c echo.Context) string {
 	cookie, err := c.Cookie(sessionCookieName)
 	if err != nil {
@@ -184,28 +376,26 @@ func (s *Server) setSessionCookie(c echo.Context, session auth.Session) {
 	c.SetCookie(&http.Cookie{
 		Name:     sessionCookieName,
 		Value:    session.Token,
-		Path:     "/",
+		Path:     s.cookiePath(),
 		Expires:  session.ExpiresAt,
 		MaxAge:   int(time.Until(session.ExpiresAt).Seconds()),
 		HttpOnly: true,
-		Secure:   requestIsSecure(c),
+		Secure:   s.requestIsSecure(c),
 		SameSite: http.SameSiteLaxMode,
 	})
+	s.setCSRFCookie(c, csrfToken(session.Token), session.ExpiresAt)
 }
 
 func (s *Server) clearSessionCookie(c echo.Context) {
 	c.SetCookie(&http.Cookie{
 		Name:     sessionCookieName,
 		Value:    "",
-		Path:     "/",
+		Path:     s.cookiePath(),
 		MaxAge:   -1,
 		Expires:  time.Unix(0, 0),
 		HttpOnly: true,
-		Secure:   requestIsSecure(c),
+		Secure:   s.requestIsSecure(c),
 		SameSite: http.SameSiteLaxMode,
 	})
-}
-
-func requestIsSecure(c echo.Context) bool {
-	return c.Request().TLS != nil || strings.EqualFold(c.Request().Header.Get("X-Forwarded-Proto"), "https")
+	s.setCSRFCookie(c, "", time.Time{})
 }
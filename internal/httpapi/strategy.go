@@ -1,13 +1,14 @@
 package httpapi
 
 import (
+	"errors"
 	"net/http"
-	"strings"
-	"unicode/utf8"
 
 	"github.com/labstack/echo/v4"
 
 	"github.com/CoxxA/nomadbank/v2/internal/domain"
+	"github.com/CoxxA/nomadbank/v2/internal/event"
+	"github.com/CoxxA/nomadbank/v2/internal/sqlite"
 )
 
 type strategyRequest struct {
@@ -39,6 +40,7 @@ func (s *Server) getStrategy(c echo.Context) error {
 	if err != nil {
 		return mapStoreError(err, "策略不存在")
 	}
+	setEntityTag(c, strategy.Version)
 	return c.JSON(http.StatusOK, strategy)
 }
 
@@ -51,9 +53,22 @@ func (s *Server) createStrategy(c echo.Context) error {
 	if err != nil {
 		return err
 	}
-	if err := s.store.CreateStrategy(c.Request().Context(), &strategy); err != nil {
+	ctx := c.Request().Context()
+	err = s.store.WithTx(ctx, func(tx *sqlite.Store) error {
+		if err := tx.CreateStrategy(ctx, &strategy); err != nil {
+			return err
+		}
+		if err := s.webhooks.Queue(ctx, tx, event.StrategyCreated, strategy.ID); err != nil {
+			return err
+		}
+		return s.audit(c, tx, domain.AuditStrategyCreate, strategy.ID, nil, strategy)
+	})
+	if err != nil {
 		return mapStoreError(err, "策略不存在")
 	}
+	s.events.Publish(event.StrategyCreated, strategy.ID)
+	setEntityTag(c, strategy.Version)
+	s.setLocation(c, "strategies", strategy.ID)
 	return c.JSON(http.StatusCreated, strategy)
 }
 
@@ -66,6 +81,9 @@ func (s *Server) updateStrategy(c echo.Context) error {
 	if err != nil {
 		return mapStoreError(err, "策略不存在")
 	}
+	if err := checkIfMatch(c, existing.Version); err != nil {
+		return err
+	}
 	var request strategyRequest
 	if err := c.Bind(&request); err != nil {
 		return badRequest("invalid_json", "请求格式错误")
@@ -74,9 +92,21 @@ func (s *Server) updateStrategy(c echo.Context) error {
 	if err != nil {
 		return err
 	}
-	if err := s.store.UpdateStrategy(c.Request().Context(), &strategy); err != nil {
+	ctx := c.Request().Context()
+	err = s.store.WithTx(ctx, func(tx *sqlite.Store) error {
+		if err := tx.UpdateStrategy(ctx, &strategy); err != nil {
+			return err
+		}
+		if err := s.webhooks.Queue(ctx, tx, event.StrategyUpdated, strategy.ID); err != nil {
+			return err
+		}
+		return s.audit(c, tx, domain.AuditStrategyUpdate, strategy.ID, existing, strategy)
+	})
+	if err != nil {
 		return mapStoreError(err, "策略不存在")
 	}
+	s.events.Publish(event.StrategyUpdated, strategy.ID)
+	setEntityTag(c, strategy.Version)
 	return c.JSON(http.StatusOK, strategy)
 }
 
@@ -85,9 +115,32 @@ func (s *Server) deleteStrategy(c echo.Context) error {
 	if err != nil {
 		return err
 	}
-	if err := s.store.DeleteStrategy(c.Request().Context(), id); err != nil {
+	existing, err := s.store.GetStrategy(c.Request().Context(), id)
+	if err != nil {
+		return mapStoreError(err, "策略不存在")
+	}
+	// Without If-Match the delete is unconditional.
+	var version int64
+	if c.Request().Header.Get("If-Match") != "" {
+		if err := checkIfMatch(c, existing.Version); err != nil {
+			return err
+		}
+		version = existing.Version
+	}
+	ctx := c.Request().Context()
+	err = s.store.WithTx(ctx, func(tx *sqlite.Store) error {
+		if err := tx.DeleteStrategy(ctx, id, version); err != nil {
+			return err
+		}
+		if err := s.webhooks.Queue(ctx, tx, event.StrategyDeleted, id); err != nil {
+			return err
+		}
+		return s.audit(c, tx, domain.AuditStrategyDelete, id, existing, nil)
+	})
+	if err != nil {
 		return mapStoreError(err, "策略不存在")
 	}
+	s.events.Publish(event.StrategyDeleted, id)
 	return c.NoContent(http.StatusNoContent)
 }
 
@@ -97,28 +150,11 @@ func strategyFromRequest(request strategyRequest, existing *domain.Strategy) (do
 		request.AmountMinCents == nil || request.AmountMaxCents == nil || request.DailyLimit == nil {
 		return domain.Strategy{}, badRequest("missing_fields", "策略字段均为必填项")
 	}
-	name := strings.TrimSpace(*request.Name)
-	if utf8.RuneCountInString(name) < 1 || utf8.RuneCountInString(name) > 100 {
-		return domain.Strategy{}, badRequest("invalid_strategy_name", "策略名称需为 1～100 个字符")
-	}
-	if *request.IntervalMinDays < 1 || *request.IntervalMaxDays < *request.IntervalMinDays || *request.IntervalMaxDays > 365 {
-		return domain.Strategy{}, badRequest("invalid_interval", "间隔天数需在 1～365 之间，且最大值不能小于最小值")
-	}
-	if *request.TimeStartMinutes < 0 || *request.TimeEndMinutes > 1440 || *request.TimeEndMinutes <= *request.TimeStartMinutes {
-		return domain.Strategy{}, badRequest("invalid_time_range", "执行时段无效")
-	}
-	if *request.AmountMinCents < 1 || *request.AmountMaxCents < *request.AmountMinCents || *request.AmountMaxCents > 100_000_000 {
-		return domain.Strategy{}, badRequest("invalid_amount_range", "金额范围无效")
-	}
-	if *request.DailyLimit < 1 || *request.DailyLimit > 100 {
-		return domain.Strategy{}, badRequest("invalid_daily_limit", "每日任务上限需在 1～100 之间")
-	}
-
 	strategy := domain.Strategy{}
 	if existing != nil {
 		strategy = *existing
 	}
-	strategy.Name = name
+	strategy.Name = *request.Name
 	strategy.IntervalMinDays = *request.IntervalMinDays
 	strategy.IntervalMaxDays = *request.IntervalMaxDays
 	strategy.TimeStartMinutes = *request.TimeStartMinutes
@@ -127,5 +163,18 @@ func strategyFromRequest(request strategyRequest, existing *domain.Strategy) (do
 	strategy.AmountMinCents = *request.AmountMinCents
 	strategy.AmountMaxCents = *request.AmountMaxCents
 	strategy.DailyLimit = *request.DailyLimit
+	strategy, err := domain.NormalizeStrategy(strategy)
+	switch {
+	case errors.Is(err, domain.ErrInvalidStrategyName):
+		return domain.Strategy{}, badRequest("invalid_strategy_name", err.Error())
+	case errors.Is(err, domain.ErrInvalidInterval):
+		return domain.Strategy{}, badRequest("invalid_interval", err.Error())
+	case errors.Is(err, domain.ErrInvalidTimeRange):
+		return domain.Strategy{}, badRequest("invalid_time_range", err.Error())
+	case errors.Is(err, domain.ErrInvalidAmountRange):
+		return domain.Strategy{}, badRequest("invalid_amount_range", err.Error())
+	case errors.Is(err, domain.ErrInvalidDailyLimit):
+		return domain.Strategy{}, badRequest("invalid_daily_limit", err.Error())
+	}
 	return strategy, nil
 }
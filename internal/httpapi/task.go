@@ -10,6 +10,7 @@ import (
 	"github.com/labstack/echo/v4"
 
 	"github.com/CoxxA/nomadbank/v2/internal/domain"
+	"github.com/CoxxA/nomadbank/v2/internal/event"
 	"github.com/CoxxA/nomadbank/v2/internal/sqlite"
 	taskservice "github.com/CoxxA/nomadbank/v2/internal/task"
 )
@@ -36,10 +37,22 @@ func (s *Server) createTaskBatch(c echo.Context) error {
 	if request.StrategyID <= 0 {
 		return badRequest("invalid_strategy", "请选择策略")
 	}
-	result, err := s.taskService.Generate(c.Request().Context(), taskservice.GenerateInput{
-		StrategyID: request.StrategyID,
-		GroupName:  request.GroupName,
-		Cycles:     request.Cycles,
+	var result taskservice.GenerateResult
+	ctx := c.Request().Context()
+	err := s.store.WithTx(ctx, func(tx *sqlite.Store) error {
+		var err error
+		result, err = s.taskService.InTx(tx).Generate(ctx, taskservice.GenerateInput{
+			StrategyID: request.StrategyID,
+			GroupName:  request.GroupName,
+			Cycles:     request.Cycles,
+		})
+		if err != nil {
+			return err
+		}
+		if err := s.webhooks.Queue(ctx, tx, event.BatchCreated, result.Batch.ID); err != nil {
+			return err
+		}
+		return s.audit(c, tx, domain.AuditTaskBatchCreate, result.Batch.ID, nil, result.Batch)
 	})
 	if err != nil {
 		switch {
@@ -53,6 +66,7 @@ func (s *Server) createTaskBatch(c echo.Context) error {
 			return err
 		}
 	}
+	s.events.Publish(event.BatchCreated, result.Batch.ID)
 	return c.JSON(http.StatusCreated, result)
 }
 
@@ -61,9 +75,24 @@ func (s *Server) deleteTaskBatch(c echo.Context) error {
 	if err != nil {
 		return err
 	}
-	if err := s.store.DeleteTaskBatch(c.Request().Context(), id); err != nil {
+	batch, err := s.store.GetTaskBatch(c.Request().Context(), id)
+	if err != nil {
+		return mapStoreError(err, "任务批次不存在")
+	}
+	ctx := c.Request().Context()
+	err = s.store.WithTx(ctx, func(tx *sqlite.Store) error {
+		if err := tx.DeleteTaskBatch(ctx, id); err != nil {
+			return err
+		}
+		if err := s.webhooks.Queue(ctx, tx, event.BatchDeleted, id); err != nil {
+			return err
+		}
+		return s.audit(c, tx, domain.AuditTaskBatchDelete, id, batch, nil)
+	})
+	if err != nil {
 		return mapStoreError(err, "任务批次不存在")
 	}
+	s.events.Publish(event.BatchDeleted, id)
 	return c.NoContent(http.StatusNoContent)
 }
 
@@ -80,22 +109,80 @@ func (s *Server) listTasks(c echo.Context) error {
 	if status != "" && status != domain.TaskStatusPending && status != domain.TaskStatusCompleted {
 		return badRequest("invalid_status", "任务状态无效")
 	}
-	batchID := int64(0)
-	if value := strings.TrimSpace(c.QueryParam("batch_id")); value != "" {
-		batchID, err = strconv.ParseInt(value, 10, 64)
-		if err != nil || batchID <= 0 {
-			return badRequest("invalid_batch_id", "任务批次 ID 无效")
-		}
+	batchID, err := optionalQueryInt64(c.QueryParam("batch_id"))
+	if err != nil {
+		return badRequest("invalid_batch_id", "任务批次 ID 无效")
+	}
+	accountID, err := optionalQueryInt64(c.QueryParam("account_id"))
+	if err != nil {
+		return badRequest("invalid_account_id", "账户 ID 无效")
+	}
+	now, err := ownerNow(c)
+	if err != nil {
+		return err
+	}
+	location := now.Location()
+	scheduledFrom, err := optionalQueryDate(c.QueryParam("scheduled_from"), location)
+	if err != nil {
+		return badRequest("invalid_date_range", "日期需为 YYYY-MM-DD 格式")
+	}
+	scheduledTo, err := optionalQueryDate(c.QueryParam("scheduled_to"), location)
+	if err != nil {
+		return badRequest("invalid_date_range", "日期需为 YYYY-MM-DD 格式")
+	}
+	scheduledBefore := time.Time{}
+	if !scheduledTo.IsZero() {
+		// The end date is inclusive in the owner's calendar.
+		scheduledBefore = scheduledTo.AddDate(0, 0, 1)
+	}
+	if !scheduledFrom.IsZero() && !scheduledBefore.IsZero() && !scheduledFrom.Before(scheduledBefore) {
+		return badRequest("invalid_date_range", "开始日期不能晚于结束日期")
+	}
+	amountMin, err := optionalQueryInt64(c.QueryParam("amount_min_cents"))
+	if err != nil {
+		return badRequest("invalid_amount_range", "金额范围无效")
+	}
+	amountMax, err := optionalQueryInt64(c.QueryParam("amount_max_cents"))
+	if err != nil || (amountMax > 0 && amountMax < amountMin) {
+		return badRequest("invalid_amount_range", "金额范围无效")
+	}
+	overdueBefore := time.Time{}
+	switch strings.TrimSpace(c.QueryParam("overdue")) {
+	case "", "false":
+	case "true":
+		overdueBefore = domain.OverdueCutoff(now)
+	default:
+		return badRequest("invalid_overdue", "overdue 只能为 true 或 false")
+	}
+	sort := sqlite.TaskSort(strings.TrimSpace(c.QueryParam("sort")))
+	if !sort.Valid() {
+		return badRequest("invalid_sort", "排序方式无效")
+	}
+	var groupName *string
+	if values, ok := c.QueryParams()["group_name"]; ok && len(values) > 0 {
+		value := strings.TrimSpace(values[0])
+		groupName = &value
 	}
 	result, err := s.store.ListTasks(c.Request().Context(), sqlite.TaskFilter{
-		Status:   status,
-		BatchID:  batchID,
-		Page:     page,
-		PageSize: pageSize,
+		Status:          status,
+		BatchID:         batchID,
+		AccountID:       accountID,
+		GroupName:       groupName,
+		ScheduledFrom:   scheduledFrom,
+		ScheduledBefore: scheduledBefore,
+		AmountMinCents:  amountMin,
+		AmountMaxCents:  amountMax,
+		OverdueBefore:   overdueBefore,
+		Sort:            sort,
+		Page:            page,
+		PageSize:        pageSize,
 	})
 	if err != nil {
 		return err
 	}
+	for index := range result.Items {
+		result.Items[index].MarkOverdue(now)
+	}
 	return c.JSON(http.StatusOK, result)
 }
 
@@ -104,21 +191,65 @@ func (s *Server) completeTask(c echo.Context) error {
 	if err != nil {
 		return err
 	}
-	task, err := s.store.CompleteTask(c.Request().Context(), id, time.Now())
+	now, err := ownerNow(c)
+	if err != nil {
+		return err
+	}
+	var (
+		task      domain.Task
+		completed bool
+	)
+	ctx := c.Request().Context()
+	err = s.store.WithTx(ctx, func(tx *sqlite.Store) error {
+		var err error
+		task, completed, err = tx.CompleteTask(ctx, id, now)
+		if err != nil || !completed {
+			return err
+		}
+		if err := s.webhooks.Queue(ctx, tx, event.TaskCompleted, task.ID); err != nil {
+			return err
+		}
+		return s.audit(c, tx, domain.AuditTaskComplete, task.ID, pendingTask(task), task)
+	})
 	if err != nil {
 		return mapStoreError(err, "任务不存在")
 	}
+	if completed {
+		s.events.Publish(event.TaskCompleted, task.ID)
+	}
+	task.MarkOverdue(now)
 	return c.JSON(http.StatusOK, task)
 }
 
 func (s *Server) dashboard(c echo.Context) error {
-	result, err := s.store.Dashboard(c.Request().Context())
+	now, err := ownerNow(c)
+	if err != nil {
+		return err
+	}
+	result, err := s.store.Dashboard(c.Request().Context(), domain.OverdueCutoff(now))
 	if err != nil {
 		return err
 	}
+	for index := range result.Upcoming {
+		result.Upcoming[index].MarkOverdue(now)
+	}
 	return c.JSON(http.StatusOK, result)
 }
 
+// ownerNow returns the current time in the authenticated owner's timezone,
+// which defines calendar days for filters and overdue tracking.
+func ownerNow(c echo.Context) (time.Time, error) {
+	owner, ok := c.Get("owner").(domain.Owner)
+	if !ok {
+		return time.Time{}, unauthorized()
+	}
+	location, err := time.LoadLocation(owner.Timezone)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Now().In(location), nil
+}
+
 func positiveQueryInt(value string, fallback, minimum, maximum int) (int, error) {
 	if strings.TrimSpace(value) == "" {
 		return fallback, nil
@@ -129,3 +260,30 @@ func positiveQueryInt(value string, fallback, minimum, maximum int) (int, error)
 	}
 	return parsed, nil
 }
+
+func optionalQueryInt64(value string) (int64, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, nil
+	}
+	parsed, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || parsed <= 0 {
+		return 0, errors.New("无效整数")
+	}
+	return parsed, nil
+}
+
+func optionalQueryDate(value string, location *time.Location) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.ParseInLocation("2006-01-02", value, location)
+}
+
+// pendingTask is task as it was before it was completed, for the audit log.
+func pendingTask(task domain.Task) domain.Task {
+	task.Status = domain.TaskStatusPending
+	task.CompletedAt = nil
+	return task
+}
@@ -0,0 +1,215 @@
+package httpapi
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/CoxxA/nomadbank/v2/internal/auth"
+	"github.com/CoxxA/nomadbank/v2/internal/domain"
+	"github.com/CoxxA/nomadbank/v2/internal/event"
+	"github.com/CoxxA/nomadbank/v2/internal/sqlite"
+)
+
+type taskLinkResponse struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// createTaskLink issues a completion link for a pending task, e.g. for a
+// script that forwards it to a phone.
+func (s *Server) createTaskLink(c echo.Context) error {
+	id, err := parseID(c.Param("id"))
+	if err != nil {
+		return err
+	}
+	ctx := c.Request().Context()
+	task, err := s.store.GetTask(ctx, id)
+	if err != nil {
+		return mapStoreError(err, "任务不存在")
+	}
+	if task.Status != domain.TaskStatusPending {
+		return conflict("task_completed", "任务已经完成")
+	}
+	token, expiresAt, err := s.authService.IssueTaskLink(ctx, id)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusCreated, taskLinkResponse{
+		URL:       s.publicURL(c) + "/t/" + token,
+		ExpiresAt: expiresAt,
+	})
+}
+
+// publicURL is PUBLIC_URL, or the address the request was made to.
+func (s *Server) publicURL(c echo.Context) string {
+	if s.config.PublicURL != "" {
+		return s.config.PublicURL
+	}
+	scheme := "http"
+	if s.requestIsSecure(c) {
+		scheme = "https"
+	}
+	return scheme + "://" + s.requestHost(c) + s.config.BasePath
+}
+
+// taskLinkPage asks for confirmation instead of completing the task, since
+// chat apps and mail scanners open links to preview them.
+func (s *Server) taskLinkPage(c echo.Context) error {
+	ctx := c.Request().Context()
+	link, err := s.authService.VerifyTaskLink(ctx, c.Param("token"))
+	if errors.Is(err, auth.ErrInvalidTaskLink) {
+		return renderTaskLinkPage(c, http.StatusNotFound, taskLinkView{Title: "链接不可用", Message: err.Error()})
+	}
+	if err != nil {
+		return err
+	}
+	task, err := s.store.GetTask(ctx, link.TaskID)
+	if errors.Is(err, sqlite.ErrNotFound) {
+		return renderTaskLinkPage(c, http.StatusNotFound, taskLinkView{Title: "链接不可用", Message: "任务已被删除。"})
+	}
+	if err != nil {
+		return err
+	}
+	view, err := s.newTaskLinkView(c, task)
+	if err != nil {
+		return err
+	}
+	if task.Status == domain.TaskStatusPending {
+		view.Title = "确认完成任务"
+		view.Confirm = true
+	} else {
+		view.Title = "任务已完成"
+	}
+	return renderTaskLinkPage(c, http.StatusOK, view)
+}
+
+func (s *Server) completeTaskByLink(c echo.Context) error {
+	ctx := c.Request().Context()
+	credentials, err := s.store.OwnerCredentials(ctx)
+	if errors.Is(err, sqlite.ErrNotFound) {
+		return renderTaskLinkPage(c, http.StatusNotFound, taskLinkView{Title: "链接不可用", Message: auth.ErrInvalidTaskLink.Error()})
+	}
+	if err != nil {
+		return err
+	}
+	// The link stands in for a session: the audit event names the owner and
+	// marks the link as the actor.
+	c.Set("owner", credentials.Owner)
+	c.Set("task_link", true)
+	now, err := ownerNow(c)
+	if err != nil {
+		return err
+	}
+	var (
+		task      domain.Task
+		completed bool
+	)
+	err = s.store.WithTx(ctx, func(tx *sqlite.Store) error {
+		var err error
+		task, completed, err = s.authService.InTx(tx).CompleteTaskLink(ctx, c.Param("token"), now)
+		if err != nil || !completed {
+			return err
+		}
+		if err := s.webhooks.Queue(ctx, tx, event.TaskCompleted, task.ID); err != nil {
+			return err
+		}
+		return s.audit(c, tx, domain.AuditTaskComplete, task.ID, pendingTask(task), task)
+	})
+	if errors.Is(err, auth.ErrInvalidTaskLink) {
+		return renderTaskLinkPage(c, http.StatusNotFound, taskLinkView{Title: "链接不可用", Message: err.Error()})
+	}
+	if errors.Is(err, sqlite.ErrNotFound) {
+		return renderTaskLinkPage(c, http.StatusNotFound, taskLinkView{Title: "链接不可用", Message: "任务已被删除。"})
+	}
+	if err != nil {
+		return err
+	}
+	if completed {
+		s.events.Publish(event.TaskCompleted, task.ID)
+	}
+	view, err := s.newTaskLinkView(c, task)
+	if err != nil {
+		return err
+	}
+	view.Title = "任务已完成"
+	if completed {
+		view.Message = "已记录完成，可以关闭此页面。"
+	}
+	return renderTaskLinkPage(c, http.StatusOK, view)
+}
+
+type taskLinkView struct {
+	Title       string
+	Message     string
+	Confirm     bool
+	ScheduledAt string
+	Transfer    string
+	CompletedAt string
+}
+
+func (s *Server) newTaskLinkView(c echo.Context, task domain.Task) (taskLinkView, error) {
+	credentials, err := s.store.OwnerCredentials(c.Request().Context())
+	if err != nil {
+		return taskLinkView{}, err
+	}
+	location, err := time.LoadLocation(credentials.Owner.Timezone)
+	if err != nil {
+		return taskLinkView{}, err
+	}
+	view := taskLinkView{
+		ScheduledAt: task.ScheduledAt.In(location).Format("2006-01-02 15:04"),
+		Transfer: fmt.Sprintf("从「%s」向「%s」转账 %d.%02d 元",
+			task.FromAccountName, task.ToAccountName, task.AmountCents/100, task.AmountCents%100),
+	}
+	if task.CompletedAt != nil {
+		view.CompletedAt = task.CompletedAt.In(location).Format("2006-01-02 15:04")
+	}
+	return view, nil
+}
+
+// renderTaskLinkPage serves a self-contained page. The token is in the URL,
+// so the page is never cached and only sends a referrer to this site.
+func renderTaskLinkPage(c echo.Context, status int, view taskLinkView) error {
+	header := c.Response().Header()
+	header.Set(echo.HeaderCacheControl, "no-store")
+	header.Set("Referrer-Policy", "same-origin")
+	header.Set("X-Robots-Tag", "noindex")
+	header.Set(echo.HeaderContentSecurityPolicy,
+		"default-src 'none'; style-src 'unsafe-inline'; form-action 'self'; frame-ancestors 'none'")
+	header.Set(echo.HeaderContentType, echo.MIMETextHTMLCharsetUTF8)
+	c.Response().WriteHeader(status)
+	return taskLinkTemplate.Execute(c.Response(), view)
+}
+
+var taskLinkTemplate = template.Must(template.New("task-link").Parse(`<!doctype html>
+<html lang="zh-CN">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}} · NomadBank</title>
+<style>
+body { margin: 0; font-family: system-ui, sans-serif; background: #f4f3ee; color: #25312c; }
+main { max-width: 26rem; margin: 12vh auto 0; padding: 1.75rem 1.5rem; background: #fff; border: 1px solid #e2e6e2; border-radius: 1rem; }
+h1 { margin: 0 0 1rem; font-size: 1.25rem; }
+p { margin: 0.5rem 0; line-height: 1.6; }
+.muted { color: #748079; font-size: 0.875rem; }
+button { width: 100%; margin-top: 1.25rem; padding: 0.85rem; border: 0; border-radius: 0.75rem; background: #2f6b57; color: #fff; font-size: 1rem; font-weight: 600; }
+</style>
+</head>
+<body>
+<main>
+<h1>{{.Title}}</h1>
+{{if .Transfer}}<p>{{.Transfer}}</p>
+<p class="muted">计划时间 {{.ScheduledAt}}{{if .CompletedAt}} · 完成于 {{.CompletedAt}}{{end}}</p>{{end}}
+{{if .Message}}<p class="muted">{{.Message}}</p>{{end}}
+{{if .Confirm}}<form method="post"><button type="submit">标记为已完成</button></form>
+<p class="muted">链接只能使用一次。</p>{{end}}
+</main>
+</body>
+</html>
+`))
@@ -0,0 +1,66 @@
+package httpapi
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/CoxxA/nomadbank/v2/internal/tlscert"
+)
+
+// hstsHeader asks browsers to use HTTPS for a year. Subdomains are left out
+// because a self-hosted domain often serves other things.
+const hstsHeader = "max-age=31536000"
+
+// EnableTLS serves HTTPS with the configured key pair, reloading it when the
+// files are replaced, and sends HSTS on every response.
+func (s *Server) EnableTLS() error {
+	reloader, err := tlscert.NewReloader(s.config.TLSCertFile, s.config.TLSKeyFile)
+	if err != nil {
+		return err
+	}
+	s.echo.Server.TLSConfig = &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: reloader.GetCertificate,
+		NextProtos:     []string{"h2", "http/1.1"},
+	}
+	s.echo.Use(hsts)
+	return nil
+}
+
+func hsts(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if c.Request().TLS != nil {
+			c.Response().Header().Set(echo.HeaderStrictTransportSecurity, hstsHeader)
+		}
+		return next(c)
+	}
+}
+
+// RedirectHandler sends plain HTTP requests to the same host and path on
+// the HTTPS port. GET and HEAD get 301; other methods get 308 so clients
+// repeat them unchanged.
+func (s *Server) RedirectHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = r.Host
+		}
+		if host == "" {
+			http.Error(w, "missing Host header", http.StatusBadRequest)
+			return
+		}
+		if _, port, _ := net.SplitHostPort(s.config.Address()); port != "443" {
+			host = net.JoinHostPort(host, port)
+		} else if ip := net.ParseIP(host); ip != nil && ip.To4() == nil {
+			host = "[" + host + "]"
+		}
+		status := http.StatusPermanentRedirect
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			status = http.StatusMovedPermanently
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), status)
+	})
+}
@@ -0,0 +1,135 @@
+package httpapi
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/CoxxA/nomadbank/v2/internal/auth"
+	"github.com/CoxxA/nomadbank/v2/internal/domain"
+	"github.com/CoxxA/nomadbank/v2/internal/sqlite"
+)
+
+type passwordRequest struct {
+	Password string `json:"password"`
+}
+
+type totpCodeRequest struct {
+	Code string `json:"code"`
+}
+
+type totpStatusResponse struct {
+	Enabled                bool `json:"enabled"`
+	RecoveryCodesRemaining int  `json:"recovery_codes_remaining"`
+}
+
+type totpEnrollmentResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+type recoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+func (s *Server) totpStatus(c echo.Context) error {
+	status, err := s.authService.TOTPStatus(c.Request().Context())
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, totpStatusResponse{
+		Enabled:                status.Enabled,
+		RecoveryCodesRemaining: status.RecoveryCodesRemaining,
+	})
+}
+
+func (s *Server) beginTOTPEnrollment(c echo.Context) error {
+	var request passwordRequest
+	if err := c.Bind(&request); err != nil {
+		return badRequest("invalid_json", "请求格式错误")
+	}
+	enrollment, err := s.authService.BeginTOTPEnrollment(c.Request().Context(), request.Password)
+	if err != nil {
+		return totpError(err)
+	}
+	return c.JSON(http.StatusOK, totpEnrollmentResponse{
+		Secret:          enrollment.Secret,
+		ProvisioningURI: enrollment.ProvisioningURI,
+	})
+}
+
+func (s *Server) confirmTOTP(c echo.Context) error {
+	var request totpCodeRequest
+	if err := c.Bind(&request); err != nil {
+		return badRequest("invalid_json", "请求格式错误")
+	}
+	var codes []string
+	ctx := c.Request().Context()
+	err := s.store.WithTx(ctx, func(tx *sqlite.Store) error {
+		var err error
+		if codes, err = s.authService.InTx(tx).ConfirmTOTP(ctx, request.Code); err != nil {
+			return err
+		}
+		return s.audit(c, tx, domain.AuditOwnerTOTPEnable, 0, nil, nil)
+	})
+	if err != nil {
+		return totpError(err)
+	}
+	return c.JSON(http.StatusOK, recoveryCodesResponse{RecoveryCodes: codes})
+}
+
+func (s *Server) disableTOTP(c echo.Context) error {
+	var request passwordRequest
+	if err := c.Bind(&request); err != nil {
+		return badRequest("invalid_json", "请求格式错误")
+	}
+	ctx := c.Request().Context()
+	err := s.store.WithTx(ctx, func(tx *sqlite.Store) error {
+		if err := s.authService.InTx(tx).DisableTOTP(ctx, request.Password); err != nil {
+			return err
+		}
+		return s.audit(c, tx, domain.AuditOwnerTOTPDisable, 0, nil, nil)
+	})
+	if err != nil {
+		return totpError(err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (s *Server) regenerateRecoveryCodes(c echo.Context) error {
+	var request passwordRequest
+	if err := c.Bind(&request); err != nil {
+		return badRequest("invalid_json", "请求格式错误")
+	}
+	var codes []string
+	ctx := c.Request().Context()
+	err := s.store.WithTx(ctx, func(tx *sqlite.Store) error {
+		var err error
+		if codes, err = s.authService.InTx(tx).RegenerateRecoveryCodes(ctx, request.Password); err != nil {
+			return err
+		}
+		return s.audit(c, tx, domain.AuditOwnerRecoveryCodes, 0, nil, nil)
+	})
+	if err != nil {
+		return totpError(err)
+	}
+	return c.JSON(http.StatusOK, recoveryCodesResponse{RecoveryCodes: codes})
+}
+
+func totpError(err error) error {
+	switch {
+	case errors.Is(err, auth.ErrInvalidCredentials):
+		return badRequest("wrong_password", "当前密码错误")
+	case errors.Is(err, auth.ErrInvalidSecondFactor):
+		return badRequest("invalid_totp", "验证码错误，请确认设备时间准确后重试")
+	case errors.Is(err, auth.ErrTOTPEnabled):
+		return conflict("totp_enabled", "两步验证已开启")
+	case errors.Is(err, auth.ErrTOTPNotEnabled):
+		return conflict("totp_not_enabled", "两步验证未开启")
+	case errors.Is(err, auth.ErrNoPendingTOTP):
+		return conflict("totp_not_pending", "请先开始设置两步验证")
+	default:
+		return err
+	}
+}
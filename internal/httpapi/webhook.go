@@ -0,0 +1,217 @@
+package httpapi
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/CoxxA/nomadbank/v2/internal/domain"
+	"github.com/CoxxA/nomadbank/v2/internal/notify"
+	"github.com/CoxxA/nomadbank/v2/internal/sqlite"
+)
+
+// webhookResponse masks the URL and leaves out the signing secret, which is
+// only returned by createWebhook.
+type webhookResponse struct {
+	ID        int64                 `json:"id"`
+	Name      string                `json:"name"`
+	URL       string                `json:"url"`
+	Events    []domain.WebhookEvent `json:"events"`
+	Active    bool                  `json:"active"`
+	CreatedAt time.Time             `json:"created_at"`
+	UpdatedAt time.Time             `json:"updated_at"`
+}
+
+type createdWebhookResponse struct {
+	webhookResponse
+	Secret string `json:"secret"`
+}
+
+// webhookRequest keeps the stored URL when url is omitted on update, since
+// clients only ever see it masked.
+type webhookRequest struct {
+	Name   *string               `json:"name"`
+	URL    *string               `json:"url"`
+	Events []domain.WebhookEvent `json:"events"`
+	Active *bool                 `json:"active"`
+}
+
+func newWebhookResponse(webhook domain.Webhook) webhookResponse {
+	return webhookResponse{
+		ID:        webhook.ID,
+		Name:      webhook.Name,
+		URL:       notify.MaskURL(webhook.URL),
+		Events:    webhook.Events,
+		Active:    webhook.Active,
+		CreatedAt: webhook.CreatedAt,
+		UpdatedAt: webhook.UpdatedAt,
+	}
+}
+
+func (s *Server) listWebhooks(c echo.Context) error {
+	webhooks, err := s.store.ListWebhooks(c.Request().Context())
+	if err != nil {
+		return err
+	}
+	response := make([]webhookResponse, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		response = append(response, newWebhookResponse(webhook))
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+func (s *Server) createWebhook(c echo.Context) error {
+	var request webhookRequest
+	if err := c.Bind(&request); err != nil {
+		return badRequest("invalid_json", "请求格式错误")
+	}
+	if request.URL == nil {
+		return badRequest("missing_fields", "name、url、events 和 active 均为必填项")
+	}
+	webhook := domain.Webhook{Secret: notify.NewWebhookSecret()}
+	if err := applyWebhookRequest(&webhook, request); err != nil {
+		return err
+	}
+	var response webhookResponse
+	ctx := c.Request().Context()
+	err := s.store.WithTx(ctx, func(tx *sqlite.Store) error {
+		if err := tx.CreateWebhook(ctx, &webhook); err != nil {
+			return err
+		}
+		response = newWebhookResponse(webhook)
+		return s.audit(c, tx, domain.AuditWebhookCreate, webhook.ID, nil, response)
+	})
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusCreated, createdWebhookResponse{webhookResponse: response, Secret: webhook.Secret})
+}
+
+func (s *Server) updateWebhook(c echo.Context) error {
+	id, err := parseID(c.Param("id"))
+	if err != nil {
+		return err
+	}
+	ctx := c.Request().Context()
+	webhook, err := s.store.GetWebhook(ctx, id)
+	if err != nil {
+		return mapStoreError(err, "Webhook 不存在")
+	}
+	before := newWebhookResponse(webhook)
+	var request webhookRequest
+	if err := c.Bind(&request); err != nil {
+		return badRequest("invalid_json", "请求格式错误")
+	}
+	if err := applyWebhookRequest(&webhook, request); err != nil {
+		return err
+	}
+	var after webhookResponse
+	err = s.store.WithTx(ctx, func(tx *sqlite.Store) error {
+		if err := tx.UpdateWebhook(ctx, &webhook); err != nil {
+			return err
+		}
+		after = newWebhookResponse(webhook)
+		return s.audit(c, tx, domain.AuditWebhookUpdate, id, before, after)
+	})
+	if err != nil {
+		return mapStoreError(err, "Webhook 不存在")
+	}
+	return c.JSON(http.StatusOK, after)
+}
+
+func (s *Server) deleteWebhook(c echo.Context) error {
+	id, err := parseID(c.Param("id"))
+	if err != nil {
+		return err
+	}
+	ctx := c.Request().Context()
+	webhook, err := s.store.GetWebhook(ctx, id)
+	if err != nil {
+		return mapStoreError(err, "Webhook 不存在")
+	}
+	err = s.store.WithTx(ctx, func(tx *sqlite.Store) error {
+		if err := tx.DeleteWebhook(ctx, id); err != nil {
+			return err
+		}
+		return s.audit(c, tx, domain.AuditWebhookDelete, id, newWebhookResponse(webhook), nil)
+	})
+	if err != nil {
+		return mapStoreError(err, "Webhook 不存在")
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func applyWebhookRequest(webhook *domain.Webhook, request webhookRequest) error {
+	if request.Name == nil || request.Events == nil || request.Active == nil {
+		return badRequest("missing_fields", "name、events 和 active 均为必填项")
+	}
+	name := strings.TrimSpace(*request.Name)
+	if name == "" || utf8.RuneCountInString(name) > 64 {
+		return badRequest("invalid_name", "名称不能为空且不能超过 64 个字符")
+	}
+	if request.URL != nil {
+		raw := strings.TrimSpace(*request.URL)
+		if !validWebhookURL(raw) {
+			return badRequest("invalid_webhook_url", "Webhook 地址必须是 http 或 https 的完整 URL")
+		}
+		webhook.URL = raw
+	}
+	events := make([]domain.WebhookEvent, 0, len(request.Events))
+	for _, event := range request.Events {
+		if !event.Subscribable() {
+			return badRequest("invalid_events", "不支持的事件："+string(event))
+		}
+		if !slices.Contains(events, event) {
+			events = append(events, event)
+		}
+	}
+	if len(events) == 0 {
+		return badRequest("invalid_events", "至少需要订阅一个事件")
+	}
+	webhook.Name = name
+	webhook.Events = events
+	webhook.Active = *request.Active
+	return nil
+}
+
+// listWebhookDeliveries shows a webhook's 50 most recent deliveries with
+// their retry log.
+func (s *Server) listWebhookDeliveries(c echo.Context) error {
+	id, err := parseID(c.Param("id"))
+	if err != nil {
+		return err
+	}
+	ctx := c.Request().Context()
+	if _, err := s.store.GetWebhook(ctx, id); err != nil {
+		return mapStoreError(err, "Webhook 不存在")
+	}
+	deliveries, err := s.store.ListWebhookDeliveries(ctx, id, 50)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, deliveries)
+}
+
+// testWebhook sends a webhook.test event right away, even to a paused
+// webhook, and returns the delivery with the attempt's outcome. A failed
+// test is reported in the delivery, not as an error.
+func (s *Server) testWebhook(c echo.Context) error {
+	id, err := parseID(c.Param("id"))
+	if err != nil {
+		return err
+	}
+	ctx := c.Request().Context()
+	webhook, err := s.store.GetWebhook(ctx, id)
+	if err != nil {
+		return mapStoreError(err, "Webhook 不存在")
+	}
+	delivery, err := s.webhooks.SendTest(ctx, webhook)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, delivery)
+}
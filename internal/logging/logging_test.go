@@ -0,0 +1,54 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestNewAddsRequestIDAndFiltersLevel(t *testing.T) {
+	var out bytes.Buffer
+	logger, err := New(&out, "warn", "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := WithRequestID(context.Background(), "req-1")
+	logger.InfoContext(ctx, "hidden")
+	logger.With("component", "test").WarnContext(ctx, "shown", "answer", 42)
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("expected one record, got %q", out.String())
+	}
+	var record map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &record); err != nil {
+		t.Fatal(err)
+	}
+	if record["msg"] != "shown" || record["request_id"] != "req-1" || record["component"] != "test" || record["answer"] != float64(42) {
+		t.Fatalf("unexpected record: %v", record)
+	}
+}
+
+func TestNewRejectsUnknownSettings(t *testing.T) {
+	if _, err := New(&bytes.Buffer{}, "verbose", "text"); err == nil {
+		t.Fatal("expected unknown level to be rejected")
+	}
+	if _, err := New(&bytes.Buffer{}, "info", "xml"); err == nil {
+		t.Fatal("expected unknown format to be rejected")
+	}
+}
+
+func TestNewDefaultsEmptySettings(t *testing.T) {
+	var out bytes.Buffer
+	logger, err := New(&out, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Debug("hidden")
+	logger.Info("shown")
+	if got := out.String(); strings.Contains(got, "hidden") || !strings.Contains(got, "level=INFO msg=shown") {
+		t.Fatalf("expected text records at info and above, got %q", got)
+	}
+}
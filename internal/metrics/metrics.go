@@ -0,0 +1,233 @@
+// Package metrics implements the small part of the Prometheus text exposition
+// format that NomadBank exports: labelled counters, gauges and histograms.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType is the Content-Type of the text exposition format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultBuckets suit request and query latencies in seconds.
+var DefaultBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+type kind string
+
+const (
+	kindCounter   kind = "counter"
+	kindGauge     kind = "gauge"
+	kindHistogram kind = "histogram"
+)
+
+// Registry holds metric families in registration order.
+type Registry struct {
+	mu       sync.Mutex
+	families []*family
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+type family struct {
+	name    string
+	help    string
+	kind    kind
+	labels  []string
+	buckets []float64
+
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+type series struct {
+	labels []string
+	value  float64
+	// counts[i] is the number of observations in buckets[i], not cumulative.
+	counts []uint64
+	count  uint64
+}
+
+func (r *Registry) register(name, help string, kind kind, buckets []float64, labels []string) *family {
+	f := &family{
+		name:    name,
+		help:    help,
+		kind:    kind,
+		labels:  labels,
+		buckets: buckets,
+		series:  make(map[string]*series),
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.families {
+		if existing.name == name {
+			panic("metrics: duplicate metric " + name)
+		}
+	}
+	r.families = append(r.families, f)
+	return f
+}
+
+// with returns the series for the label values; the caller holds f.mu.
+func (f *family) with(values []string) *series {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", f.name, len(f.labels), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{labels: append([]string(nil), values...)}
+		if f.kind == kindHistogram {
+			s.counts = make([]uint64, len(f.buckets))
+		}
+		f.series[key] = s
+	}
+	return s
+}
+
+// Counter only goes up.
+type Counter struct{ f *family }
+
+func (r *Registry) Counter(name, help string, labels ...string) *Counter {
+	return &Counter{r.register(name, help, kindCounter, nil, labels)}
+}
+
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+func (c *Counter) Add(delta float64, labelValues ...string) {
+	if delta < 0 {
+		panic("metrics: counter " + c.f.name + " cannot decrease")
+	}
+	c.f.mu.Lock()
+	defer c.f.mu.Unlock()
+	c.f.with(labelValues).value += delta
+}
+
+// Gauge holds a value that is set as a whole, usually right before a scrape.
+type Gauge struct{ f *family }
+
+func (r *Registry) Gauge(name, help string, labels ...string) *Gauge {
+	return &Gauge{r.register(name, help, kindGauge, nil, labels)}
+}
+
+func (g *Gauge) Set(value float64, labelValues ...string) {
+	g.f.mu.Lock()
+	defer g.f.mu.Unlock()
+	g.f.with(labelValues).value = value
+}
+
+// Histogram counts observations into cumulative upper-bound buckets.
+type Histogram struct{ f *family }
+
+// Histogram registers a histogram; buckets must be sorted ascending and
+// exclude +Inf, which is always added.
+func (r *Registry) Histogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	if !sort.Float64sAreSorted(buckets) {
+		panic("metrics: buckets of " + name + " are not sorted")
+	}
+	return &Histogram{r.register(name, help, kindHistogram, append([]float64(nil), buckets...), labels)}
+}
+
+func (h *Histogram) Observe(value float64, labelValues ...string) {
+	h.f.mu.Lock()
+	defer h.f.mu.Unlock()
+	s := h.f.with(labelValues)
+	s.value += value
+	s.count++
+	if index := sort.SearchFloat64s(h.f.buckets, value); index < len(s.counts) {
+		s.counts[index]++
+	}
+}
+
+// Write renders every family in the text exposition format. Series are
+// sorted by label values so the output is stable.
+func (r *Registry) Write(w io.Writer) error {
+	r.mu.Lock()
+	families := append([]*family(nil), r.families...)
+	r.mu.Unlock()
+
+	out := bufio.NewWriter(w)
+	for _, f := range families {
+		f.write(out)
+	}
+	return out.Flush()
+}
+
+func (f *family) write(out *bufio.Writer) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	keys := make([]string, 0, len(f.series))
+	for key := range f.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	fmt.Fprintf(out, "# HELP %s %s\n", f.name, escapeHelp(f.help))
+	fmt.Fprintf(out, "# TYPE %s %s\n", f.name, f.kind)
+	for _, key := range keys {
+		s := f.series[key]
+		if f.kind != kindHistogram {
+			fmt.Fprintf(out, "%s%s %s\n", f.name, f.labelSet(s.labels, "", 0), formatFloat(s.value))
+			continue
+		}
+		var cumulative uint64
+		for index, bound := range f.buckets {
+			cumulative += s.counts[index]
+			fmt.Fprintf(out, "%s_bucket%s %d\n", f.name, f.labelSet(s.labels, "le", bound), cumulative)
+		}
+		fmt.Fprintf(out, "%s_bucket%s %d\n", f.name, f.labelSet(s.labels, "le", math.Inf(1)), s.count)
+		fmt.Fprintf(out, "%s_sum%s %s\n", f.name, f.labelSet(s.labels, "", 0), formatFloat(s.value))
+		fmt.Fprintf(out, "%s_count%s %d\n", f.name, f.labelSet(s.labels, "", 0), s.count)
+	}
+}
+
+// labelSet formats {name="value",...}, appending the bucket label when
+// extra is not empty.
+func (f *family) labelSet(values []string, extra string, bound float64) string {
+	if len(values) == 0 && extra == "" {
+		return ""
+	}
+	pairs := make([]string, 0, len(values)+1)
+	for index, value := range values {
+		pairs = append(pairs, f.labels[index]+`="`+escapeLabel(value)+`"`)
+	}
+	if extra != "" {
+		pairs = append(pairs, extra+`="`+formatFloat(bound)+`"`)
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(value string) string {
+	return helpEscaper.Replace(value)
+}
+
+func escapeLabel(value string) string {
+	return labelEscaper.Replace(value)
+}
@@ -0,0 +1,63 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRegistryWritesTextFormat(t *testing.T) {
+	registry := NewRegistry()
+	requests := registry.Counter("app_requests_total", "Handled requests.", "route", "status")
+	latency := registry.Histogram("app_latency_seconds", "Request latency.", []float64{0.1, 1}, "route")
+	info := registry.Gauge("app_info", "Build \\ info.", "version")
+
+	requests.Inc("/b", "200")
+	requests.Add(2, "/a", "404")
+	latency.Observe(0.05, "/a")
+	latency.Observe(0.1, "/a")
+	latency.Observe(3, "/a")
+	info.Set(1, "v\"2\"\n")
+
+	var out strings.Builder
+	if err := registry.Write(&out); err != nil {
+		t.Fatal(err)
+	}
+	want := `# HELP app_requests_total Handled requests.
+# TYPE app_requests_total counter
+app_requests_total{route="/a",status="404"} 2
+app_requests_total{route="/b",status="200"} 1
+# HELP app_latency_seconds Request latency.
+# TYPE app_latency_seconds histogram
+app_latency_seconds_bucket{route="/a",le="0.1"} 2
+app_latency_seconds_bucket{route="/a",le="1"} 2
+app_latency_seconds_bucket{route="/a",le="+Inf"} 3
+app_latency_seconds_sum{route="/a"} 3.15
+app_latency_seconds_count{route="/a"} 3
+# HELP app_info Build \\ info.
+# TYPE app_info gauge
+app_info{version="v\"2\"\n"} 1
+`
+	if out.String() != want {
+		t.Fatalf("unexpected output:\n%s", out.String())
+	}
+}
+
+func TestRegistryRejectsMisuse(t *testing.T) {
+	registry := NewRegistry()
+	counter := registry.Counter("dup_total", "Duplicate.", "label")
+	for name, misuse := range map[string]func(){
+		"duplicate name":      func() { registry.Gauge("dup_total", "Duplicate.") },
+		"missing label value": func() { counter.Inc() },
+		"decreasing counter":  func() { counter.Add(-1, "x") },
+		"unsorted buckets":    func() { registry.Histogram("h", "Unsorted.", []float64{1, 0.5}) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s did not panic", name)
+				}
+			}()
+			misuse()
+		}()
+	}
+}
@@ -0,0 +1,155 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/CoxxA/nomadbank/v2/internal/domain"
+	"github.com/CoxxA/nomadbank/v2/internal/sqlite"
+)
+
+const (
+	// digestRetryInterval spaces attempts after a failed digest; retries stop
+	// once the day is over.
+	digestRetryInterval = 15 * time.Minute
+	// dormancyWarningDays is how long before reaching the dormancy threshold
+	// an account starts to appear in the digest.
+	dormancyWarningDays = 30
+	// digestListLimit bounds each task list in one email.
+	digestListLimit = 50
+)
+
+// mailDigest sends the day's digest once the configured local time has
+// passed, unless it was already sent today.
+func (s *Scheduler) mailDigest(ctx context.Context, now time.Time) error {
+	settings, err := s.store.EmailDigestSettings(ctx)
+	if err != nil {
+		return err
+	}
+	if !settings.Enabled || settings.SMTPHost == "" || settings.Recipient == "" {
+		return nil
+	}
+	today := domain.OverdueCutoff(now)
+	day := today.Format(time.DateOnly)
+	// time.Date normalizes the minutes, which keeps the send time right on
+	// days when the clocks change.
+	sendAt := time.Date(now.Year(), now.Month(), now.Day(), 0, settings.SendMinutes, 0, 0, now.Location())
+	if settings.LastSentOn == day || now.Before(sendAt) {
+		return nil
+	}
+	if settings.LastAttemptAt != nil && now.Sub(*settings.LastAttemptAt) < digestRetryInterval {
+		return nil
+	}
+
+	digest, err := BuildDigest(ctx, s.store, now, settings.DormancyDays)
+	if err != nil {
+		return err
+	}
+	if err := s.sendMail(ctx, settings, digest.Subject(), digest.Body(), now); err != nil {
+		slog.WarnContext(ctx, "发送每日摘要失败", "error", err)
+		return s.store.RecordDigestFailed(ctx, s.now(), err.Error())
+	}
+	slog.InfoContext(ctx, "已发送每日摘要", "day", day)
+	return s.store.RecordDigestSent(ctx, day, s.now())
+}
+
+// Digest is one day's summary for the owner. Today holds every task
+// scheduled that day; Overdue holds pending tasks from earlier days. The
+// totals count tasks left out by digestListLimit.
+type Digest struct {
+	Now          time.Time
+	Today        []domain.Task
+	TodayTotal   int64
+	Overdue      []domain.Task
+	OverdueTotal int64
+	Idle         []domain.IdleAccount
+	DormancyDays int
+}
+
+// BuildDigest gathers the digest for now's day in now's location, which
+// should be the owner's timezone.
+func BuildDigest(ctx context.Context, store *sqlite.Store, now time.Time, dormancyDays int) (Digest, error) {
+	today := domain.OverdueCutoff(now)
+	digest := Digest{Now: now, DormancyDays: dormancyDays}
+	todayPage, err := store.ListTasks(ctx, sqlite.TaskFilter{
+		ScheduledFrom: today, ScheduledBefore: today.AddDate(0, 0, 1), Page: 1, PageSize: digestListLimit,
+	})
+	if err != nil {
+		return Digest{}, err
+	}
+	overduePage, err := store.ListTasks(ctx, sqlite.TaskFilter{
+		OverdueBefore: today, Page: 1, PageSize: digestListLimit,
+	})
+	if err != nil {
+		return Digest{}, err
+	}
+	for index := range overduePage.Items {
+		overduePage.Items[index].MarkOverdue(now)
+	}
+	digest.Today, digest.TodayTotal = todayPage.Items, todayPage.Total
+	digest.Overdue, digest.OverdueTotal = overduePage.Items, overduePage.Total
+	digest.Idle, err = store.IdleAccounts(ctx, now.AddDate(0, 0, -(dormancyDays-dormancyWarningDays)))
+	if err != nil {
+		return Digest{}, err
+	}
+	return digest, nil
+}
+
+func (d Digest) Subject() string {
+	return fmt.Sprintf("NomadBank 每日摘要 %s：今日 %d 项，逾期 %d 项",
+		d.Now.Format("01-02"), d.TodayTotal, d.OverdueTotal)
+}
+
+// Body renders the digest as plain text in the owner's timezone.
+func (d Digest) Body() string {
+	var body strings.Builder
+	fmt.Fprintf(&body, "NomadBank 每日摘要 · %s（%s）\n", d.Now.Format(time.DateOnly), d.Now.Location())
+
+	fmt.Fprintf(&body, "\n今日任务（%d）\n", d.TodayTotal)
+	for _, task := range d.Today {
+		status := "待完成"
+		if task.Status == domain.TaskStatusCompleted {
+			status = "已完成"
+		}
+		fmt.Fprintf(&body, "  %s  %s  %s\n", task.ScheduledAt.In(d.Now.Location()).Format("15:04"), transferText(task), status)
+	}
+	writeRemainder(&body, len(d.Today), d.TodayTotal)
+
+	fmt.Fprintf(&body, "\n逾期任务（%d）\n", d.OverdueTotal)
+	for _, task := range d.Overdue {
+		fmt.Fprintf(&body, "  %s  %s  已逾期 %d 天\n",
+			task.ScheduledAt.In(d.Now.Location()).Format("01-02 15:04"), transferText(task), task.DaysOverdue)
+	}
+	writeRemainder(&body, len(d.Overdue), d.OverdueTotal)
+
+	fmt.Fprintf(&body, "\n即将休眠的账户（%d）\n", len(d.Idle))
+	for _, account := range d.Idle {
+		idleDays := int(d.Now.Sub(account.LastActiveAt).Hours() / 24)
+		state := fmt.Sprintf("约 %d 天后满 %d 天无交易", d.DormancyDays-idleDays, d.DormancyDays)
+		if idleDays >= d.DormancyDays {
+			state = fmt.Sprintf("已 %d 天无交易", idleDays)
+		}
+		fmt.Fprintf(&body, "  %s  最近活动 %s，%s\n",
+			account.Name, account.LastActiveAt.In(d.Now.Location()).Format(time.DateOnly), state)
+	}
+	if len(d.Idle) == 0 {
+		body.WriteString("  无\n")
+	}
+	return body.String()
+}
+
+func transferText(task domain.Task) string {
+	return fmt.Sprintf("%s → %s  %s 元", task.FromAccountName, task.ToAccountName, formatCents(task.AmountCents))
+}
+
+func writeRemainder(body *strings.Builder, listed int, total int64) {
+	switch {
+	case total == 0:
+		body.WriteString("  无\n")
+	case int64(listed) < total:
+		fmt.Fprintf(body, "  另有 %d 项未列出\n", total-int64(listed))
+	}
+}
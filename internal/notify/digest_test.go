@@ -0,0 +1,344 @@
+package notify
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"io"
+	"math/big"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/CoxxA/nomadbank/v2/internal/domain"
+	"github.com/CoxxA/nomadbank/v2/internal/sqlite"
+)
+
+// fakeSMTP is a minimal SMTP server that offers STARTTLS (unless noTLS is
+// set), accepts AUTH PLAIN only after it and records accepted messages.
+type fakeSMTP struct {
+	listener  net.Listener
+	tlsConfig *tls.Config
+	noTLS     bool
+	username  string
+	password  string
+
+	mu          sync.Mutex
+	connections int
+	messages    []fakeMessage
+}
+
+type fakeMessage struct {
+	from   string
+	to     []string
+	data   []byte
+	secure bool
+}
+
+func startFakeSMTP(t *testing.T, noTLS bool) (*fakeSMTP, *x509.CertPool) {
+	t.Helper()
+	certificate, pool := selfSignedCertificate(t)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &fakeSMTP{
+		listener:  listener,
+		tlsConfig: &tls.Config{Certificates: []tls.Certificate{certificate}},
+		noTLS:     noTLS,
+		username:  "mailer",
+		password:  "smtp-password",
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(conn)
+		}
+	}()
+	return server, pool
+}
+
+func (f *fakeSMTP) port() int {
+	return f.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (f *fakeSMTP) received() ([]fakeMessage, int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]fakeMessage(nil), f.messages...), f.connections
+}
+
+func (f *fakeSMTP) serve(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+	f.mu.Lock()
+	f.connections++
+	f.mu.Unlock()
+	text := textproto.NewConn(conn)
+	reply := func(line string) bool { return text.PrintfLine("%s", line) == nil }
+	if !reply("220 fake ESMTP") {
+		return
+	}
+	var message fakeMessage
+	authenticated := false
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, argument, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			lines := []string{"250-fake"}
+			if !f.noTLS && !message.secure {
+				lines = append(lines, "250-STARTTLS")
+			}
+			if message.secure {
+				lines = append(lines, "250-AUTH PLAIN")
+			}
+			lines = append(lines, "250 HELP")
+			for _, line := range lines {
+				reply(line)
+			}
+		case "STARTTLS":
+			reply("220 ready")
+			secure := tls.Server(conn, f.tlsConfig)
+			if err := secure.Handshake(); err != nil {
+				return
+			}
+			conn, text = secure, textproto.NewConn(secure)
+			message.secure = true
+		case "AUTH":
+			mechanism, payload, _ := strings.Cut(argument, " ")
+			decoded, _ := base64.StdEncoding.DecodeString(payload)
+			parts := strings.Split(string(decoded), "\x00")
+			if !message.secure || mechanism != "PLAIN" || len(parts) != 3 ||
+				parts[1] != f.username || parts[2] != f.password {
+				reply("535 authentication failed")
+				continue
+			}
+			authenticated = true
+			reply("235 authenticated")
+		case "MAIL":
+			if !authenticated {
+				reply("530 authentication required")
+				continue
+			}
+			message.from = argument
+			reply("250 ok")
+		case "RCPT":
+			message.to = append(message.to, argument)
+			reply("250 ok")
+		case "DATA":
+			reply("354 go ahead")
+			data, err := text.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			message.data = data
+			f.mu.Lock()
+			f.messages = append(f.messages, message)
+			f.mu.Unlock()
+			reply("250 queued")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 not implemented")
+		}
+	}
+}
+
+func TestDigestIsMailedOncePerDayOverSTARTTLS(t *testing.T) {
+	ctx := context.Background()
+	store, location := openStore(t)
+	// Far enough ahead that accounts created now count as long idle.
+	now := time.Date(2030, 6, 10, 8, 0, 0, 0, location)
+	taskIDs := createTasks(t, store,
+		now.Add(2*time.Hour),  // today, pending
+		now.Add(-time.Hour),   // today, completed below
+		now.AddDate(0, 0, -2), // overdue
+		now.AddDate(0, 0, 1),  // tomorrow, not listed
+	)
+	if _, _, err := store.CompleteTask(ctx, taskIDs[1], now.Add(-30*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	idle := &domain.Account{Name: "建设银行", Active: true}
+	if err := store.CreateAccount(ctx, idle); err != nil {
+		t.Fatal(err)
+	}
+
+	server, pool := startFakeSMTP(t, false)
+	enableDigest(t, store, server.port(), domain.SMTPStartTLS)
+	scheduler := NewScheduler(store, "test")
+	scheduler.rootCAs = pool
+	scheduler.now = func() time.Time { return now }
+
+	// Before the 08:30 send time nothing is sent.
+	if err := scheduler.Tick(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if messages, _ := server.received(); len(messages) != 0 {
+		t.Fatalf("digest sent before its time: %d", len(messages))
+	}
+	now = now.Add(time.Hour)
+	for range 2 {
+		if err := scheduler.Tick(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	messages, _ := server.received()
+	if len(messages) != 1 {
+		t.Fatalf("expected one digest, got %d", len(messages))
+	}
+	message := messages[0]
+	if !message.secure || message.from != "FROM:<nomadbank@example.com>" ||
+		len(message.to) != 1 || message.to[0] != "TO:<owner@example.com>" {
+		t.Fatalf("unexpected envelope: %+v", message)
+	}
+	subject, body := decodeMessage(t, message.data)
+	if subject != "NomadBank 每日摘要 06-10：今日 2 项，逾期 1 项" {
+		t.Fatalf("unexpected subject: %q", subject)
+	}
+	for _, want := range []string{"10:00  招商银行 → 工商银行  12.34 元  待完成", "已完成", "已逾期 2 天", "建设银行", "已 "} {
+		if !strings.Contains(body, want) {
+			t.Errorf("digest lacks %q:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "06-11") || strings.Contains(body, "招商银行  最近活动") {
+		t.Errorf("digest lists tomorrow's task or an active account:\n%s", body)
+	}
+	settings, err := store.EmailDigestSettings(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if settings.LastSentOn != "2030-06-10" || settings.LastError != "" {
+		t.Fatalf("unexpected digest state: %+v", settings)
+	}
+
+	now = now.AddDate(0, 0, 1)
+	if err := scheduler.Tick(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if messages, _ := server.received(); len(messages) != 2 {
+		t.Fatalf("expected the next day's digest, got %d messages", len(messages))
+	}
+}
+
+func TestDigestRequiresSTARTTLSAndRetriesLater(t *testing.T) {
+	ctx := context.Background()
+	store, location := openStore(t)
+	now := time.Date(2030, 6, 10, 9, 0, 0, 0, location)
+	server, _ := startFakeSMTP(t, true)
+	enableDigest(t, store, server.port(), domain.SMTPStartTLS)
+	scheduler := NewScheduler(store, "test")
+	scheduler.now = func() time.Time { return now }
+
+	if err := scheduler.Tick(ctx); err != nil {
+		t.Fatal(err)
+	}
+	settings, err := store.EmailDigestSettings(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if settings.LastSentOn != "" || !strings.Contains(settings.LastError, "STARTTLS") {
+		t.Fatalf("expected a STARTTLS failure: %+v", settings)
+	}
+
+	now = now.Add(time.Minute)
+	if err := scheduler.Tick(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, connections := server.received(); connections != 1 {
+		t.Fatalf("retried before the interval: %d connections", connections)
+	}
+	now = now.Add(digestRetryInterval)
+	if err := scheduler.Tick(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if messages, connections := server.received(); connections != 2 || len(messages) != 0 {
+		t.Fatalf("expected a second refused attempt: %d connections, %d messages", connections, len(messages))
+	}
+}
+
+func enableDigest(t *testing.T, store *sqlite.Store, port int, security domain.SMTPSecurity) {
+	t.Helper()
+	if err := store.UpdateEmailDigestSettings(context.Background(), &domain.EmailDigestSettings{
+		Enabled:      true,
+		SendMinutes:  8*60 + 30,
+		Recipient:    "owner@example.com",
+		Sender:       "NomadBank <nomadbank@example.com>",
+		SMTPHost:     "127.0.0.1",
+		SMTPPort:     port,
+		SMTPSecurity: security,
+		SMTPUsername: "mailer",
+		SMTPPassword: "smtp-password",
+		DormancyDays: 365,
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// decodeMessage returns the decoded subject and body of a message.
+func decodeMessage(t *testing.T, data []byte) (string, string) {
+	t.Helper()
+	message, err := mail.ReadMessage(strings.NewReader(string(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(message.Header.Get("Subject"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := io.ReadAll(quotedprintable.NewReader(message.Body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return subject, strings.ReplaceAll(string(body), "\r\n", "\n")
+}
+
+// selfSignedCertificate returns a certificate for 127.0.0.1 and a pool that
+// trusts it.
+func selfSignedCertificate(t *testing.T) (tls.Certificate, *x509.CertPool) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(parsed)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, pool
+}
@@ -0,0 +1,338 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/CoxxA/nomadbank/v2/internal/domain"
+	"github.com/CoxxA/nomadbank/v2/internal/event"
+	"github.com/CoxxA/nomadbank/v2/internal/sqlite"
+)
+
+const (
+	// webhookMaxAttempts includes the first attempt. With retryBase doubling
+	// the last retry comes about half an hour after the event.
+	webhookMaxAttempts = 6
+	// webhookRetention is how long sent and failed deliveries, and their
+	// retry log, are kept.
+	webhookRetention = 30 * 24 * time.Hour
+)
+
+// Dispatcher delivers events to subscribed webhooks. Unlike reminders,
+// events are delivered at least once: every failure is retried, and a
+// delivery interrupted by a restart is sent again. Receivers drop
+// duplicates by delivery ID. Events are queued by Queue in the transaction
+// that makes the change, so none is lost while the dispatcher is busy or
+// stopped.
+type Dispatcher struct {
+	store     *sqlite.Store
+	client    *http.Client
+	userAgent string
+	now       func() time.Time
+	// wake prompts the delivery loop after events are queued.
+	wake chan struct{}
+}
+
+func NewDispatcher(store *sqlite.Store) *Dispatcher {
+	return &Dispatcher{
+		store:     store,
+		client:    &http.Client{Timeout: 10 * time.Second},
+		userAgent: "NomadBank-Webhooks",
+		now:       time.Now,
+		wake:      make(chan struct{}, 1),
+	}
+}
+
+// EventPayload is the JSON body of every delivery. Data is the resource as
+// the API returns it, read when the event was queued.
+type EventPayload struct {
+	ID         string              `json:"id"`
+	Type       domain.WebhookEvent `json:"type"`
+	OccurredAt time.Time           `json:"occurred_at"`
+	Data       any                 `json:"data"`
+}
+
+// Sign returns the hex HMAC-SHA256 of "<timestamp>.<body>" under secret,
+// sent as X-NomadBank-Signature: sha256=<hex>.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// NewWebhookSecret returns a random signing secret.
+func NewWebhookSecret() string {
+	return "whsec_" + hex.EncodeToString(randomBytes(24))
+}
+
+// newDeliveryID returns a random UUID, so IDs stay unique even if the
+// database is restored from an older backup.
+func newDeliveryID() string {
+	id := randomBytes(16)
+	id[6] = id[6]&0x0f | 0x40
+	id[8] = id[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", id[0:4], id[4:6], id[6:8], id[8:10], id[10:])
+}
+
+func randomBytes(n int) []byte {
+	value := make([]byte, n)
+	// crypto/rand.Read never returns an error.
+	_, _ = rand.Read(value)
+	return value
+}
+
+// Run delivers queued events until ctx is cancelled. Deliveries interrupted
+// by a previous process are queued again first.
+func (d *Dispatcher) Run(ctx context.Context) {
+	if requeued, err := d.store.RequeueInterruptedWebhookDeliveries(ctx); err != nil {
+		slog.ErrorContext(ctx, "重新排队中断的 Webhook 事件", "error", err)
+	} else if requeued > 0 {
+		slog.WarnContext(ctx, "已重新排队中断的 Webhook 事件", "count", requeued)
+	}
+	ticker := time.NewTicker(tickInterval)
+	defer ticker.Stop()
+	for {
+		if err := d.Tick(ctx); err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "发送 Webhook 事件", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-d.wake:
+		}
+	}
+}
+
+// Queue records an event for every active webhook subscribed to it through
+// tx, the transaction that made the change, so the deliveries commit or roll
+// back with it. Events no webhook can subscribe to are ignored. The delivery
+// loop is woken; it runs once the transaction has released the connection.
+func (d *Dispatcher) Queue(ctx context.Context, tx *sqlite.Store, kind event.Type, resourceID int64) error {
+	webhookEvent := domain.WebhookEvent(kind)
+	if !webhookEvent.Subscribable() {
+		return nil
+	}
+	webhooks, err := subscribers(ctx, tx, webhookEvent)
+	if err != nil || len(webhooks) == 0 {
+		return err
+	}
+	data, err := d.snapshot(ctx, tx, webhookEvent, resourceID)
+	if err != nil {
+		return err
+	}
+	for _, webhook := range webhooks {
+		if _, err := d.enqueue(ctx, tx, webhook.ID, webhookEvent, &resourceID, d.now(), data); err != nil {
+			return err
+		}
+	}
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func subscribers(ctx context.Context, store *sqlite.Store, kind domain.WebhookEvent) ([]domain.Webhook, error) {
+	webhooks, err := store.ListWebhooks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	subscribed := webhooks[:0]
+	for _, webhook := range webhooks {
+		if webhook.Subscribes(kind) {
+			subscribed = append(subscribed, webhook)
+		}
+	}
+	return subscribed, nil
+}
+
+// snapshot reads the resource an event refers to.
+func (d *Dispatcher) snapshot(ctx context.Context, store *sqlite.Store, kind domain.WebhookEvent, id int64) (any, error) {
+	switch kind {
+	case domain.WebhookTaskCompleted, domain.WebhookTaskOverdue:
+		task, err := store.GetTask(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		location, err := ownerLocation(ctx, store)
+		if err != nil {
+			return nil, err
+		}
+		task.MarkOverdue(d.now().In(location))
+		return task, nil
+	case domain.WebhookBatchCreated:
+		return store.GetTaskBatch(ctx, id)
+	case domain.WebhookAccountUpdated:
+		return store.GetAccount(ctx, id)
+	}
+	return nil, fmt.Errorf("未知的 Webhook 事件 %q", kind)
+}
+
+func (d *Dispatcher) enqueue(ctx context.Context, store *sqlite.Store, webhookID int64, kind domain.WebhookEvent, resourceID *int64, occurredAt time.Time, data any) (domain.WebhookDelivery, error) {
+	delivery := domain.WebhookDelivery{
+		WebhookID:     webhookID,
+		DeliveryID:    newDeliveryID(),
+		Event:         kind,
+		ResourceID:    resourceID,
+		NextAttemptAt: d.now(),
+		CreatedAt:     d.now(),
+	}
+	payload, err := json.Marshal(EventPayload{
+		ID:         delivery.DeliveryID,
+		Type:       kind,
+		OccurredAt: occurredAt.UTC(),
+		Data:       data,
+	})
+	if err != nil {
+		return domain.WebhookDelivery{}, err
+	}
+	delivery.Payload = payload
+	_, err = store.EnqueueWebhookDelivery(ctx, &delivery)
+	return delivery, err
+}
+
+// Tick queues task.overdue for tasks whose day has passed, sends the
+// deliveries that are due and drops old ones.
+func (d *Dispatcher) Tick(ctx context.Context) error {
+	if err := d.queueOverdue(ctx); err != nil {
+		return err
+	}
+	deliveries, err := d.store.DueWebhookDeliveries(ctx, d.now(), deliveryBatch)
+	if err != nil {
+		return err
+	}
+	for _, delivery := range deliveries {
+		webhook, err := d.store.GetWebhook(ctx, delivery.WebhookID)
+		if err != nil {
+			return err
+		}
+		if _, err := d.deliver(ctx, webhook, delivery); err != nil {
+			return err
+		}
+	}
+	_, err = d.store.PruneWebhookDeliveries(ctx, d.now().Add(-webhookRetention))
+	return err
+}
+
+// queueOverdue looks back overdueLookbackDays like reminders do, so a new
+// subscription is not flooded with every task ever missed.
+func (d *Dispatcher) queueOverdue(ctx context.Context) error {
+	webhooks, err := subscribers(ctx, d.store, domain.WebhookTaskOverdue)
+	if err != nil || len(webhooks) == 0 {
+		return err
+	}
+	location, err := ownerLocation(ctx, d.store)
+	if errors.Is(err, sqlite.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	today := domain.OverdueCutoff(d.now().In(location))
+	for _, webhook := range webhooks {
+		ids, err := d.store.TasksAwaitingOverdueEvent(ctx, webhook.ID, today.AddDate(0, 0, -overdueLookbackDays), today)
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			data, err := d.snapshot(ctx, d.store, domain.WebhookTaskOverdue, id)
+			if errors.Is(err, sqlite.ErrNotFound) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if _, err := d.enqueue(ctx, d.store, webhook.ID, domain.WebhookTaskOverdue, &id, d.now(), data); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// SendTest sends a webhook.test event right away and returns the delivery
+// with its log. Test events are not retried.
+func (d *Dispatcher) SendTest(ctx context.Context, webhook domain.Webhook) (domain.WebhookDelivery, error) {
+	delivery, err := d.enqueue(ctx, d.store, webhook.ID, domain.WebhookTest, nil, d.now(), map[string]any{
+		"webhook_id": webhook.ID,
+		"name":       webhook.Name,
+	})
+	if err != nil {
+		return domain.WebhookDelivery{}, err
+	}
+	return d.deliver(ctx, webhook, delivery)
+}
+
+// deliver makes one attempt and records it in the retry log. It returns the
+// delivery as settled by the attempt.
+func (d *Dispatcher) deliver(ctx context.Context, webhook domain.Webhook, delivery domain.WebhookDelivery) (domain.WebhookDelivery, error) {
+	claimed, err := d.store.ClaimWebhookDelivery(ctx, delivery.ID)
+	if err != nil || !claimed {
+		return delivery, err
+	}
+	attempt := d.post(ctx, webhook, delivery)
+	delivery.Attempts++
+	delivery.Log = append(delivery.Log, attempt)
+	delivery.Status = domain.DeliverySent
+	var retryAt *time.Time
+	if attempt.Error != "" {
+		delivery.Status = domain.DeliveryFailed
+		if delivery.Event != domain.WebhookTest && delivery.Attempts < webhookMaxAttempts {
+			next := d.now().Add(retryBase << (delivery.Attempts - 1))
+			retryAt = &next
+			delivery.Status = domain.DeliveryPending
+			delivery.NextAttemptAt = next
+		}
+		slog.WarnContext(ctx, "发送 Webhook 事件失败",
+			"webhook_id", webhook.ID, "delivery_id", delivery.DeliveryID, "attempts", delivery.Attempts,
+			"retry", retryAt != nil, "error", attempt.Error)
+	}
+	return delivery, d.store.RecordWebhookAttempt(ctx, delivery.ID, attempt, delivery.Status, retryAt)
+}
+
+// post sends a delivery once. Any error or non-2xx response is a failure.
+// The result is named so the deferred duration lands in the returned attempt.
+func (d *Dispatcher) post(ctx context.Context, webhook domain.Webhook, delivery domain.WebhookDelivery) (attempt domain.WebhookAttempt) {
+	started := time.Now()
+	attempt = domain.WebhookAttempt{AttemptedAt: d.now()}
+	defer func() { attempt.DurationMS = time.Since(started).Milliseconds() }()
+
+	timestamp := strconv.FormatInt(attempt.AttemptedAt.Unix(), 10)
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		attempt.Error = "webhook 地址无效"
+		return attempt
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", d.userAgent)
+	request.Header.Set("X-NomadBank-Event", string(delivery.Event))
+	request.Header.Set("X-NomadBank-Delivery", delivery.DeliveryID)
+	request.Header.Set("X-NomadBank-Timestamp", timestamp)
+	request.Header.Set("X-NomadBank-Signature", "sha256="+Sign(webhook.Secret, timestamp, delivery.Payload))
+	response, err := d.client.Do(request)
+	if err != nil {
+		attempt.Error = redactURL(err).Error()
+		return attempt
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(response.Body, 64<<10))
+	_ = response.Body.Close()
+	attempt.ResponseStatus = &response.StatusCode
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		attempt.Error = fmt.Sprintf("webhook 返回 %s", response.Status)
+	}
+	return attempt
+}
@@ -0,0 +1,180 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/CoxxA/nomadbank/v2/internal/domain"
+	"github.com/CoxxA/nomadbank/v2/internal/event"
+	"github.com/CoxxA/nomadbank/v2/internal/sqlite"
+)
+
+// eventReceiver records signed deliveries and answers with the queued
+// statuses, then 204.
+type eventReceiver struct {
+	mu       sync.Mutex
+	bodies   [][]byte
+	headers  []http.Header
+	statuses []int
+}
+
+func (r *eventReceiver) ServeHTTP(response http.ResponseWriter, request *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	body, err := io.ReadAll(request.Body)
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	r.bodies = append(r.bodies, body)
+	r.headers = append(r.headers, request.Header.Clone())
+	status := http.StatusNoContent
+	if len(r.statuses) > 0 {
+		status, r.statuses = r.statuses[0], r.statuses[1:]
+	}
+	response.WriteHeader(status)
+}
+
+// received verifies every request's signature against secret and returns
+// the decoded payloads.
+func (r *eventReceiver) received(t *testing.T, secret string) []EventPayload {
+	t.Helper()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	payloads := make([]EventPayload, 0, len(r.bodies))
+	for index, body := range r.bodies {
+		header := r.headers[index]
+		if header.Get("X-NomadBank-Signature") != "sha256="+Sign(secret, header.Get("X-NomadBank-Timestamp"), body) {
+			t.Fatalf("bad signature on request %d: %v", index, header)
+		}
+		var payload EventPayload
+		if err := json.Unmarshal(body, &payload); err != nil {
+			t.Fatal(err)
+		}
+		if header.Get("X-NomadBank-Delivery") != payload.ID || header.Get("X-NomadBank-Event") != string(payload.Type) {
+			t.Fatalf("headers do not match the payload: %v %+v", header, payload)
+		}
+		payloads = append(payloads, payload)
+	}
+	return payloads
+}
+
+func TestDispatcherSignsAndRetriesEvents(t *testing.T) {
+	ctx := context.Background()
+	store, location := openStore(t)
+	now := time.Date(2026, 6, 10, 9, 0, 0, 0, location)
+	taskIDs := createTasks(t, store, now.Add(-time.Hour), now.AddDate(0, 0, -2))
+	if _, _, err := store.CompleteTask(ctx, taskIDs[0], now); err != nil {
+		t.Fatal(err)
+	}
+
+	receiver := &eventReceiver{statuses: []int{http.StatusServiceUnavailable}}
+	server := httptest.NewServer(receiver)
+	t.Cleanup(server.Close)
+	webhook := createWebhook(t, store, server.URL, true, domain.WebhookTaskCompleted, domain.WebhookTaskOverdue)
+	createWebhook(t, store, server.URL, false, domain.WebhookTaskCompleted)
+
+	dispatcher := NewDispatcher(store)
+	dispatcher.now = func() time.Time { return now }
+	for _, published := range []event.Event{
+		{Type: event.TaskCompleted, ResourceID: taskIDs[0]},
+		{Type: event.AccountCreated, ResourceID: 1},
+	} {
+		if err := dispatcher.Queue(ctx, store, published.Type, published.ResourceID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for range 2 {
+		if err := dispatcher.Tick(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	payloads := receiver.received(t, webhook.Secret)
+	if len(payloads) != 2 || payloads[0].Type != domain.WebhookTaskCompleted || payloads[1].Type != domain.WebhookTaskOverdue {
+		t.Fatalf("expected task.completed then task.overdue once each, got %+v", payloads)
+	}
+	if data := payloads[1].Data.(map[string]any); data["id"] != float64(taskIDs[1]) || data["days_overdue"] != float64(2) {
+		t.Fatalf("unexpected overdue task: %+v", data)
+	}
+
+	deliveries, err := store.ListWebhookDeliveries(ctx, webhook.ID, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	completed := deliveries[1]
+	if completed.Status != domain.DeliveryPending || completed.Attempts != 1 || len(completed.Log) != 1 ||
+		*completed.Log[0].ResponseStatus != http.StatusServiceUnavailable || !completed.NextAttemptAt.Equal(now.Add(retryBase)) {
+		t.Fatalf("expected a logged failure and a retry in one minute: %+v", completed)
+	}
+
+	now = now.Add(retryBase)
+	if err := dispatcher.Tick(ctx); err != nil {
+		t.Fatal(err)
+	}
+	payloads = receiver.received(t, webhook.Secret)
+	if len(payloads) != 3 || payloads[2].ID != payloads[0].ID {
+		t.Fatalf("expected the retry to reuse the delivery ID: %+v", payloads)
+	}
+	deliveries, err = store.ListWebhookDeliveries(ctx, webhook.ID, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if completed := deliveries[1]; completed.Status != domain.DeliverySent || len(completed.Log) != 2 {
+		t.Fatalf("expected the retry to succeed: %+v", completed)
+	}
+}
+
+func TestDispatcherDoesNotRetryTestEvents(t *testing.T) {
+	store, _ := openStore(t)
+	receiver := &eventReceiver{statuses: []int{http.StatusInternalServerError}}
+	server := httptest.NewServer(receiver)
+	t.Cleanup(server.Close)
+	webhook := createWebhook(t, store, server.URL, false, domain.WebhookBatchCreated)
+
+	delivery, err := NewDispatcher(store).SendTest(context.Background(), webhook)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if delivery.Status != domain.DeliveryFailed || len(delivery.Log) != 1 || delivery.Log[0].Error == "" {
+		t.Fatalf("expected a failed test delivery: %+v", delivery)
+	}
+	if payloads := receiver.received(t, webhook.Secret); len(payloads) != 1 || payloads[0].Type != domain.WebhookTest {
+		t.Fatalf("unexpected test payloads: %+v", payloads)
+	}
+}
+
+func TestDispatcherRecordsAttemptDuration(t *testing.T) {
+	store, _ := openStore(t)
+	server := httptest.NewServer(http.HandlerFunc(func(response http.ResponseWriter, _ *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		response.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(server.Close)
+	webhook := createWebhook(t, store, server.URL, true, domain.WebhookBatchCreated)
+
+	if _, err := NewDispatcher(store).SendTest(context.Background(), webhook); err != nil {
+		t.Fatal(err)
+	}
+	deliveries, err := store.ListWebhookDeliveries(context.Background(), webhook.ID, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deliveries) != 1 || len(deliveries[0].Log) != 1 || deliveries[0].Log[0].DurationMS < 20 {
+		t.Fatalf("expected the slow attempt's duration to be recorded: %+v", deliveries)
+	}
+}
+
+func createWebhook(t *testing.T, store *sqlite.Store, url string, active bool, events ...domain.WebhookEvent) domain.Webhook {
+	t.Helper()
+	webhook := domain.Webhook{Name: "接收端", URL: url, Secret: NewWebhookSecret(), Events: events, Active: active}
+	if err := store.CreateWebhook(context.Background(), &webhook); err != nil {
+		t.Fatal(err)
+	}
+	return webhook
+}
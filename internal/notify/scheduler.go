@@ -0,0 +1,235 @@
+// Package notify sends task reminders, the daily email digest and event
+// webhooks. A scheduler queues one reminder per task and kind in the
+// database outbox and delivers queued reminders to the owner's webhook,
+// retrying failures that are known not to have arrived. The digest is mailed
+// once per local day through the configured SMTP server. A dispatcher turns
+// event bus events into signed deliveries for each subscribed webhook and
+// retries them until they arrive.
+package notify
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/CoxxA/nomadbank/v2/internal/auth"
+	"github.com/CoxxA/nomadbank/v2/internal/domain"
+	"github.com/CoxxA/nomadbank/v2/internal/sqlite"
+)
+
+const (
+	// tickInterval is how often Run looks for due tasks and reminders.
+	tickInterval = time.Minute
+	// overdueLookbackDays keeps enabling reminders from flooding the
+	// webhook with every task that was ever missed.
+	overdueLookbackDays = 7
+	// deliveryBatch bounds the reminders sent per tick.
+	deliveryBatch = 20
+	// maxAttempts includes the first attempt.
+	maxAttempts = 5
+	// retryBase doubles after every failed attempt: 1, 2, 4 and 8 minutes.
+	retryBase = time.Minute
+)
+
+// Scheduler turns due tasks into reminders and delivers them, and mails the
+// daily digest.
+type Scheduler struct {
+	store     *sqlite.Store
+	client    *http.Client
+	userAgent string
+	now       func() time.Time
+	// rootCAs verifies the SMTP server; nil uses the system pool.
+	rootCAs *x509.CertPool
+	// links issues completion links below publicURL once EnableTaskLinks
+	// was called.
+	links     *auth.Service
+	publicURL string
+}
+
+func NewScheduler(store *sqlite.Store, version string) *Scheduler {
+	return &Scheduler{
+		store:     store,
+		client:    &http.Client{Timeout: 10 * time.Second},
+		userAgent: "NomadBank/" + version,
+		now:       time.Now,
+	}
+}
+
+// EnableTaskLinks adds a one-click completion link below publicURL, the
+// address the owner opens the app at, to every reminder.
+func (s *Scheduler) EnableTaskLinks(publicURL string) {
+	s.links = auth.NewService(s.store, 0)
+	s.publicURL = publicURL
+}
+
+// Run delivers reminders every tickInterval until ctx is cancelled.
+// Reminders interrupted by a previous process are failed first, since they
+// may have been delivered.
+func (s *Scheduler) Run(ctx context.Context) {
+	s.abandonInterrupted(ctx)
+	ticker := time.NewTicker(tickInterval)
+	defer ticker.Stop()
+	for {
+		if err := s.Tick(ctx); err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "处理提醒", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Scheduler) abandonInterrupted(ctx context.Context) {
+	abandoned, err := s.store.AbandonInterruptedDeliveries(ctx, "发送过程中服务停止，可能已送达，不再重试")
+	if err != nil {
+		slog.ErrorContext(ctx, "清理中断的提醒", "error", err)
+	} else if abandoned > 0 {
+		slog.WarnContext(ctx, "已放弃中断的提醒", "count", abandoned)
+	}
+}
+
+// Tick sends the reminders and the digest that are due. It does nothing
+// until the owner exists, whose timezone decides when a day starts and ends.
+func (s *Scheduler) Tick(ctx context.Context) error {
+	location, err := ownerLocation(ctx, s.store)
+	if errors.Is(err, sqlite.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	now := s.now().In(location)
+	// A failing webhook does not hold back the digest, nor the reverse.
+	return errors.Join(s.remind(ctx, now), s.mailDigest(ctx, now))
+}
+
+// ownerLocation returns the owner's timezone, or sqlite.ErrNotFound before
+// setup.
+func ownerLocation(ctx context.Context, store *sqlite.Store) (*time.Location, error) {
+	credentials, err := store.OwnerCredentials(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return time.LoadLocation(credentials.Owner.Timezone)
+}
+
+// remind queues reminders for tasks that became due and sends those whose
+// attempt is due, once reminders are enabled.
+func (s *Scheduler) remind(ctx context.Context, now time.Time) error {
+	settings, err := s.store.NotificationSettings(ctx)
+	if err != nil {
+		return err
+	}
+	if !settings.Enabled || settings.WebhookURL == "" {
+		return nil
+	}
+	today := domain.OverdueCutoff(now)
+	lead := time.Duration(settings.LeadMinutes) * time.Minute
+	// A task is upcoming from the start of its day until it is overdue, so
+	// one missed by downtime earlier today is still reminded.
+	if _, err := s.store.EnqueueReminders(ctx, domain.ReminderUpcoming, today, now.Add(lead+time.Second), now); err != nil {
+		return err
+	}
+	if _, err := s.store.EnqueueReminders(ctx, domain.ReminderOverdue, today.AddDate(0, 0, -overdueLookbackDays), today, now); err != nil {
+		return err
+	}
+
+	deliveries, err := s.store.DueDeliveries(ctx, now, deliveryBatch)
+	if err != nil {
+		return err
+	}
+	for _, delivery := range deliveries {
+		if err := s.deliver(ctx, settings, now, delivery); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Scheduler) deliver(ctx context.Context, settings domain.NotificationSettings, now time.Time, delivery domain.NotificationDelivery) error {
+	claimed, err := s.store.ClaimDelivery(ctx, delivery.ID)
+	if err != nil || !claimed {
+		return err
+	}
+	task, err := s.store.GetTask(ctx, delivery.TaskID)
+	if errors.Is(err, sqlite.ErrNotFound) {
+		return s.store.CancelDelivery(ctx, delivery.ID, "任务已删除")
+	}
+	if err != nil {
+		return err
+	}
+	if task.Status != domain.TaskStatusPending {
+		return s.store.CancelDelivery(ctx, delivery.ID, "任务已完成")
+	}
+	task.MarkOverdue(now)
+
+	err = s.postJSON(ctx, settings, delivery.ID, newReminder(delivery, task, now.Location(), s.completeURL(ctx, task.ID)))
+	if err == nil {
+		return s.store.MarkDeliverySent(ctx, delivery.ID, s.now())
+	}
+	attempts := delivery.Attempts + 1
+	var retryAt *time.Time
+	if retryable(err) && attempts < maxAttempts {
+		next := s.now().Add(retryBase << (attempts - 1))
+		retryAt = &next
+	}
+	slog.WarnContext(ctx, "发送提醒失败", "delivery_id", delivery.ID, "attempts", attempts, "retry", retryAt != nil, "error", err)
+	return s.store.MarkDeliveryFailed(ctx, delivery.ID, err.Error(), retryAt)
+}
+
+// completeURL returns a fresh completion link for the task, or "" when
+// links are off. A reminder without a link beats no reminder, so failures
+// are only logged.
+func (s *Scheduler) completeURL(ctx context.Context, taskID int64) string {
+	if s.links == nil {
+		return ""
+	}
+	token, _, err := s.links.IssueTaskLink(ctx, taskID)
+	if err != nil {
+		slog.WarnContext(ctx, "生成完成链接", "task_id", taskID, "error", err)
+		return ""
+	}
+	return s.publicURL + "/t/" + token
+}
+
+// Reminder is the JSON body posted to the webhook. ID stays the same across
+// retries so receivers can drop duplicates; CompleteURL is issued anew for
+// every attempt.
+type Reminder struct {
+	ID          string              `json:"id"`
+	Kind        domain.ReminderKind `json:"kind"`
+	Text        string              `json:"text"`
+	Timezone    string              `json:"timezone"`
+	Task        domain.Task         `json:"task"`
+	CompleteURL string              `json:"complete_url,omitempty"`
+}
+
+func newReminder(delivery domain.NotificationDelivery, task domain.Task, location *time.Location, completeURL string) Reminder {
+	scheduled := task.ScheduledAt.In(location).Format("01-02 15:04")
+	transfer := fmt.Sprintf("从「%s」向「%s」转账 %s 元", task.FromAccountName, task.ToAccountName, formatCents(task.AmountCents))
+	text := fmt.Sprintf("待办提醒：%s %s", scheduled, transfer)
+	if delivery.Kind == domain.ReminderOverdue {
+		text = fmt.Sprintf("任务已逾期 %d 天：%s %s", task.DaysOverdue, scheduled, transfer)
+	}
+	if completeURL != "" {
+		text += "\n一键完成：" + completeURL
+	}
+	return Reminder{
+		ID:          fmt.Sprintf("reminder-%d", delivery.ID),
+		Kind:        delivery.Kind,
+		Text:        text,
+		Timezone:    location.String(),
+		Task:        task,
+		CompleteURL: completeURL,
+	}
+}
+
+func formatCents(cents int64) string {
+	return fmt.Sprintf("%d.%02d", cents/100, cents%100)
+}
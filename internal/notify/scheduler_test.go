@@ -0,0 +1,317 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/CoxxA/nomadbank/v2/internal/auth"
+	"github.com/CoxxA/nomadbank/v2/internal/domain"
+	"github.com/CoxxA/nomadbank/v2/internal/sqlite"
+)
+
+// webhookStandIn records reminders and answers with the queued statuses,
+// then 204.
+type webhookStandIn struct {
+	mu        sync.Mutex
+	reminders []Reminder
+	headers   []http.Header
+	statuses  []int
+}
+
+func (w *webhookStandIn) ServeHTTP(response http.ResponseWriter, request *http.Request) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var reminder Reminder
+	if err := json.NewDecoder(request.Body).Decode(&reminder); err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	w.reminders = append(w.reminders, reminder)
+	w.headers = append(w.headers, request.Header.Clone())
+	status := http.StatusNoContent
+	if len(w.statuses) > 0 {
+		status, w.statuses = w.statuses[0], w.statuses[1:]
+	}
+	response.WriteHeader(status)
+}
+
+func (w *webhookStandIn) received() []Reminder {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]Reminder(nil), w.reminders...)
+}
+
+func TestSchedulerDeliversEachReminderOnce(t *testing.T) {
+	ctx := context.Background()
+	store, location := openStore(t)
+	now := time.Date(2026, 6, 10, 9, 0, 0, 0, location)
+	taskIDs := createTasks(t, store,
+		now.Add(30*time.Minute), // upcoming within the hour
+		now.Add(3*time.Hour),    // later today, outside the lead time
+		now.AddDate(0, 0, -2),   // overdue
+	)
+
+	standIn := &webhookStandIn{}
+	server := httptest.NewServer(standIn)
+	t.Cleanup(server.Close)
+	enable(t, store, server.URL+"/hook?token=abc", "webhook-secret")
+
+	scheduler := NewScheduler(store, "test")
+	scheduler.now = func() time.Time { return now }
+	for range 2 {
+		if err := scheduler.Tick(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	reminders := standIn.received()
+	if len(reminders) != 2 {
+		t.Fatalf("expected two reminders, got %+v", reminders)
+	}
+	kinds := map[int64]domain.ReminderKind{}
+	for _, reminder := range reminders {
+		kinds[reminder.Task.ID] = reminder.Kind
+	}
+	if kinds[taskIDs[0]] != domain.ReminderUpcoming || kinds[taskIDs[2]] != domain.ReminderOverdue {
+		t.Fatalf("unexpected reminders: %+v", reminders)
+	}
+	if header := standIn.headers[0]; header.Get("Authorization") != "Bearer webhook-secret" ||
+		header.Get("X-NomadBank-Delivery") == "" || header.Get("Content-Type") != "application/json" {
+		t.Fatalf("unexpected headers: %v", header)
+	}
+	if reminders[0].Text == "" || reminders[0].Timezone != "Asia/Shanghai" {
+		t.Fatalf("unexpected reminder body: %+v", reminders[0])
+	}
+
+	// The second task enters the lead time later; the first is not resent.
+	now = now.Add(2*time.Hour + 30*time.Minute)
+	if err := scheduler.Tick(ctx); err != nil {
+		t.Fatal(err)
+	}
+	reminders = standIn.received()
+	if len(reminders) != 3 || reminders[2].Task.ID != taskIDs[1] {
+		t.Fatalf("expected one more reminder for the later task, got %+v", reminders)
+	}
+}
+
+func TestSchedulerAddsCompletionLinks(t *testing.T) {
+	ctx := context.Background()
+	store, location := openStore(t)
+	now := time.Date(2026, 6, 10, 9, 0, 0, 0, location)
+	taskIDs := createTasks(t, store, now.Add(30*time.Minute))
+
+	standIn := &webhookStandIn{}
+	server := httptest.NewServer(standIn)
+	t.Cleanup(server.Close)
+	enable(t, store, server.URL, "")
+
+	scheduler := NewScheduler(store, "test")
+	scheduler.now = func() time.Time { return now }
+	scheduler.EnableTaskLinks("https://nomadbank.example/app")
+	if err := scheduler.Tick(ctx); err != nil {
+		t.Fatal(err)
+	}
+	reminders := standIn.received()
+	if len(reminders) != 1 {
+		t.Fatalf("expected one reminder, got %+v", reminders)
+	}
+	token, ok := strings.CutPrefix(reminders[0].CompleteURL, "https://nomadbank.example/app/t/")
+	if !ok || !strings.HasSuffix(reminders[0].Text, reminders[0].CompleteURL) {
+		t.Fatalf("expected a completion link in the reminder: %+v", reminders[0])
+	}
+	link, err := auth.NewService(store, 0).VerifyTaskLink(ctx, token)
+	if err != nil || link.TaskID != taskIDs[0] {
+		t.Fatalf("expected a valid link for the task: %+v %v", link, err)
+	}
+}
+
+func TestSchedulerRetriesWithBackoff(t *testing.T) {
+	ctx := context.Background()
+	store, location := openStore(t)
+	now := time.Date(2026, 6, 10, 9, 0, 0, 0, location)
+	createTasks(t, store, now.Add(10*time.Minute), now.Add(20*time.Minute))
+
+	standIn := &webhookStandIn{statuses: []int{
+		http.StatusServiceUnavailable, // first task, retried
+		http.StatusBadRequest,         // second task, permanent
+	}}
+	server := httptest.NewServer(standIn)
+	t.Cleanup(server.Close)
+	enable(t, store, server.URL, "")
+
+	scheduler := NewScheduler(store, "test")
+	scheduler.now = func() time.Time { return now }
+	if err := scheduler.Tick(ctx); err != nil {
+		t.Fatal(err)
+	}
+	deliveries := listDeliveries(t, store)
+	if deliveries[1].Status != domain.DeliveryPending || deliveries[1].Attempts != 1 ||
+		!deliveries[1].NextAttemptAt.Equal(now.Add(retryBase)) {
+		t.Fatalf("expected a retry in one minute: %+v", deliveries[1])
+	}
+	if deliveries[0].Status != domain.DeliveryFailed || deliveries[0].LastError == "" {
+		t.Fatalf("expected a permanent failure: %+v", deliveries[0])
+	}
+	if standIn.headers[0].Get("Authorization") != "" {
+		t.Fatal("authorization sent without a secret")
+	}
+
+	// Not yet due: nothing is sent.
+	if err := scheduler.Tick(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(standIn.received()) != 2 {
+		t.Fatalf("retry sent before its backoff: %d requests", len(standIn.received()))
+	}
+	now = now.Add(retryBase)
+	if err := scheduler.Tick(ctx); err != nil {
+		t.Fatal(err)
+	}
+	deliveries = listDeliveries(t, store)
+	reminders := standIn.received()
+	if deliveries[1].Status != domain.DeliverySent || deliveries[1].Attempts != 2 ||
+		len(reminders) != 3 || reminders[0].ID != reminders[2].ID {
+		t.Fatalf("expected the retry to succeed with the same ID: %+v %+v", deliveries[1], reminders)
+	}
+}
+
+func TestSchedulerDoesNotRetryInterruptedOrCompletedReminders(t *testing.T) {
+	ctx := context.Background()
+	store, location := openStore(t)
+	now := time.Date(2026, 6, 10, 9, 0, 0, 0, location)
+	taskIDs := createTasks(t, store, now.Add(10*time.Minute), now.Add(20*time.Minute))
+
+	if _, err := store.EnqueueReminders(ctx, domain.ReminderUpcoming, now, now.Add(time.Hour), now); err != nil {
+		t.Fatal(err)
+	}
+	deliveries := listDeliveries(t, store)
+	// The first reminder was being sent when the process stopped.
+	if claimed, err := store.ClaimDelivery(ctx, deliveries[1].ID); err != nil || !claimed {
+		t.Fatalf("claim failed: %v", err)
+	}
+	if _, _, err := store.CompleteTask(ctx, taskIDs[1], now); err != nil {
+		t.Fatal(err)
+	}
+
+	standIn := &webhookStandIn{}
+	server := httptest.NewServer(standIn)
+	t.Cleanup(server.Close)
+	enable(t, store, server.URL, "")
+	scheduler := NewScheduler(store, "test")
+	scheduler.now = func() time.Time { return now }
+	scheduler.abandonInterrupted(ctx)
+	if err := scheduler.Tick(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	deliveries = listDeliveries(t, store)
+	if deliveries[1].Status != domain.DeliveryFailed || deliveries[0].Status != domain.DeliveryCancelled {
+		t.Fatalf("unexpected statuses: %+v", deliveries)
+	}
+	if len(standIn.received()) != 0 {
+		t.Fatalf("unexpected requests: %+v", standIn.received())
+	}
+}
+
+func TestMaskURL(t *testing.T) {
+	for raw, want := range map[string]string{
+		"":                                  "",
+		"https://hooks.example.com":         "https://hooks.example.com",
+		"https://hooks.example.com/T0/B1/x": "https://hooks.example.com/******",
+		"https://user:pw@hooks.example.com": "https://hooks.example.com/******",
+		"https://hooks.example.com/?key=1":  "https://hooks.example.com/******",
+	} {
+		if got := MaskURL(raw); got != want {
+			t.Errorf("MaskURL(%q) = %q, want %q", raw, got, want)
+		}
+	}
+}
+
+func openStore(t *testing.T) (*sqlite.Store, *time.Location) {
+	t.Helper()
+	store, err := sqlite.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := store.Close(); err != nil {
+			t.Errorf("close database: %v", err)
+		}
+	})
+	if err := store.CreateOwner(context.Background(), domain.Owner{Username: "owner", Timezone: "Asia/Shanghai"}, "hash"); err != nil {
+		t.Fatal(err)
+	}
+	location, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store, location
+}
+
+// createTasks adds one pending task per time and returns their IDs in order.
+func createTasks(t *testing.T, store *sqlite.Store, times ...time.Time) []int64 {
+	t.Helper()
+	ctx := context.Background()
+	from := &domain.Account{Name: "招商银行", Active: true}
+	to := &domain.Account{Name: "工商银行", Active: true}
+	for _, account := range []*domain.Account{from, to} {
+		if err := store.CreateAccount(ctx, account); err != nil {
+			t.Fatal(err)
+		}
+	}
+	strategy := &domain.Strategy{
+		Name: "默认", IntervalMinDays: 1, IntervalMaxDays: 2, TimeStartMinutes: 540, TimeEndMinutes: 1080,
+		AmountMinCents: 100, AmountMaxCents: 200, DailyLimit: 1,
+	}
+	if err := store.CreateStrategy(ctx, strategy); err != nil {
+		t.Fatal(err)
+	}
+	drafts := make([]domain.TaskDraft, 0, len(times))
+	for _, scheduledAt := range times {
+		drafts = append(drafts, domain.TaskDraft{
+			CycleNo: 1, ScheduledAt: scheduledAt, FromAccountID: from.ID, ToAccountID: to.ID, AmountCents: 1234,
+		})
+	}
+	batch, err := store.CreateTaskBatch(ctx, *strategy, "", 1, drafts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	page, err := store.ListTasks(ctx, sqlite.TaskFilter{BatchID: batch.ID, Page: 1, PageSize: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := make([]int64, 0, len(times))
+	for _, scheduledAt := range times {
+		for _, task := range page.Items {
+			if task.ScheduledAt.Equal(scheduledAt.Truncate(time.Second)) {
+				ids = append(ids, task.ID)
+			}
+		}
+	}
+	return ids
+}
+
+func enable(t *testing.T, store *sqlite.Store, url, secret string) {
+	t.Helper()
+	if err := store.UpdateNotificationSettings(context.Background(), &domain.NotificationSettings{
+		Enabled: true, LeadMinutes: 60, WebhookURL: url, WebhookSecret: secret,
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// listDeliveries returns the outbox newest first.
+func listDeliveries(t *testing.T, store *sqlite.Store) []domain.NotificationDelivery {
+	t.Helper()
+	deliveries, err := store.ListDeliveries(context.Background(), 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return deliveries
+}
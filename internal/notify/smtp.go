@@ -0,0 +1,135 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/CoxxA/nomadbank/v2/internal/domain"
+)
+
+// smtpTimeout bounds one whole SMTP conversation.
+const smtpTimeout = 30 * time.Second
+
+// sendMail delivers a plain-text message to the digest recipient. With
+// STARTTLS the server must offer the upgrade; the message and credentials
+// are never sent in clear text instead.
+func (s *Scheduler) sendMail(ctx context.Context, settings domain.EmailDigestSettings, subject, body string, now time.Time) error {
+	from, err := mail.ParseAddress(settings.Sender)
+	if err != nil {
+		return fmt.Errorf("发件人地址无效: %w", err)
+	}
+	to, err := mail.ParseAddress(settings.Recipient)
+	if err != nil {
+		return fmt.Errorf("收件人地址无效: %w", err)
+	}
+	message, err := composeMessage(from, to, subject, body, now)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, smtpTimeout)
+	defer cancel()
+	address := net.JoinHostPort(settings.SMTPHost, strconv.Itoa(settings.SMTPPort))
+	tlsConfig := &tls.Config{ServerName: settings.SMTPHost, RootCAs: s.rootCAs, MinVersion: tls.VersionTLS12}
+	var conn net.Conn
+	if settings.SMTPSecurity == domain.SMTPTLS {
+		conn, err = (&tls.Dialer{Config: tlsConfig}).DialContext(ctx, "tcp", address)
+	} else {
+		conn, err = (&net.Dialer{}).DialContext(ctx, "tcp", address)
+	}
+	if err != nil {
+		return fmt.Errorf("连接 SMTP 服务器: %w", err)
+	}
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetDeadline(deadline); err != nil {
+		_ = conn.Close()
+		return err
+	}
+	client, err := smtp.NewClient(conn, settings.SMTPHost)
+	if err != nil {
+		_ = conn.Close()
+		return fmt.Errorf("SMTP 握手: %w", err)
+	}
+	// Quit below ends a successful conversation; Close covers the errors.
+	defer func() { _ = client.Close() }()
+
+	if settings.SMTPSecurity == domain.SMTPStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return errors.New("SMTP 服务器不支持 STARTTLS")
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("STARTTLS: %w", err)
+		}
+	}
+	if settings.SMTPUsername != "" {
+		// PlainAuth itself refuses to send the password over an unencrypted
+		// connection to anything but localhost.
+		if err := client.Auth(smtp.PlainAuth("", settings.SMTPUsername, settings.SMTPPassword, settings.SMTPHost)); err != nil {
+			return fmt.Errorf("SMTP 认证: %w", err)
+		}
+	}
+	if err := client.Mail(from.Address); err != nil {
+		return fmt.Errorf("SMTP 发件人: %w", err)
+	}
+	if err := client.Rcpt(to.Address); err != nil {
+		return fmt.Errorf("SMTP 收件人: %w", err)
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("SMTP 正文: %w", err)
+	}
+	if _, err := writer.Write(message); err != nil {
+		return fmt.Errorf("SMTP 正文: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("SMTP 正文: %w", err)
+	}
+	// The server accepted the message; a failed QUIT does not undo that.
+	_ = client.Quit()
+	return nil
+}
+
+// composeMessage builds a UTF-8 plain-text message with CRLF line endings.
+func composeMessage(from, to *mail.Address, subject, body string, now time.Time) ([]byte, error) {
+	var message bytes.Buffer
+	headers := [][2]string{
+		{"From", from.String()},
+		{"To", to.String()},
+		{"Subject", mime.QEncoding.Encode("utf-8", subject)},
+		{"Date", now.Format(time.RFC1123Z)},
+		{"Message-ID", fmt.Sprintf("<digest.%d@%s>", now.UnixNano(), addressDomain(from.Address))},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", "text/plain; charset=utf-8"},
+		{"Content-Transfer-Encoding", "quoted-printable"},
+	}
+	for _, header := range headers {
+		fmt.Fprintf(&message, "%s: %s\r\n", header[0], header[1])
+	}
+	message.WriteString("\r\n")
+	writer := quotedprintable.NewWriter(&message)
+	if _, err := writer.Write([]byte(strings.ReplaceAll(body, "\n", "\r\n"))); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return message.Bytes(), nil
+}
+
+func addressDomain(address string) string {
+	if _, host, ok := strings.Cut(address, "@"); ok {
+		return host
+	}
+	return "localhost"
+}
@@ -0,0 +1,104 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/CoxxA/nomadbank/v2/internal/domain"
+)
+
+// deliveryError is a failed webhook call. Retry is set only when the
+// request certainly did not arrive or the receiver asked for a retry, which
+// keeps delivery at most once.
+type deliveryError struct {
+	err   error
+	retry bool
+}
+
+func (e *deliveryError) Error() string { return e.err.Error() }
+
+func (e *deliveryError) Unwrap() error { return e.err }
+
+func retryable(err error) bool {
+	var delivery *deliveryError
+	return errors.As(err, &delivery) && delivery.retry
+}
+
+// postJSON sends body to the webhook with the delivery ID and, when a secret
+// is set, an Authorization: Bearer header.
+func (s *Scheduler) postJSON(ctx context.Context, settings domain.NotificationSettings, deliveryID int64, body any) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, settings.WebhookURL, bytes.NewReader(payload))
+	if err != nil {
+		return &deliveryError{err: err}
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", s.userAgent)
+	request.Header.Set("X-NomadBank-Delivery", strconv.FormatInt(deliveryID, 10))
+	if settings.WebhookSecret != "" {
+		request.Header.Set("Authorization", "Bearer "+settings.WebhookSecret)
+	}
+	response, err := s.client.Do(request)
+	if err != nil {
+		// Only a failed dial proves nothing was sent; a timeout or reset
+		// after connecting may follow a delivered request.
+		var opError *net.OpError
+		dialFailed := errors.As(err, &opError) && opError.Op == "dial"
+		return &deliveryError{err: redactURL(err), retry: dialFailed}
+	}
+	// The body is irrelevant; draining it lets the connection be reused.
+	_, _ = io.Copy(io.Discard, io.LimitReader(response.Body, 64<<10))
+	_ = response.Body.Close()
+	if response.StatusCode >= 200 && response.StatusCode < 300 {
+		return nil
+	}
+	return &deliveryError{
+		err:   fmt.Errorf("webhook 返回 %s", response.Status),
+		retry: response.StatusCode == http.StatusTooManyRequests || response.StatusCode >= 500,
+	}
+}
+
+// redactURL drops the request URL from client errors, since it may carry a
+// token and the error is stored and shown in the delivery log.
+func redactURL(err error) error {
+	var urlError *url.Error
+	if errors.As(err, &urlError) {
+		return fmt.Errorf("%s webhook: %w", urlError.Op, urlError.Err)
+	}
+	return err
+}
+
+// MaskURL keeps only the scheme and host of a webhook URL, since its path
+// or query often embeds a token.
+func MaskURL(raw string) string {
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Host == "" {
+		return MaskSecret(raw)
+	}
+	masked := parsed.Scheme + "://" + parsed.Host
+	if (parsed.Path != "" && parsed.Path != "/") || parsed.RawQuery != "" || parsed.User != nil {
+		masked += "/" + maskedValue
+	}
+	return masked
+}
+
+const maskedValue = "******"
+
+// MaskSecret shows only whether a secret is set.
+func MaskSecret(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return maskedValue
+}
@@ -10,7 +10,7 @@ import (
 )
 
 func (s *Store) ListAccounts(ctx context.Context, activeOnly bool, groupName string) ([]domain.Account, error) {
-	query := `SELECT id, name, group_name, active, created_at, updated_at FROM accounts WHERE 1 = 1`
+	query := `SELECT id, name, group_name, active, version, created_at, updated_at FROM accounts WHERE 1 = 1`
 	args := make([]any, 0, 2)
 	if activeOnly {
 		query += " AND active = 1"
@@ -33,7 +33,7 @@ func (s *Store) ListAccounts(ctx context.Context, activeOnly bool, groupName str
 		var account domain.Account
 		var active int
 		var createdAt, updatedAt int64
-		if err := rows.Scan(&account.ID, &account.Name, &account.GroupName, &active, &createdAt, &updatedAt); err != nil {
+		if err := rows.Scan(&account.ID, &account.Name, &account.GroupName, &active, &account.Version, &createdAt, &updatedAt); err != nil {
 			return nil, err
 		}
 		account.Active = active == 1
@@ -49,8 +49,8 @@ func (s *Store) GetAccount(ctx context.Context, id int64) (domain.Account, error
 	var active int
 	var createdAt, updatedAt int64
 	err := s.q.QueryRowContext(ctx, `
-		SELECT id, name, group_name, active, created_at, updated_at FROM accounts WHERE id = ?
-	`, id).Scan(&account.ID, &account.Name, &account.GroupName, &active, &createdAt, &updatedAt)
+		SELECT id, name, group_name, active, version, created_at, updated_at FROM accounts WHERE id = ?
+	`, id).Scan(&account.ID, &account.Name, &account.GroupName, &active, &account.Version, &createdAt, &updatedAt)
 	if errors.Is(err, sql.ErrNoRows) {
 		return domain.Account{}, ErrNotFound
 	}
@@ -78,16 +78,20 @@ func (s *Store) CreateAccount(ctx context.Context, account *domain.Account) erro
 	if err != nil {
 		return err
 	}
+	account.Version = 1
 	account.CreatedAt = unixTime(now)
 	account.UpdatedAt = unixTime(now)
 	return nil
 }
 
+// UpdateAccount only succeeds while the stored version still equals
+// account.Version and returns ErrStale when another writer got there first.
 func (s *Store) UpdateAccount(ctx context.Context, account *domain.Account) error {
 	now := time.Now().UTC().Unix()
 	result, err := s.q.ExecContext(ctx, `
-		UPDATE accounts SET name = ?, group_name = ?, active = ?, updated_at = ? WHERE id = ?
-	`, account.Name, account.GroupName, account.Active, now, account.ID)
+		UPDATE accounts SET name = ?, group_name = ?, active = ?, version = version + 1, updated_at = ?
+		WHERE id = ? AND version = ?
+	`, account.Name, account.GroupName, account.Active, now, account.ID, account.Version)
 	if isConstraintError(err) {
 		return ErrConflict
 	}
@@ -99,13 +103,16 @@ func (s *Store) UpdateAccount(ctx context.Context, account *domain.Account) erro
 		return err
 	}
 	if count == 0 {
-		return ErrNotFound
+		return s.missingOrStale(ctx, "accounts", account.ID)
 	}
+	account.Version++
 	account.UpdatedAt = unixTime(now)
 	return nil
 }
 
-func (s *Store) DeleteAccount(ctx context.Context, id int64) error {
+// DeleteAccount removes the account if it is still at version; pass 0 to
+// delete regardless of concurrent edits.
+func (s *Store) DeleteAccount(ctx context.Context, id, version int64) error {
 	var references int
 	if err := s.q.QueryRowContext(ctx, `
 		SELECT COUNT(*) FROM tasks WHERE from_account_id = ? OR to_account_id = ?
@@ -115,7 +122,7 @@ func (s *Store) DeleteAccount(ctx context.Context, id int64) error {
 	if references > 0 {
 		return ErrInUse
 	}
-	result, err := s.q.ExecContext(ctx, "DELETE FROM accounts WHERE id = ?", id)
+	result, err := s.q.ExecContext(ctx, "DELETE FROM accounts WHERE id = ? AND (? = 0 OR version = ?)", id, version, version)
 	if isConstraintError(err) {
 		// A task may have been created after the reference check but before the
 		// delete when this method is used outside a transaction.
@@ -129,7 +136,7 @@ func (s *Store) DeleteAccount(ctx context.Context, id int64) error {
 		return err
 	}
 	if count == 0 {
-		return ErrNotFound
+		return s.missingOrStale(ctx, "accounts", id)
 	}
 	return nil
 }
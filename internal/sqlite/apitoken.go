@@ -0,0 +1,113 @@
+package sqlite
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/CoxxA/nomadbank/v2/internal/domain"
+)
+
+// apiTokenTouchInterval limits last_used_at writes so a busy script does not
+// turn every read into a database write.
+const apiTokenTouchInterval = time.Minute
+
+func (s *Store) ListAPITokens(ctx context.Context) ([]domain.APIToken, error) {
+	rows, err := s.q.QueryContext(ctx, `
+		SELECT id, name, scope, prefix, expires_at, last_used_at, created_at
+		FROM api_tokens ORDER BY created_at DESC, id DESC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	// Iteration errors are returned by rows.Err; Close is cleanup only.
+	defer func() { _ = rows.Close() }()
+
+	tokens := make([]domain.APIToken, 0)
+	for rows.Next() {
+		token, err := scanAPIToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens, rows.Err()
+}
+
+func (s *Store) CreateAPIToken(ctx context.Context, token *domain.APIToken, rawToken string) error {
+	hash := sha256.Sum256([]byte(rawToken))
+	now := time.Now().UTC().Unix()
+	result, err := s.q.ExecContext(ctx, `
+		INSERT INTO api_tokens(name, token_hash, prefix, scope, expires_at, created_at)
+		VALUES(?, ?, ?, ?, ?, ?)
+	`, token.Name, hash[:], token.Prefix, token.Scope, token.ExpiresAt.UTC().Unix(), now)
+	if isConstraintError(err) {
+		return ErrConflict
+	}
+	if err != nil {
+		return err
+	}
+	token.ID, err = result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	token.LastUsedAt = nil
+	token.CreatedAt = unixTime(now)
+	return nil
+}
+
+// UseAPIToken looks up an unexpired token by its secret and records the use.
+func (s *Store) UseAPIToken(ctx context.Context, rawToken string, now time.Time) (domain.APIToken, error) {
+	hash := sha256.Sum256([]byte(rawToken))
+	row := s.q.QueryRowContext(ctx, `
+		SELECT id, name, scope, prefix, expires_at, last_used_at, created_at
+		FROM api_tokens WHERE token_hash = ? AND expires_at > ?
+	`, hash[:], now.UTC().Unix())
+	token, err := scanAPIToken(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.APIToken{}, ErrNotFound
+	}
+	if err != nil {
+		return domain.APIToken{}, err
+	}
+	if token.LastUsedAt != nil && now.Sub(*token.LastUsedAt) < apiTokenTouchInterval {
+		return token, nil
+	}
+	if _, err := s.q.ExecContext(ctx, "UPDATE api_tokens SET last_used_at = ? WHERE id = ?", now.UTC().Unix(), token.ID); err != nil {
+		return domain.APIToken{}, err
+	}
+	usedAt := unixTime(now.UTC().Unix())
+	token.LastUsedAt = &usedAt
+	return token, nil
+}
+
+func (s *Store) DeleteAPIToken(ctx context.Context, id int64) error {
+	result, err := s.q.ExecContext(ctx, "DELETE FROM api_tokens WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func scanAPIToken(row rowScanner) (domain.APIToken, error) {
+	var token domain.APIToken
+	var expiresAt, createdAt int64
+	var lastUsedAt sql.NullInt64
+	err := row.Scan(&token.ID, &token.Name, &token.Scope, &token.Prefix, &expiresAt, &lastUsedAt, &createdAt)
+	if err != nil {
+		return domain.APIToken{}, err
+	}
+	token.ExpiresAt = unixTime(expiresAt)
+	token.LastUsedAt = nullableTime(lastUsedAt)
+	token.CreatedAt = unixTime(createdAt)
+	return token, nil
+}
@@ -0,0 +1,157 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/CoxxA/nomadbank/v2/internal/domain"
+)
+
+type AuditFilter struct {
+	ResourceType string
+	ResourceID   int64
+	Action       domain.AuditAction
+	Page         int
+	PageSize     int
+}
+
+// Audit records event with before and after encoded as JSON; either may be
+// nil. It fills in the resource type from the action and, when
+// retentionDays is positive, drops events older than that many days.
+func (s *Store) Audit(ctx context.Context, event domain.AuditEvent, before, after any, retentionDays int) error {
+	var err error
+	if event.Before, err = auditJSON(before); err != nil {
+		return err
+	}
+	if event.After, err = auditJSON(after); err != nil {
+		return err
+	}
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+	event.ResourceType = event.Action.ResourceType()
+	var expiredBefore time.Time
+	if retentionDays > 0 {
+		expiredBefore = event.CreatedAt.AddDate(0, 0, -retentionDays)
+	}
+	return s.RecordAuditEvent(ctx, &event, expiredBefore)
+}
+
+func auditJSON(value any) (json.RawMessage, error) {
+	if value == nil {
+		return nil, nil
+	}
+	return json.Marshal(value)
+}
+
+// RecordAuditEvent appends an event and drops events created before
+// expiredBefore. A zero expiredBefore keeps every event.
+func (s *Store) RecordAuditEvent(ctx context.Context, event *domain.AuditEvent, expiredBefore time.Time) error {
+	if !expiredBefore.IsZero() {
+		if _, err := s.q.ExecContext(ctx, "DELETE FROM audit_events WHERE created_at < ?", expiredBefore.UTC().Unix()); err != nil {
+			return err
+		}
+	}
+	createdAt := event.CreatedAt.UTC().Unix()
+	result, err := s.q.ExecContext(ctx, `
+		INSERT INTO audit_events(action, resource_type, resource_id, actor_type, actor_name, ip,
+		                         before_json, after_json, created_at)
+		VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, event.Action, event.ResourceType, event.ResourceID, event.ActorType, event.ActorName, event.IP,
+		nullableJSON(event.Before), nullableJSON(event.After), createdAt)
+	if err != nil {
+		return err
+	}
+	event.ID, err = result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	event.CreatedAt = unixTime(createdAt)
+	return nil
+}
+
+// ListAuditEvents returns events newest first.
+func (s *Store) ListAuditEvents(ctx context.Context, filter AuditFilter) (domain.AuditPage, error) {
+	conditions := make([]string, 0, 3)
+	args := make([]any, 0, 5)
+	if filter.ResourceType != "" {
+		conditions = append(conditions, "resource_type = ?")
+		args = append(args, filter.ResourceType)
+	}
+	if filter.ResourceID > 0 {
+		conditions = append(conditions, "resource_id = ?")
+		args = append(args, filter.ResourceID)
+	}
+	if filter.Action != "" {
+		conditions = append(conditions, "action = ?")
+		args = append(args, filter.Action)
+	}
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+	var total int64
+	if err := s.q.QueryRowContext(ctx, "SELECT COUNT(*) FROM audit_events"+where, args...).Scan(&total); err != nil {
+		return domain.AuditPage{}, err
+	}
+
+	offset := (filter.Page - 1) * filter.PageSize
+	rows, err := s.q.QueryContext(ctx, `
+		SELECT id, action, resource_type, resource_id, actor_type, actor_name, ip,
+		       before_json, after_json, created_at
+		FROM audit_events`+where+" ORDER BY id DESC LIMIT ? OFFSET ?",
+		append(args, filter.PageSize, offset)...)
+	if err != nil {
+		return domain.AuditPage{}, err
+	}
+	// Iteration errors are returned by rows.Err; Close is cleanup only.
+	defer func() { _ = rows.Close() }()
+
+	items := make([]domain.AuditEvent, 0)
+	for rows.Next() {
+		var event domain.AuditEvent
+		var resourceID sql.NullInt64
+		var before, after sql.NullString
+		var createdAt int64
+		if err := rows.Scan(
+			&event.ID,
+			&event.Action,
+			&event.ResourceType,
+			&resourceID,
+			&event.ActorType,
+			&event.ActorName,
+			&event.IP,
+			&before,
+			&after,
+			&createdAt,
+		); err != nil {
+			return domain.AuditPage{}, err
+		}
+		if resourceID.Valid {
+			value := resourceID.Int64
+			event.ResourceID = &value
+		}
+		if before.Valid {
+			event.Before = []byte(before.String)
+		}
+		if after.Valid {
+			event.After = []byte(after.String)
+		}
+		event.CreatedAt = unixTime(createdAt)
+		items = append(items, event)
+	}
+	if err := rows.Err(); err != nil {
+		return domain.AuditPage{}, err
+	}
+	return domain.AuditPage{Items: items, Total: total, Page: filter.Page, PageSize: filter.PageSize}, nil
+}
+
+func nullableJSON(value []byte) any {
+	if len(value) == 0 {
+		return nil
+	}
+	return string(value)
+}
@@ -40,7 +40,7 @@ func TestOfflineDataDirectoryBackupAndRestore(t *testing.T) {
 		DisplayName: "Backup Owner",
 		Timezone:    "Asia/Shanghai",
 	}
-	setupSession, err := auth.NewService(sourceStore, 30).Setup(ctx, setupInput)
+	setupSession, err := auth.NewService(sourceStore, 30).Setup(ctx, setupInput, auth.Client{})
 	if err != nil {
 		t.Fatalf("set up owner: %v", err)
 	}
@@ -103,7 +103,7 @@ func TestOfflineDataDirectoryBackupAndRestore(t *testing.T) {
 		t.Fatalf("source task count = %d, want 2", len(sourceTasks.Items))
 	}
 	completedAt := time.Date(2026, time.July, 21, 9, 45, 0, 0, time.UTC)
-	if _, err := sourceStore.CompleteTask(ctx, sourceTasks.Items[0].ID, completedAt); err != nil {
+	if _, _, err := sourceStore.CompleteTask(ctx, sourceTasks.Items[0].ID, completedAt); err != nil {
 		t.Fatalf("complete task: %v", err)
 	}
 
@@ -163,8 +163,8 @@ func TestOfflineDataDirectoryBackupAndRestore(t *testing.T) {
 
 	restoredSession, err := auth.NewService(restoredStore, 30).Login(
 		ctx,
-		setupInput.Username,
-		setupInput.Password,
+		auth.LoginInput{Username: setupInput.Username, Password: setupInput.Password},
+		auth.Client{},
 	)
 	if err != nil {
 		t.Fatalf("log in with restored credentials: %v", err)
@@ -2,24 +2,32 @@ package sqlite
 
 import (
 	"context"
+	"time"
 
 	"github.com/CoxxA/nomadbank/v2/internal/domain"
 )
 
-func (s *Store) Dashboard(ctx context.Context) (domain.Dashboard, error) {
+// Dashboard counts pending tasks scheduled before overdueBefore as overdue.
+func (s *Store) Dashboard(ctx context.Context, overdueBefore time.Time) (domain.Dashboard, error) {
 	var dashboard domain.Dashboard
 	queries := []struct {
 		query string
+		args  []any
 		value *int64
 	}{
-		{"SELECT COUNT(*) FROM accounts", &dashboard.TotalAccounts},
-		{"SELECT COUNT(*) FROM accounts WHERE active = 1", &dashboard.ActiveAccounts},
-		{"SELECT COUNT(*) FROM tasks WHERE status = 'pending'", &dashboard.PendingTasks},
-		{"SELECT COUNT(*) FROM tasks WHERE status = 'completed'", &dashboard.CompletedTasks},
-		{"SELECT COUNT(*) FROM strategies", &dashboard.Strategies},
+		{"SELECT COUNT(*) FROM accounts", nil, &dashboard.TotalAccounts},
+		{"SELECT COUNT(*) FROM accounts WHERE active = 1", nil, &dashboard.ActiveAccounts},
+		{"SELECT COUNT(*) FROM tasks WHERE status = 'pending'", nil, &dashboard.PendingTasks},
+		{
+			"SELECT COUNT(*) FROM tasks WHERE status = 'pending' AND scheduled_at < ?",
+			[]any{overdueBefore.UTC().Unix()},
+			&dashboard.OverdueTasks,
+		},
+		{"SELECT COUNT(*) FROM tasks WHERE status = 'completed'", nil, &dashboard.CompletedTasks},
+		{"SELECT COUNT(*) FROM strategies", nil, &dashboard.Strategies},
 	}
 	for _, item := range queries {
-		if err := s.q.QueryRowContext(ctx, item.query).Scan(item.value); err != nil {
+		if err := s.q.QueryRowContext(ctx, item.query, item.args...).Scan(item.value); err != nil {
 			return domain.Dashboard{}, err
 		}
 	}
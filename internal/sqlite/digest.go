@@ -0,0 +1,120 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/CoxxA/nomadbank/v2/internal/domain"
+)
+
+func (s *Store) EmailDigestSettings(ctx context.Context) (domain.EmailDigestSettings, error) {
+	var settings domain.EmailDigestSettings
+	var lastAttemptAt sql.NullInt64
+	var updatedAt int64
+	err := s.q.QueryRowContext(ctx, `
+		SELECT enabled, send_minutes, recipient, sender, smtp_host, smtp_port, smtp_security,
+		       smtp_username, smtp_password, dormancy_days, last_sent_on, last_attempt_at, last_error, updated_at
+		FROM email_digest_settings WHERE id = 1
+	`).Scan(
+		&settings.Enabled,
+		&settings.SendMinutes,
+		&settings.Recipient,
+		&settings.Sender,
+		&settings.SMTPHost,
+		&settings.SMTPPort,
+		&settings.SMTPSecurity,
+		&settings.SMTPUsername,
+		&settings.SMTPPassword,
+		&settings.DormancyDays,
+		&settings.LastSentOn,
+		&lastAttemptAt,
+		&settings.LastError,
+		&updatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.EmailDigestSettings{}, ErrNotFound
+	}
+	settings.LastAttemptAt = nullableTime(lastAttemptAt)
+	settings.UpdatedAt = unixTime(updatedAt)
+	return settings, err
+}
+
+// UpdateEmailDigestSettings saves the configuration. The delivery state
+// (last sent date, attempt and error) is left alone, so changing the send
+// time does not send a second digest on the same day.
+func (s *Store) UpdateEmailDigestSettings(ctx context.Context, settings *domain.EmailDigestSettings) error {
+	now := time.Now().UTC().Unix()
+	_, err := s.q.ExecContext(ctx, `
+		UPDATE email_digest_settings
+		SET enabled = ?, send_minutes = ?, recipient = ?, sender = ?, smtp_host = ?, smtp_port = ?,
+		    smtp_security = ?, smtp_username = ?, smtp_password = ?, dormancy_days = ?, updated_at = ?
+		WHERE id = 1
+	`,
+		settings.Enabled,
+		settings.SendMinutes,
+		settings.Recipient,
+		settings.Sender,
+		settings.SMTPHost,
+		settings.SMTPPort,
+		settings.SMTPSecurity,
+		settings.SMTPUsername,
+		settings.SMTPPassword,
+		settings.DormancyDays,
+		now,
+	)
+	if err != nil {
+		return err
+	}
+	settings.UpdatedAt = unixTime(now)
+	return nil
+}
+
+// RecordDigestSent marks the digest for day, the owner's local date, as sent.
+func (s *Store) RecordDigestSent(ctx context.Context, day string, sentAt time.Time) error {
+	_, err := s.q.ExecContext(ctx, `
+		UPDATE email_digest_settings SET last_sent_on = ?, last_attempt_at = ?, last_error = '' WHERE id = 1
+	`, day, sentAt.UTC().Unix())
+	return err
+}
+
+func (s *Store) RecordDigestFailed(ctx context.Context, attemptedAt time.Time, message string) error {
+	_, err := s.q.ExecContext(ctx, `
+		UPDATE email_digest_settings SET last_attempt_at = ?, last_error = ? WHERE id = 1
+	`, attemptedAt.UTC().Unix(), message)
+	return err
+}
+
+// IdleAccounts returns the active accounts without a completed task since
+// idleSince, longest idle first. An account that never had one counts from
+// its creation.
+func (s *Store) IdleAccounts(ctx context.Context, idleSince time.Time) ([]domain.IdleAccount, error) {
+	rows, err := s.q.QueryContext(ctx, `
+		SELECT a.id, a.name, a.group_name, COALESCE(MAX(t.completed_at), a.created_at) AS last_active_at
+		FROM accounts a
+		LEFT JOIN tasks t
+		  ON t.status = 'completed' AND (t.from_account_id = a.id OR t.to_account_id = a.id)
+		WHERE a.active = 1
+		GROUP BY a.id
+		HAVING last_active_at < ?
+		ORDER BY last_active_at ASC, a.name COLLATE NOCASE ASC
+	`, idleSince.UTC().Unix())
+	if err != nil {
+		return nil, err
+	}
+	// Iteration errors are returned by rows.Err; Close is cleanup only.
+	defer func() { _ = rows.Close() }()
+
+	accounts := make([]domain.IdleAccount, 0)
+	for rows.Next() {
+		var account domain.IdleAccount
+		var lastActiveAt int64
+		if err := rows.Scan(&account.ID, &account.Name, &account.GroupName, &lastActiveAt); err != nil {
+			return nil, err
+		}
+		account.LastActiveAt = unixTime(lastActiveAt)
+		accounts = append(accounts, account)
+	}
+	return accounts, rows.Err()
+}
@@ -0,0 +1,83 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// IdempotentResponse is the stored outcome of a request made with an
+// Idempotency-Key. Status is zero while the original request is still running.
+// ETag and Location are the response headers a client may act on.
+type IdempotentResponse struct {
+	RequestHash []byte
+	Status      int
+	ContentType string
+	ETag        string
+	Location    string
+	Body        []byte
+}
+
+// ReserveIdempotencyKey claims key for a new request. When the key is already
+// known it returns the stored response instead and reserved is false. Keys
+// created before expiredBefore are purged first, as are reservations made
+// before abandonedBefore that never completed, whose process died mid-request.
+func (s *Store) ReserveIdempotencyKey(
+	ctx context.Context,
+	key string,
+	requestHash []byte,
+	now time.Time,
+	expiredBefore time.Time,
+	abandonedBefore time.Time,
+) (IdempotentResponse, bool, error) {
+	if _, err := s.q.ExecContext(ctx, `
+		DELETE FROM idempotency_keys WHERE created_at < ? OR (status IS NULL AND created_at < ?)
+	`, expiredBefore.UTC().Unix(), abandonedBefore.UTC().Unix()); err != nil {
+		return IdempotentResponse{}, false, err
+	}
+	result, err := s.q.ExecContext(ctx, `
+		INSERT INTO idempotency_keys(idempotency_key, request_hash, created_at) VALUES(?, ?, ?)
+		ON CONFLICT(idempotency_key) DO NOTHING
+	`, key, requestHash, now.UTC().Unix())
+	if err != nil {
+		return IdempotentResponse{}, false, err
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return IdempotentResponse{}, false, err
+	}
+	if count == 1 {
+		return IdempotentResponse{RequestHash: requestHash}, true, nil
+	}
+
+	var stored IdempotentResponse
+	var status sql.NullInt64
+	err = s.q.QueryRowContext(ctx, `
+		SELECT request_hash, status, content_type, etag, location, body FROM idempotency_keys WHERE idempotency_key = ?
+	`, key).Scan(&stored.RequestHash, &status, &stored.ContentType, &stored.ETag, &stored.Location, &stored.Body)
+	if err != nil {
+		return IdempotentResponse{}, false, err
+	}
+	stored.Status = int(status.Int64)
+	return stored, false, nil
+}
+
+// CompleteIdempotencyKey stores the response of the reservation made at
+// reservedAt. A reservation that was given up as abandoned in the meantime
+// and taken over by a retry is left alone.
+func (s *Store) CompleteIdempotencyKey(ctx context.Context, key string, reservedAt time.Time, response IdempotentResponse) error {
+	_, err := s.q.ExecContext(ctx, `
+		UPDATE idempotency_keys SET status = ?, content_type = ?, etag = ?, location = ?, body = ?
+		WHERE idempotency_key = ? AND created_at = ? AND status IS NULL
+	`, response.Status, response.ContentType, response.ETag, response.Location, response.Body, key, reservedAt.UTC().Unix())
+	return err
+}
+
+// ReleaseIdempotencyKey forgets the reservation made at reservedAt whose
+// request did not succeed, so the client may retry with the same key.
+func (s *Store) ReleaseIdempotencyKey(ctx context.Context, key string, reservedAt time.Time) error {
+	_, err := s.q.ExecContext(ctx, `
+		DELETE FROM idempotency_keys WHERE idempotency_key = ? AND created_at = ? AND status IS NULL
+	`, key, reservedAt.UTC().Unix())
+	return err
+}
@@ -0,0 +1,66 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/CoxxA/nomadbank/v2/internal/domain"
+)
+
+// loginAttemptRetention bounds the history shown in settings.
+const loginAttemptRetention = 30 * 24 * time.Hour
+
+func (s *Store) RecordLoginAttempt(ctx context.Context, attempt domain.LoginAttempt) error {
+	createdAt := attempt.CreatedAt.UTC()
+	if _, err := s.q.ExecContext(ctx, "DELETE FROM login_attempts WHERE created_at < ?", createdAt.Add(-loginAttemptRetention).Unix()); err != nil {
+		return err
+	}
+	_, err := s.q.ExecContext(ctx, `
+		INSERT INTO login_attempts(succeeded, ip, user_agent, created_at) VALUES(?, ?, ?, ?)
+	`, attempt.Succeeded, attempt.IP, attempt.UserAgent, createdAt.Unix())
+	return err
+}
+
+// ConsecutiveLoginFailures counts failed attempts after the last successful
+// login, ignoring those before since, and returns when the latest happened.
+func (s *Store) ConsecutiveLoginFailures(ctx context.Context, since time.Time) (int, time.Time, error) {
+	var count int
+	var last sql.NullInt64
+	err := s.q.QueryRowContext(ctx, `
+		SELECT COUNT(*), MAX(created_at) FROM login_attempts
+		WHERE succeeded = 0 AND created_at >= ?
+		  AND id > COALESCE((SELECT MAX(id) FROM login_attempts WHERE succeeded = 1), 0)
+	`, since.UTC().Unix()).Scan(&count, &last)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	if !last.Valid {
+		return count, time.Time{}, nil
+	}
+	return count, unixTime(last.Int64), nil
+}
+
+func (s *Store) ListLoginAttempts(ctx context.Context, limit int) ([]domain.LoginAttempt, error) {
+	rows, err := s.q.QueryContext(ctx, `
+		SELECT id, succeeded, ip, user_agent, created_at
+		FROM login_attempts ORDER BY id DESC LIMIT ?
+	`, limit)
+	if err != nil {
+		return nil, err
+	}
+	// Iteration errors are returned by rows.Err; Close is cleanup only.
+	defer func() { _ = rows.Close() }()
+
+	attempts := make([]domain.LoginAttempt, 0)
+	for rows.Next() {
+		var attempt domain.LoginAttempt
+		var createdAt int64
+		if err := rows.Scan(&attempt.ID, &attempt.Succeeded, &attempt.IP, &attempt.UserAgent, &createdAt); err != nil {
+			return nil, err
+		}
+		attempt.CreatedAt = unixTime(createdAt)
+		attempts = append(attempts, attempt)
+	}
+	return attempts, rows.Err()
+}
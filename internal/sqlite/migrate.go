@@ -0,0 +1,83 @@
+package sqlite
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"log/slog"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// schema.sql is the frozen version 1 baseline. Every later change is a
+// numbered file in migrations/ named NNN_description.sql, where NNN is the
+// schema version it produces.
+//
+//go:embed migrations/*.sql
+var migrationFS embed.FS
+
+type migration struct {
+	version int
+	name    string
+	sql     string
+}
+
+func loadMigrations() ([]migration, error) {
+	entries, err := migrationFS.ReadDir("migrations")
+	if err != nil {
+		return nil, fmt.Errorf("读取数据库迁移: %w", err)
+	}
+	migrations := make([]migration, 0, len(entries))
+	for _, entry := range entries {
+		prefix, _, ok := strings.Cut(entry.Name(), "_")
+		version, err := strconv.Atoi(prefix)
+		if !ok || err != nil {
+			return nil, fmt.Errorf("数据库迁移文件名无效: %s", entry.Name())
+		}
+		content, err := migrationFS.ReadFile(path.Join("migrations", entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("读取数据库迁移 %s: %w", entry.Name(), err)
+		}
+		migrations = append(migrations, migration{version: version, name: entry.Name(), sql: string(content)})
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].version < migrations[j].version })
+	for index, item := range migrations {
+		if item.version != index+2 {
+			return nil, fmt.Errorf("数据库迁移版本不连续: %s", item.name)
+		}
+	}
+	return migrations, nil
+}
+
+// migrate upgrades the schema one version at a time. Each step and its
+// version bump share a transaction, so an interrupted upgrade resumes from
+// the last completed version on the next start.
+func (s *Store) migrate(ctx context.Context, version int) error {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+	latest := len(migrations) + 1
+	if version < 1 || version > latest {
+		return fmt.Errorf("不支持的数据库版本: %d", version)
+	}
+	for _, item := range migrations {
+		if item.version <= version {
+			continue
+		}
+		err := s.WithTx(ctx, func(tx *Store) error {
+			if _, err := tx.q.ExecContext(ctx, item.sql); err != nil {
+				return err
+			}
+			_, err := tx.q.ExecContext(ctx, "UPDATE schema_meta SET version = ? WHERE id = 1", item.version)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("执行数据库迁移 %s: %w", item.name, err)
+		}
+		slog.InfoContext(ctx, "已执行数据库迁移", "migration", item.name, "version", item.version)
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+func TestOpenUpgradesVersionOneDatabase(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "v1.db")
+	writeVersionOneFixture(t, path)
+
+	store, err := Open(path)
+	if err != nil {
+		t.Fatalf("open v1 database: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := store.Close(); err != nil {
+			t.Errorf("close database: %v", err)
+		}
+	})
+
+	migrations, err := loadMigrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var version int
+	if err := store.db.QueryRowContext(ctx, "SELECT version FROM schema_meta WHERE id = 1").Scan(&version); err != nil {
+		t.Fatal(err)
+	}
+	if version != len(migrations)+1 {
+		t.Fatalf("expected schema version %d, got %d", len(migrations)+1, version)
+	}
+
+	credentials, err := store.OwnerCredentials(ctx)
+	if err != nil || credentials.Owner.Username != "fixture-owner" {
+		t.Fatalf("owner was not preserved: %+v %v", credentials, err)
+	}
+	page, err := store.ListTasks(ctx, TaskFilter{Page: 1, PageSize: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Total != 1 || page.Items[0].AmountCents != 1800 {
+		t.Fatalf("task was not preserved: %+v", page)
+	}
+
+	// Opening an already migrated database must be a no-op.
+	if err := store.initialize(ctx); err != nil {
+		t.Fatalf("re-run migrations: %v", err)
+	}
+}
+
+func TestOpenRejectsNewerDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "future.db")
+	writeVersionOneFixture(t, path)
+	db, err := sql.Open("sqlite", "file:"+filepath.ToSlash(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("UPDATE schema_meta SET version = 999 WHERE id = 1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := Open(path)
+	if err == nil {
+		_ = store.Close()
+		t.Fatal("expected newer schema version to be rejected")
+	}
+}
+
+// writeVersionOneFixture creates a database exactly as released 2.0.x builds
+// did, before any numbered migration existed.
+func writeVersionOneFixture(t *testing.T, path string) {
+	t.Helper()
+	schema, err := schemaFS.ReadFile("schema.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := sql.Open("sqlite", fmt.Sprintf("file:%s?_pragma=foreign_keys(1)", filepath.ToSlash(path)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("close fixture: %v", err)
+		}
+	}()
+	statements := []string{
+		string(schema),
+		`INSERT INTO owner(id, username, password_hash, display_name, timezone, created_at, updated_at)
+		 VALUES(1, 'fixture-owner', 'hash', 'Fixture', 'Asia/Shanghai', 1784000000, 1784000000)`,
+		`INSERT INTO sessions(token_hash, expires_at, created_at) VALUES(x'00', 1794000000, 1784000000)`,
+		`INSERT INTO accounts(id, name, group_name, active, created_at, updated_at)
+		 VALUES(1, 'Checking', 'Personal', 1, 1784000000, 1784000000),
+		       (2, 'Savings', 'Personal', 1, 1784000000, 1784000000)`,
+		`INSERT INTO strategies(id, name, interval_min_days, interval_max_days, time_start_minutes,
+		        time_end_minutes, skip_weekends, amount_min_cents, amount_max_cents, daily_limit,
+		        created_at, updated_at)
+		 VALUES(1, 'Fixture', 14, 28, 480, 1200, 0, 1000, 3000, 2, 1784000000, 1784000000)`,
+		`INSERT INTO task_batches(id, strategy_id, strategy_name, group_name, cycle_count, created_at)
+		 VALUES(1, 1, 'Fixture', 'Personal', 1, 1784000000)`,
+		`INSERT INTO tasks(batch_id, cycle_no, scheduled_at, from_account_id, to_account_id,
+		        amount_cents, status, created_at)
+		 VALUES(1, 1, 1784600000, 1, 2, 1800, 'pending', 1784000000)`,
+	}
+	for _, statement := range statements {
+		if _, err := db.Exec(statement); err != nil {
+			t.Fatalf("write v1 fixture: %v", err)
+		}
+	}
+}
@@ -0,0 +1,4 @@
+CREATE INDEX IF NOT EXISTS idx_tasks_from_scheduled ON tasks(from_account_id, scheduled_at);
+CREATE INDEX IF NOT EXISTS idx_tasks_to_scheduled ON tasks(to_account_id, scheduled_at);
+CREATE INDEX IF NOT EXISTS idx_tasks_amount ON tasks(amount_cents);
+CREATE INDEX IF NOT EXISTS idx_task_batches_group ON task_batches(group_name);
@@ -0,0 +1,2 @@
+ALTER TABLE accounts ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE strategies ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS api_tokens (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    token_hash BLOB NOT NULL UNIQUE,
+    prefix TEXT NOT NULL,
+    scope TEXT NOT NULL CHECK (scope IN ('read_only', 'read_write')),
+    expires_at INTEGER NOT NULL,
+    last_used_at INTEGER,
+    created_at INTEGER NOT NULL
+);
@@ -0,0 +1,10 @@
+ALTER TABLE owner ADD COLUMN totp_secret TEXT NOT NULL DEFAULT '';
+ALTER TABLE owner ADD COLUMN totp_pending_secret TEXT NOT NULL DEFAULT '';
+ALTER TABLE owner ADD COLUMN totp_last_step INTEGER NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS recovery_codes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    code_hash BLOB NOT NULL UNIQUE,
+    used_at INTEGER,
+    created_at INTEGER NOT NULL
+);
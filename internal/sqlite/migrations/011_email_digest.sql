@@ -0,0 +1,23 @@
+-- A single row configures the daily email digest. The SMTP password is
+-- stored in plain text because it must be sent; the API never returns it.
+-- last_sent_on is the owner's local date of the last digest, so each day
+-- gets at most one.
+CREATE TABLE IF NOT EXISTS email_digest_settings (
+    id INTEGER PRIMARY KEY CHECK (id = 1),
+    enabled INTEGER NOT NULL DEFAULT 0,
+    send_minutes INTEGER NOT NULL DEFAULT 480 CHECK (send_minutes BETWEEN 0 AND 1439),
+    recipient TEXT NOT NULL DEFAULT '',
+    sender TEXT NOT NULL DEFAULT '',
+    smtp_host TEXT NOT NULL DEFAULT '',
+    smtp_port INTEGER NOT NULL DEFAULT 587,
+    smtp_security TEXT NOT NULL DEFAULT 'starttls' CHECK (smtp_security IN ('starttls', 'tls', 'none')),
+    smtp_username TEXT NOT NULL DEFAULT '',
+    smtp_password TEXT NOT NULL DEFAULT '',
+    dormancy_days INTEGER NOT NULL DEFAULT 365,
+    last_sent_on TEXT NOT NULL DEFAULT '',
+    last_attempt_at INTEGER,
+    last_error TEXT NOT NULL DEFAULT '',
+    updated_at INTEGER NOT NULL DEFAULT 0
+);
+
+INSERT OR IGNORE INTO email_digest_settings(id) VALUES(1);
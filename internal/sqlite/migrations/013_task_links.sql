@@ -0,0 +1,15 @@
+-- Signs one-click completion links together with the password hash, so a
+-- password change invalidates every outstanding link.
+ALTER TABLE owner ADD COLUMN link_secret TEXT NOT NULL DEFAULT '';
+UPDATE owner SET link_secret = lower(hex(randomblob(32)));
+
+-- Completion links that were used, kept until they would have expired
+-- anyway, so each link works once.
+CREATE TABLE IF NOT EXISTS used_task_links (
+    nonce TEXT PRIMARY KEY,
+    task_id INTEGER NOT NULL,
+    used_at INTEGER NOT NULL,
+    expires_at INTEGER NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_used_task_links_expires_at ON used_task_links(expires_at);
@@ -0,0 +1,2 @@
+ALTER TABLE idempotency_keys ADD COLUMN etag TEXT NOT NULL DEFAULT '';
+ALTER TABLE idempotency_keys ADD COLUMN location TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,161 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/CoxxA/nomadbank/v2/internal/domain"
+)
+
+func (s *Store) NotificationSettings(ctx context.Context) (domain.NotificationSettings, error) {
+	var settings domain.NotificationSettings
+	var updatedAt int64
+	err := s.q.QueryRowContext(ctx, `
+		SELECT enabled, lead_minutes, webhook_url, webhook_secret, updated_at
+		FROM notification_settings WHERE id = 1
+	`).Scan(&settings.Enabled, &settings.LeadMinutes, &settings.WebhookURL, &settings.WebhookSecret, &updatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.NotificationSettings{}, ErrNotFound
+	}
+	settings.UpdatedAt = unixTime(updatedAt)
+	return settings, err
+}
+
+func (s *Store) UpdateNotificationSettings(ctx context.Context, settings *domain.NotificationSettings) error {
+	now := time.Now().UTC().Unix()
+	_, err := s.q.ExecContext(ctx, `
+		UPDATE notification_settings
+		SET enabled = ?, lead_minutes = ?, webhook_url = ?, webhook_secret = ?, updated_at = ?
+		WHERE id = 1
+	`, settings.Enabled, settings.LeadMinutes, settings.WebhookURL, settings.WebhookSecret, now)
+	if err != nil {
+		return err
+	}
+	settings.UpdatedAt = unixTime(now)
+	return nil
+}
+
+// EnqueueReminders queues a reminder of kind for every pending task
+// scheduled in [from, before). Tasks that already have one are skipped, so
+// each task gets each kind at most once.
+func (s *Store) EnqueueReminders(ctx context.Context, kind domain.ReminderKind, from, before, now time.Time) (int64, error) {
+	result, err := s.q.ExecContext(ctx, `
+		INSERT OR IGNORE INTO notification_outbox(task_id, kind, next_attempt_at, created_at)
+		SELECT id, ?, ?, ? FROM tasks
+		WHERE status = 'pending' AND scheduled_at >= ? AND scheduled_at < ?
+		ORDER BY scheduled_at, id
+	`, kind, now.UTC().Unix(), now.UTC().Unix(), from.UTC().Unix(), before.UTC().Unix())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// DueDeliveries returns pending reminders whose next attempt is due, oldest
+// first.
+func (s *Store) DueDeliveries(ctx context.Context, now time.Time, limit int) ([]domain.NotificationDelivery, error) {
+	return s.listDeliveries(ctx, `
+		WHERE status = 'pending' AND next_attempt_at <= ? ORDER BY next_attempt_at, id LIMIT ?
+	`, now.UTC().Unix(), limit)
+}
+
+// ListDeliveries returns the most recently queued reminders.
+func (s *Store) ListDeliveries(ctx context.Context, limit int) ([]domain.NotificationDelivery, error) {
+	return s.listDeliveries(ctx, "ORDER BY id DESC LIMIT ?", limit)
+}
+
+func (s *Store) listDeliveries(ctx context.Context, clause string, args ...any) ([]domain.NotificationDelivery, error) {
+	rows, err := s.q.QueryContext(ctx, `
+		SELECT id, task_id, kind, status, attempts, next_attempt_at, last_error, sent_at, created_at
+		FROM notification_outbox `+clause, args...)
+	if err != nil {
+		return nil, err
+	}
+	// Iteration errors are returned by rows.Err; Close is cleanup only.
+	defer func() { _ = rows.Close() }()
+
+	deliveries := make([]domain.NotificationDelivery, 0)
+	for rows.Next() {
+		var delivery domain.NotificationDelivery
+		var nextAttemptAt, createdAt int64
+		var sentAt sql.NullInt64
+		if err := rows.Scan(
+			&delivery.ID,
+			&delivery.TaskID,
+			&delivery.Kind,
+			&delivery.Status,
+			&delivery.Attempts,
+			&nextAttemptAt,
+			&delivery.LastError,
+			&sentAt,
+			&createdAt,
+		); err != nil {
+			return nil, err
+		}
+		delivery.NextAttemptAt = unixTime(nextAttemptAt)
+		delivery.SentAt = nullableTime(sentAt)
+		delivery.CreatedAt = unixTime(createdAt)
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, rows.Err()
+}
+
+// ClaimDelivery marks a pending reminder as being sent and counts the
+// attempt. It reports false when another run claimed it first. The claim is
+// committed before sending, so a crash mid-send leaves the row in sending
+// rather than pending.
+func (s *Store) ClaimDelivery(ctx context.Context, id int64) (bool, error) {
+	result, err := s.q.ExecContext(ctx, `
+		UPDATE notification_outbox SET status = 'sending', attempts = attempts + 1
+		WHERE id = ? AND status = 'pending'
+	`, id)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected == 1, err
+}
+
+func (s *Store) MarkDeliverySent(ctx context.Context, id int64, sentAt time.Time) error {
+	_, err := s.q.ExecContext(ctx, `
+		UPDATE notification_outbox SET status = 'sent', last_error = '', sent_at = ? WHERE id = ?
+	`, sentAt.UTC().Unix(), id)
+	return err
+}
+
+// MarkDeliveryFailed records a failed attempt. A non-nil retryAt puts the
+// reminder back in the queue; otherwise it stays failed.
+func (s *Store) MarkDeliveryFailed(ctx context.Context, id int64, message string, retryAt *time.Time) error {
+	if retryAt == nil {
+		_, err := s.q.ExecContext(ctx, `
+			UPDATE notification_outbox SET status = 'failed', last_error = ? WHERE id = ?
+		`, message, id)
+		return err
+	}
+	_, err := s.q.ExecContext(ctx, `
+		UPDATE notification_outbox SET status = 'pending', last_error = ?, next_attempt_at = ? WHERE id = ?
+	`, message, retryAt.UTC().Unix(), id)
+	return err
+}
+
+func (s *Store) CancelDelivery(ctx context.Context, id int64, reason string) error {
+	_, err := s.q.ExecContext(ctx, `
+		UPDATE notification_outbox SET status = 'cancelled', last_error = ? WHERE id = ?
+	`, reason, id)
+	return err
+}
+
+// AbandonInterruptedDeliveries fails reminders left in sending by a process
+// that stopped mid-send. They may have been delivered, so they are not
+// retried.
+func (s *Store) AbandonInterruptedDeliveries(ctx context.Context, reason string) (int64, error) {
+	result, err := s.q.ExecContext(ctx, `
+		UPDATE notification_outbox SET status = 'failed', last_error = ? WHERE status = 'sending'
+	`, reason)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -2,8 +2,10 @@ package sqlite
 
 import (
 	"context"
+	"crypto/rand"
 	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
 	"errors"
 	"time"
 
@@ -32,11 +34,15 @@ func (s *Store) CreateOwner(ctx context.Context, owner domain.Owner, passwordHas
 		return ErrAlreadyInitialized
 	}
 
+	linkSecret := make([]byte, 32)
+	if _, err := rand.Read(linkSecret); err != nil {
+		return err
+	}
 	now := time.Now().UTC().Unix()
 	_, err = s.q.ExecContext(ctx, `
-		INSERT INTO owner(id, username, password_hash, display_name, timezone, created_at, updated_at)
-		VALUES(1, ?, ?, ?, ?, ?, ?)
-	`, owner.Username, passwordHash, owner.DisplayName, owner.Timezone, now, now)
+		INSERT INTO owner(id, username, password_hash, display_name, timezone, link_secret, created_at, updated_at)
+		VALUES(1, ?, ?, ?, ?, ?, ?, ?)
+	`, owner.Username, passwordHash, owner.DisplayName, owner.Timezone, hex.EncodeToString(linkSecret), now, now)
 	if isConstraintError(err) {
 		return ErrConflict
 	}
@@ -94,22 +100,69 @@ func (s *Store) UpdateOwner(ctx context.Context, owner domain.Owner) error {
 	return nil
 }
 
-func (s *Store) CreateSession(ctx context.Context, rawToken string, expiresAt time.Time) error {
+// sessionTouchInterval limits last_seen_at writes in the same way as
+// apiTokenTouchInterval.
+const sessionTouchInterval = time.Minute
+
+func (s *Store) CreateSession(ctx context.Context, rawToken string, session domain.Session) error {
 	hash := sha256.Sum256([]byte(rawToken))
 	now := time.Now().UTC().Unix()
 	_, err := s.q.ExecContext(ctx, `
-		INSERT INTO sessions(token_hash, expires_at, created_at) VALUES(?, ?, ?)
-	`, hash[:], expiresAt.UTC().Unix(), now)
+		INSERT INTO sessions(token_hash, user_agent, ip, last_seen_at, expires_at, created_at)
+		VALUES(?, ?, ?, ?, ?, ?)
+	`, hash[:], session.UserAgent, session.IP, now, session.ExpiresAt.UTC().Unix(), now)
 	return err
 }
 
-func (s *Store) SessionValid(ctx context.Context, rawToken string, now time.Time) (bool, error) {
+// TouchSession reports whether rawToken names an unexpired session and
+// records the activity.
+func (s *Store) TouchSession(ctx context.Context, rawToken string, now time.Time) (bool, error) {
 	hash := sha256.Sum256([]byte(rawToken))
-	var count int
+	var lastSeenAt int64
 	err := s.q.QueryRowContext(ctx, `
-		SELECT COUNT(*) FROM sessions WHERE token_hash = ? AND expires_at > ?
-	`, hash[:], now.UTC().Unix()).Scan(&count)
-	return count > 0, err
+		SELECT last_seen_at FROM sessions WHERE token_hash = ? AND expires_at > ?
+	`, hash[:], now.UTC().Unix()).Scan(&lastSeenAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if now.Sub(unixTime(lastSeenAt)) < sessionTouchInterval {
+		return true, nil
+	}
+	_, err = s.q.ExecContext(ctx, "UPDATE sessions SET last_seen_at = ? WHERE token_hash = ?", now.UTC().Unix(), hash[:])
+	return err == nil, err
+}
+
+// ListSessions returns the unexpired sessions, most recently active first,
+// marking the one identified by currentToken.
+func (s *Store) ListSessions(ctx context.Context, currentToken string, now time.Time) ([]domain.Session, error) {
+	hash := sha256.Sum256([]byte(currentToken))
+	rows, err := s.q.QueryContext(ctx, `
+		SELECT id, user_agent, ip, last_seen_at, expires_at, created_at, token_hash = ?
+		FROM sessions WHERE expires_at > ? ORDER BY last_seen_at DESC, id DESC
+	`, hash[:], now.UTC().Unix())
+	if err != nil {
+		return nil, err
+	}
+	// Iteration errors are returned by rows.Err; Close is cleanup only.
+	defer func() { _ = rows.Close() }()
+
+	sessions := make([]domain.Session, 0)
+	for rows.Next() {
+		var session domain.Session
+		var lastSeenAt, expiresAt, createdAt int64
+		err := rows.Scan(&session.ID, &session.UserAgent, &session.IP, &lastSeenAt, &expiresAt, &createdAt, &session.Current)
+		if err != nil {
+			return nil, err
+		}
+		session.LastSeenAt = unixTime(lastSeenAt)
+		session.ExpiresAt = unixTime(expiresAt)
+		session.CreatedAt = unixTime(createdAt)
+		sessions = append(sessions, session)
+	}
+	return sessions, rows.Err()
 }
 
 func (s *Store) DeleteSession(ctx context.Context, rawToken string) error {
@@ -118,6 +171,35 @@ func (s *Store) DeleteSession(ctx context.Context, rawToken string) error {
 	return err
 }
 
+// DeleteSessionByID revokes one session and reports whether it belonged to
+// currentToken.
+func (s *Store) DeleteSessionByID(ctx context.Context, id int64, currentToken string) (bool, error) {
+	hash := sha256.Sum256([]byte(currentToken))
+	var current bool
+	err := s.q.QueryRowContext(ctx, "SELECT token_hash = ? FROM sessions WHERE id = ?", hash[:], id).Scan(&current)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, ErrNotFound
+	}
+	if err != nil {
+		return false, err
+	}
+	if _, err := s.q.ExecContext(ctx, "DELETE FROM sessions WHERE id = ?", id); err != nil {
+		return false, err
+	}
+	return current, nil
+}
+
+// DeleteOtherSessions revokes every session except currentToken's and returns
+// how many were removed.
+func (s *Store) DeleteOtherSessions(ctx context.Context, currentToken string) (int64, error) {
+	hash := sha256.Sum256([]byte(currentToken))
+	result, err := s.q.ExecContext(ctx, "DELETE FROM sessions WHERE token_hash <> ?", hash[:])
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 func (s *Store) DeleteAllSessions(ctx context.Context) error {
 	_, err := s.q.ExecContext(ctx, "DELETE FROM sessions")
 	return err
@@ -10,6 +10,7 @@ import (
 	"path/filepath"
 	"strings"
 	"time"
+	"unicode"
 
 	_ "modernc.org/sqlite"
 )
@@ -19,6 +20,7 @@ var (
 	ErrAlreadyInitialized = errors.New("应用已经初始化")
 	ErrConflict           = errors.New("数据冲突")
 	ErrInUse              = errors.New("记录正在被使用")
+	ErrStale              = errors.New("记录已被修改")
 )
 
 //go:embed schema.sql
@@ -31,8 +33,23 @@ type queryer interface {
 }
 
 type Store struct {
-	db *sql.DB
-	q  queryer
+	db      *sql.DB
+	q       queryer
+	observe QueryObserver
+	// inTx marks a store bound to a transaction opened by WithTx.
+	inTx bool
+}
+
+// QueryObserver receives the lowercased leading keyword of each statement,
+// e.g. "select", and how long the driver took to execute it. For queries
+// returning rows the time until the first result is measured, not the scan.
+type QueryObserver func(statement string, elapsed time.Duration)
+
+// ObserveQueries reports every later statement, including those run inside
+// WithTx, to observe. It must be called before the store is shared.
+func (s *Store) ObserveQueries(observe QueryObserver) {
+	s.observe = observe
+	s.q = observedQueryer{q: s.db, observe: observe}
 }
 
 func Open(path string) (*Store, error) {
@@ -72,10 +89,7 @@ func (s *Store) initialize(ctx context.Context) error {
 	if err := s.db.QueryRowContext(ctx, "SELECT version FROM schema_meta WHERE id = 1").Scan(&version); err != nil {
 		return fmt.Errorf("读取数据库版本: %w", err)
 	}
-	if version != 1 {
-		return fmt.Errorf("不支持的数据库版本: %d", version)
-	}
-	return nil
+	return s.migrate(ctx, version)
 }
 
 func (s *Store) Close() error {
@@ -86,7 +100,14 @@ func (s *Store) Ping(ctx context.Context) error {
 	return s.db.PingContext(ctx)
 }
 
+// WithTx runs fn in a transaction and commits it when fn returns nil. Called
+// on a store that is already bound to a transaction, it runs fn in that
+// transaction, so a service can be handed the caller's transaction and its
+// changes commit or roll back with the caller's.
 func (s *Store) WithTx(ctx context.Context, fn func(*Store) error) error {
+	if s.inTx {
+		return fn(s)
+	}
 	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
 	if err != nil {
 		return err
@@ -98,13 +119,58 @@ func (s *Store) WithTx(ctx context.Context, fn func(*Store) error) error {
 		_ = tx.Rollback()
 	}()
 
-	txStore := &Store{db: s.db, q: tx}
+	txStore := &Store{db: s.db, q: tx, observe: s.observe, inTx: true}
+	if s.observe != nil {
+		txStore.q = observedQueryer{q: tx, observe: s.observe}
+	}
 	if err := fn(txStore); err != nil {
 		return err
 	}
 	return tx.Commit()
 }
 
+type observedQueryer struct {
+	q       queryer
+	observe QueryObserver
+}
+
+func (o observedQueryer) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	defer o.done(query, time.Now())
+	return o.q.ExecContext(ctx, query, args...)
+}
+
+func (o observedQueryer) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	defer o.done(query, time.Now())
+	return o.q.QueryContext(ctx, query, args...)
+}
+
+func (o observedQueryer) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	defer o.done(query, time.Now())
+	return o.q.QueryRowContext(ctx, query, args...)
+}
+
+func (o observedQueryer) done(query string, started time.Time) {
+	statement := strings.TrimSpace(query)
+	if end := strings.IndexFunc(statement, unicode.IsSpace); end >= 0 {
+		statement = statement[:end]
+	}
+	o.observe(strings.ToLower(statement), time.Since(started))
+}
+
+// missingOrStale explains why a version-checked write touched no rows. table
+// is always a constant from this package.
+func (s *Store) missingOrStale(ctx context.Context, table string, id int64) error {
+	var exists bool
+	err := s.q.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM "+table+" WHERE id = ?)", id).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrNotFound
+	}
+	return ErrStale
+}
+
 func unixTime(value int64) time.Time {
 	return time.Unix(value, 0).UTC()
 }
@@ -49,3 +49,36 @@ func TestWithTxRollsBackAfterPanic(t *testing.T) {
 		t.Fatal("panicking transaction was not rolled back")
 	}
 }
+
+func TestNestedWithTxJoinsOuterTransaction(t *testing.T) {
+	store, err := Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := store.Close(); err != nil {
+			t.Errorf("close database: %v", err)
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err = store.WithTx(ctx, func(tx *Store) error {
+		if err := tx.WithTx(ctx, func(inner *Store) error {
+			return inner.CreateOwner(ctx, domain.Owner{Username: "owner", Timezone: "UTC"}, "password-hash")
+		}); err != nil {
+			return err
+		}
+		return ErrConflict
+	})
+	if err != ErrConflict {
+		t.Fatalf("outer transaction error = %v", err)
+	}
+	initialized, err := store.IsInitialized(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if initialized {
+		t.Fatal("nested transaction committed although the outer one rolled back")
+	}
+}
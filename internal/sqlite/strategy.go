@@ -13,7 +13,7 @@ func (s *Store) ListStrategies(ctx context.Context) ([]domain.Strategy, error) {
 	rows, err := s.q.QueryContext(ctx, `
 		SELECT id, name, interval_min_days, interval_max_days,
 		       time_start_minutes, time_end_minutes, skip_weekends,
-		       amount_min_cents, amount_max_cents, daily_limit, created_at, updated_at
+		       amount_min_cents, amount_max_cents, daily_limit, version, created_at, updated_at
 		FROM strategies ORDER BY name COLLATE NOCASE ASC
 	`)
 	if err != nil {
@@ -37,7 +37,7 @@ func (s *Store) GetStrategy(ctx context.Context, id int64) (domain.Strategy, err
 	row := s.q.QueryRowContext(ctx, `
 		SELECT id, name, interval_min_days, interval_max_days,
 		       time_start_minutes, time_end_minutes, skip_weekends,
-		       amount_min_cents, amount_max_cents, daily_limit, created_at, updated_at
+		       amount_min_cents, amount_max_cents, daily_limit, version, created_at, updated_at
 		FROM strategies WHERE id = ?
 	`, id)
 	strategy, err := scanStrategy(row)
@@ -66,6 +66,7 @@ func scanStrategy(row rowScanner) (domain.Strategy, error) {
 		&strategy.AmountMinCents,
 		&strategy.AmountMaxCents,
 		&strategy.DailyLimit,
+		&strategy.Version,
 		&createdAt,
 		&updatedAt,
 	)
@@ -109,19 +110,22 @@ func (s *Store) CreateStrategy(ctx context.Context, strategy *domain.Strategy) e
 	if err != nil {
 		return err
 	}
+	strategy.Version = 1
 	strategy.CreatedAt = unixTime(now)
 	strategy.UpdatedAt = unixTime(now)
 	return nil
 }
 
+// UpdateStrategy follows the same version check as UpdateAccount.
 func (s *Store) UpdateStrategy(ctx context.Context, strategy *domain.Strategy) error {
 	now := time.Now().UTC().Unix()
 	result, err := s.q.ExecContext(ctx, `
 		UPDATE strategies SET
 			name = ?, interval_min_days = ?, interval_max_days = ?,
 			time_start_minutes = ?, time_end_minutes = ?, skip_weekends = ?,
-			amount_min_cents = ?, amount_max_cents = ?, daily_limit = ?, updated_at = ?
-		WHERE id = ?
+			amount_min_cents = ?, amount_max_cents = ?, daily_limit = ?,
+			version = version + 1, updated_at = ?
+		WHERE id = ? AND version = ?
 	`,
 		strategy.Name,
 		strategy.IntervalMinDays,
@@ -134,6 +138,7 @@ func (s *Store) UpdateStrategy(ctx context.Context, strategy *domain.Strategy) e
 		strategy.DailyLimit,
 		now,
 		strategy.ID,
+		strategy.Version,
 	)
 	if isConstraintError(err) {
 		return ErrConflict
@@ -146,14 +151,15 @@ func (s *Store) UpdateStrategy(ctx context.Context, strategy *domain.Strategy) e
 		return err
 	}
 	if count == 0 {
-		return ErrNotFound
+		return s.missingOrStale(ctx, "strategies", strategy.ID)
 	}
+	strategy.Version++
 	strategy.UpdatedAt = unixTime(now)
 	return nil
 }
 
-func (s *Store) DeleteStrategy(ctx context.Context, id int64) error {
-	result, err := s.q.ExecContext(ctx, "DELETE FROM strategies WHERE id = ?", id)
+func (s *Store) DeleteStrategy(ctx context.Context, id, version int64) error {
+	result, err := s.q.ExecContext(ctx, "DELETE FROM strategies WHERE id = ? AND (? = 0 OR version = ?)", id, version, version)
 	if err != nil {
 		return err
 	}
@@ -162,7 +168,7 @@ func (s *Store) DeleteStrategy(ctx context.Context, id int64) error {
 		return err
 	}
 	if count == 0 {
-		return ErrNotFound
+		return s.missingOrStale(ctx, "strategies", id)
 	}
 	return nil
 }
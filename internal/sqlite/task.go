@@ -11,11 +11,48 @@ import (
 	"github.com/CoxxA/nomadbank/v2/internal/domain"
 )
 
+type TaskSort string
+
+const (
+	TaskSortScheduledAsc  TaskSort = "scheduled_at"
+	TaskSortScheduledDesc TaskSort = "-scheduled_at"
+	TaskSortAmountAsc     TaskSort = "amount_cents"
+	TaskSortAmountDesc    TaskSort = "-amount_cents"
+	TaskSortCompletedAsc  TaskSort = "completed_at"
+	TaskSortCompletedDesc TaskSort = "-completed_at"
+)
+
+var taskOrders = map[TaskSort]string{
+	"":                    "t.scheduled_at ASC, t.id ASC",
+	TaskSortScheduledAsc:  "t.scheduled_at ASC, t.id ASC",
+	TaskSortScheduledDesc: "t.scheduled_at DESC, t.id DESC",
+	TaskSortAmountAsc:     "t.amount_cents ASC, t.scheduled_at ASC, t.id ASC",
+	TaskSortAmountDesc:    "t.amount_cents DESC, t.scheduled_at ASC, t.id ASC",
+	TaskSortCompletedAsc:  "t.completed_at ASC NULLS LAST, t.id ASC",
+	TaskSortCompletedDesc: "t.completed_at DESC NULLS LAST, t.id DESC",
+}
+
+func (s TaskSort) Valid() bool {
+	_, ok := taskOrders[s]
+	return ok
+}
+
+// TaskFilter narrows ListTasks. Zero values disable a condition; GroupName is
+// a pointer because the empty string is the default group.
 type TaskFilter struct {
-	Status   domain.TaskStatus
-	BatchID  int64
-	Page     int
-	PageSize int
+	Status          domain.TaskStatus
+	BatchID         int64
+	AccountID       int64
+	GroupName       *string
+	ScheduledFrom   time.Time
+	ScheduledBefore time.Time
+	AmountMinCents  int64
+	AmountMaxCents  int64
+	// OverdueBefore limits results to pending tasks scheduled before it.
+	OverdueBefore time.Time
+	Sort          TaskSort
+	Page          int
+	PageSize      int
 }
 
 func (s *Store) LastScheduledAt(ctx context.Context, groupName string) (*time.Time, error) {
@@ -84,12 +121,15 @@ func (s *Store) CreateTaskBatch(
 	}, nil
 }
 
+const taskBatchColumns = `
+	SELECT b.id, b.strategy_id, b.strategy_name, b.group_name, b.cycle_count,
+	       COUNT(t.id), b.created_at
+	FROM task_batches b
+	LEFT JOIN tasks t ON t.batch_id = b.id
+`
+
 func (s *Store) ListTaskBatches(ctx context.Context) ([]domain.TaskBatch, error) {
-	rows, err := s.q.QueryContext(ctx, `
-		SELECT b.id, b.strategy_id, b.strategy_name, b.group_name, b.cycle_count,
-		       COUNT(t.id), b.created_at
-		FROM task_batches b
-		LEFT JOIN tasks t ON t.batch_id = b.id
+	rows, err := s.q.QueryContext(ctx, taskBatchColumns+`
 		GROUP BY b.id
 		ORDER BY b.created_at DESC, b.id DESC
 	`)
@@ -101,30 +141,47 @@ func (s *Store) ListTaskBatches(ctx context.Context) ([]domain.TaskBatch, error)
 
 	batches := make([]domain.TaskBatch, 0)
 	for rows.Next() {
-		var batch domain.TaskBatch
-		var strategyID sql.NullInt64
-		var createdAt int64
-		if err := rows.Scan(
-			&batch.ID,
-			&strategyID,
-			&batch.StrategyName,
-			&batch.GroupName,
-			&batch.CycleCount,
-			&batch.TaskCount,
-			&createdAt,
-		); err != nil {
+		batch, err := scanTaskBatch(rows)
+		if err != nil {
 			return nil, err
 		}
-		if strategyID.Valid {
-			value := strategyID.Int64
-			batch.StrategyID = &value
-		}
-		batch.CreatedAt = unixTime(createdAt)
 		batches = append(batches, batch)
 	}
 	return batches, rows.Err()
 }
 
+func (s *Store) GetTaskBatch(ctx context.Context, id int64) (domain.TaskBatch, error) {
+	row := s.q.QueryRowContext(ctx, taskBatchColumns+" WHERE b.id = ? GROUP BY b.id", id)
+	batch, err := scanTaskBatch(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.TaskBatch{}, ErrNotFound
+	}
+	return batch, err
+}
+
+func scanTaskBatch(row rowScanner) (domain.TaskBatch, error) {
+	var batch domain.TaskBatch
+	var strategyID sql.NullInt64
+	var createdAt int64
+	if err := row.Scan(
+		&batch.ID,
+		&strategyID,
+		&batch.StrategyName,
+		&batch.GroupName,
+		&batch.CycleCount,
+		&batch.TaskCount,
+		&createdAt,
+	); err != nil {
+		return domain.TaskBatch{}, err
+	}
+	if strategyID.Valid {
+		value := strategyID.Int64
+		batch.StrategyID = &value
+	}
+	batch.CreatedAt = unixTime(createdAt)
+	return batch, nil
+}
+
 func (s *Store) DeleteTaskBatch(ctx context.Context, id int64) error {
 	result, err := s.q.ExecContext(ctx, "DELETE FROM task_batches WHERE id = ?", id)
 	if err != nil {
@@ -141,9 +198,17 @@ func (s *Store) DeleteTaskBatch(ctx context.Context, id int64) error {
 }
 
 func (s *Store) ListTasks(ctx context.Context, filter TaskFilter) (domain.TaskPage, error) {
+	order, ok := taskOrders[filter.Sort]
+	if !ok {
+		return domain.TaskPage{}, fmt.Errorf("未知的任务排序: %q", filter.Sort)
+	}
 	where, args := taskWhere(filter)
 	var total int64
-	if err := s.q.QueryRowContext(ctx, "SELECT COUNT(*) FROM tasks t"+where, args...).Scan(&total); err != nil {
+	if err := s.q.QueryRowContext(
+		ctx,
+		"SELECT COUNT(*) FROM tasks t JOIN task_batches b ON b.id = t.batch_id"+where,
+		args...,
+	).Scan(&total); err != nil {
 		return domain.TaskPage{}, err
 	}
 
@@ -153,9 +218,10 @@ func (s *Store) ListTasks(ctx context.Context, filter TaskFilter) (domain.TaskPa
 		       t.from_account_id, source.name, t.to_account_id, target.name,
 		       t.amount_cents, t.status, t.completed_at, t.created_at
 		FROM tasks t
+		JOIN task_batches b ON b.id = t.batch_id
 		JOIN accounts source ON source.id = t.from_account_id
 		JOIN accounts target ON target.id = t.to_account_id
-	` + where + " ORDER BY t.scheduled_at ASC, t.id ASC LIMIT ? OFFSET ?"
+	` + where + " ORDER BY " + order + " LIMIT ? OFFSET ?"
 	args = append(args, filter.PageSize, offset)
 	rows, err := s.q.QueryContext(ctx, query, args...)
 	if err != nil {
@@ -179,8 +245,8 @@ func (s *Store) ListTasks(ctx context.Context, filter TaskFilter) (domain.TaskPa
 }
 
 func taskWhere(filter TaskFilter) (string, []any) {
-	conditions := make([]string, 0, 2)
-	args := make([]any, 0, 2)
+	conditions := make([]string, 0, 8)
+	args := make([]any, 0, 8)
 	if filter.Status != "" {
 		conditions = append(conditions, "t.status = ?")
 		args = append(args, filter.Status)
@@ -189,6 +255,34 @@ func taskWhere(filter TaskFilter) (string, []any) {
 		conditions = append(conditions, "t.batch_id = ?")
 		args = append(args, filter.BatchID)
 	}
+	if filter.AccountID > 0 {
+		conditions = append(conditions, "(t.from_account_id = ? OR t.to_account_id = ?)")
+		args = append(args, filter.AccountID, filter.AccountID)
+	}
+	if filter.GroupName != nil {
+		conditions = append(conditions, "b.group_name = ?")
+		args = append(args, *filter.GroupName)
+	}
+	if !filter.ScheduledFrom.IsZero() {
+		conditions = append(conditions, "t.scheduled_at >= ?")
+		args = append(args, filter.ScheduledFrom.UTC().Unix())
+	}
+	if !filter.ScheduledBefore.IsZero() {
+		conditions = append(conditions, "t.scheduled_at < ?")
+		args = append(args, filter.ScheduledBefore.UTC().Unix())
+	}
+	if filter.AmountMinCents > 0 {
+		conditions = append(conditions, "t.amount_cents >= ?")
+		args = append(args, filter.AmountMinCents)
+	}
+	if filter.AmountMaxCents > 0 {
+		conditions = append(conditions, "t.amount_cents <= ?")
+		args = append(args, filter.AmountMaxCents)
+	}
+	if !filter.OverdueBefore.IsZero() {
+		conditions = append(conditions, "t.status = 'pending' AND t.scheduled_at < ?")
+		args = append(args, filter.OverdueBefore.UTC().Unix())
+	}
 	if len(conditions) == 0 {
 		return "", args
 	}
@@ -241,16 +335,23 @@ func scanTask(row rowScanner) (domain.Task, error) {
 	return task, nil
 }
 
-func (s *Store) CompleteTask(ctx context.Context, id int64, completedAt time.Time) (domain.Task, error) {
-	_, err := s.q.ExecContext(ctx, `
+// CompleteTask is idempotent. The boolean reports whether this call changed
+// the task, so callers can announce a completion exactly once.
+func (s *Store) CompleteTask(ctx context.Context, id int64, completedAt time.Time) (domain.Task, bool, error) {
+	result, err := s.q.ExecContext(ctx, `
 		UPDATE tasks
 		SET status = 'completed', completed_at = ?
 		WHERE id = ? AND status = 'pending'
 	`, completedAt.UTC().Unix(), id)
 	if err != nil {
-		return domain.Task{}, err
+		return domain.Task{}, false, err
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return domain.Task{}, false, err
 	}
-	return s.GetTask(ctx, id)
+	task, err := s.GetTask(ctx, id)
+	return task, count > 0, err
 }
 
 func (s *Store) CountTasks(ctx context.Context, status domain.TaskStatus) (int64, error) {
@@ -0,0 +1,43 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// TaskLinkSecrets returns what completion links are signed with: the owner's
+// link secret and password hash.
+func (s *Store) TaskLinkSecrets(ctx context.Context) (string, string, error) {
+	var secret, passwordHash string
+	err := s.q.QueryRowContext(ctx, "SELECT link_secret, password_hash FROM owner WHERE id = 1").
+		Scan(&secret, &passwordHash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", "", ErrNotFound
+	}
+	return secret, passwordHash, err
+}
+
+// TaskLinkUsed reports whether the completion link with nonce was used.
+func (s *Store) TaskLinkUsed(ctx context.Context, nonce string) (bool, error) {
+	var count int
+	err := s.q.QueryRowContext(ctx, "SELECT COUNT(*) FROM used_task_links WHERE nonce = ?", nonce).Scan(&count)
+	return count > 0, err
+}
+
+// UseTaskLink records a completion link as used and reports false when it
+// already was. Records of links that have expired since are dropped.
+func (s *Store) UseTaskLink(ctx context.Context, nonce string, taskID int64, expiresAt, now time.Time) (bool, error) {
+	if _, err := s.q.ExecContext(ctx, "DELETE FROM used_task_links WHERE expires_at <= ?", now.UTC().Unix()); err != nil {
+		return false, err
+	}
+	result, err := s.q.ExecContext(ctx, `
+		INSERT OR IGNORE INTO used_task_links(nonce, task_id, used_at, expires_at) VALUES(?, ?, ?, ?)
+	`, nonce, taskID, now.UTC().Unix(), expiresAt.UTC().Unix())
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected == 1, err
+}
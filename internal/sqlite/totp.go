@@ -0,0 +1,119 @@
+package sqlite
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// TOTPState is the owner's two-factor configuration. Secret is empty while
+// two-factor login is off; PendingSecret holds an enrollment that has not
+// been confirmed with a code yet.
+type TOTPState struct {
+	Secret        string
+	PendingSecret string
+	LastStep      int64
+}
+
+func (s *Store) OwnerTOTP(ctx context.Context) (TOTPState, error) {
+	var state TOTPState
+	err := s.q.QueryRowContext(ctx, `
+		SELECT totp_secret, totp_pending_secret, totp_last_step FROM owner WHERE id = 1
+	`).Scan(&state.Secret, &state.PendingSecret, &state.LastStep)
+	if errors.Is(err, sql.ErrNoRows) {
+		return TOTPState{}, ErrNotFound
+	}
+	return state, err
+}
+
+func (s *Store) SetPendingTOTPSecret(ctx context.Context, secret string) error {
+	return s.updateOwnerTOTP(ctx, "UPDATE owner SET totp_pending_secret = ?, updated_at = ? WHERE id = 1", secret)
+}
+
+// EnableTOTP promotes the pending secret after the first code at step was
+// accepted.
+func (s *Store) EnableTOTP(ctx context.Context, step int64) error {
+	return s.updateOwnerTOTP(ctx, `
+		UPDATE owner SET totp_secret = totp_pending_secret, totp_pending_secret = '', totp_last_step = ?,
+		       updated_at = ?
+		WHERE id = 1 AND totp_pending_secret <> ''
+	`, step)
+}
+
+func (s *Store) DisableTOTP(ctx context.Context) error {
+	if _, err := s.q.ExecContext(ctx, "DELETE FROM recovery_codes"); err != nil {
+		return err
+	}
+	_, err := s.q.ExecContext(ctx, `
+		UPDATE owner SET totp_secret = '', totp_pending_secret = '', totp_last_step = 0, updated_at = ?
+		WHERE id = 1
+	`, time.Now().UTC().Unix())
+	return err
+}
+
+// UseTOTPStep records that the code for step was accepted. It reports false
+// when that step or a later one was already used, so a code cannot be
+// replayed within its validity window.
+func (s *Store) UseTOTPStep(ctx context.Context, step int64) (bool, error) {
+	result, err := s.q.ExecContext(ctx, `
+		UPDATE owner SET totp_last_step = ? WHERE id = 1 AND totp_last_step < ?
+	`, step, step)
+	if err != nil {
+		return false, err
+	}
+	count, err := result.RowsAffected()
+	return count > 0, err
+}
+
+// ReplaceRecoveryCodes discards all previous recovery codes.
+func (s *Store) ReplaceRecoveryCodes(ctx context.Context, codes []string) error {
+	if _, err := s.q.ExecContext(ctx, "DELETE FROM recovery_codes"); err != nil {
+		return err
+	}
+	now := time.Now().UTC().Unix()
+	for _, code := range codes {
+		hash := sha256.Sum256([]byte(code))
+		_, err := s.q.ExecContext(ctx, "INSERT INTO recovery_codes(code_hash, created_at) VALUES(?, ?)", hash[:], now)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UseRecoveryCode marks an unused recovery code as used and reports whether
+// it was valid.
+func (s *Store) UseRecoveryCode(ctx context.Context, code string, now time.Time) (bool, error) {
+	hash := sha256.Sum256([]byte(code))
+	result, err := s.q.ExecContext(ctx, `
+		UPDATE recovery_codes SET used_at = ? WHERE code_hash = ? AND used_at IS NULL
+	`, now.UTC().Unix(), hash[:])
+	if err != nil {
+		return false, err
+	}
+	count, err := result.RowsAffected()
+	return count > 0, err
+}
+
+func (s *Store) CountRecoveryCodes(ctx context.Context) (int, error) {
+	var count int
+	err := s.q.QueryRowContext(ctx, "SELECT COUNT(*) FROM recovery_codes WHERE used_at IS NULL").Scan(&count)
+	return count, err
+}
+
+func (s *Store) updateOwnerTOTP(ctx context.Context, query string, value any) error {
+	result, err := s.q.ExecContext(ctx, query, value, time.Now().UTC().Unix())
+	if err != nil {
+		return err
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
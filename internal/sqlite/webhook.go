@@ -0,0 +1,343 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/CoxxA/nomadbank/v2/internal/domain"
+)
+
+func (s *Store) ListWebhooks(ctx context.Context) ([]domain.Webhook, error) {
+	rows, err := s.q.QueryContext(ctx, `
+		SELECT id, name, url, secret, events, active, created_at, updated_at FROM webhooks ORDER BY id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	// Iteration errors are returned by rows.Err; Close is cleanup only.
+	defer func() { _ = rows.Close() }()
+
+	webhooks := make([]domain.Webhook, 0)
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	return webhooks, rows.Err()
+}
+
+func (s *Store) GetWebhook(ctx context.Context, id int64) (domain.Webhook, error) {
+	row := s.q.QueryRowContext(ctx, `
+		SELECT id, name, url, secret, events, active, created_at, updated_at FROM webhooks WHERE id = ?
+	`, id)
+	webhook, err := scanWebhook(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Webhook{}, ErrNotFound
+	}
+	return webhook, err
+}
+
+func scanWebhook(row rowScanner) (domain.Webhook, error) {
+	var webhook domain.Webhook
+	var events string
+	var createdAt, updatedAt int64
+	if err := row.Scan(
+		&webhook.ID,
+		&webhook.Name,
+		&webhook.URL,
+		&webhook.Secret,
+		&events,
+		&webhook.Active,
+		&createdAt,
+		&updatedAt,
+	); err != nil {
+		return domain.Webhook{}, err
+	}
+	webhook.Events = make([]domain.WebhookEvent, 0)
+	for _, event := range strings.Split(events, ",") {
+		if event != "" {
+			webhook.Events = append(webhook.Events, domain.WebhookEvent(event))
+		}
+	}
+	webhook.CreatedAt = unixTime(createdAt)
+	webhook.UpdatedAt = unixTime(updatedAt)
+	return webhook, nil
+}
+
+func joinEvents(events []domain.WebhookEvent) string {
+	names := make([]string, 0, len(events))
+	for _, event := range events {
+		names = append(names, string(event))
+	}
+	return strings.Join(names, ",")
+}
+
+func (s *Store) CreateWebhook(ctx context.Context, webhook *domain.Webhook) error {
+	now := time.Now().UTC().Unix()
+	result, err := s.q.ExecContext(ctx, `
+		INSERT INTO webhooks(name, url, secret, events, active, created_at, updated_at)
+		VALUES(?, ?, ?, ?, ?, ?, ?)
+	`, webhook.Name, webhook.URL, webhook.Secret, joinEvents(webhook.Events), webhook.Active, now, now)
+	if err != nil {
+		return err
+	}
+	webhook.ID, err = result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	webhook.CreatedAt = unixTime(now)
+	webhook.UpdatedAt = unixTime(now)
+	return nil
+}
+
+func (s *Store) UpdateWebhook(ctx context.Context, webhook *domain.Webhook) error {
+	now := time.Now().UTC().Unix()
+	result, err := s.q.ExecContext(ctx, `
+		UPDATE webhooks SET name = ?, url = ?, events = ?, active = ?, updated_at = ? WHERE id = ?
+	`, webhook.Name, webhook.URL, joinEvents(webhook.Events), webhook.Active, now, webhook.ID)
+	if err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err != nil {
+		return err
+	} else if affected == 0 {
+		return ErrNotFound
+	}
+	webhook.UpdatedAt = unixTime(now)
+	return nil
+}
+
+// DeleteWebhook also drops its deliveries and their retry log.
+func (s *Store) DeleteWebhook(ctx context.Context, id int64) error {
+	result, err := s.q.ExecContext(ctx, "DELETE FROM webhooks WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err != nil {
+		return err
+	} else if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// EnqueueWebhookDelivery queues an event for a webhook. It reports false
+// when the event was already queued, which only happens for task.overdue.
+func (s *Store) EnqueueWebhookDelivery(ctx context.Context, delivery *domain.WebhookDelivery) (bool, error) {
+	result, err := s.q.ExecContext(ctx, `
+		INSERT OR IGNORE INTO webhook_deliveries(
+			webhook_id, delivery_id, event_type, resource_id, payload, next_attempt_at, created_at
+		) VALUES(?, ?, ?, ?, ?, ?, ?)
+	`,
+		delivery.WebhookID,
+		delivery.DeliveryID,
+		delivery.Event,
+		delivery.ResourceID,
+		string(delivery.Payload),
+		delivery.NextAttemptAt.UTC().Unix(),
+		delivery.CreatedAt.UTC().Unix(),
+	)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil || affected == 0 {
+		return false, err
+	}
+	delivery.ID, err = result.LastInsertId()
+	delivery.Status = domain.DeliveryPending
+	delivery.Log = make([]domain.WebhookAttempt, 0)
+	return true, err
+}
+
+// TasksAwaitingOverdueEvent returns pending tasks scheduled in [from, before)
+// that the webhook has not yet been told are overdue.
+func (s *Store) TasksAwaitingOverdueEvent(ctx context.Context, webhookID int64, from, before time.Time) ([]int64, error) {
+	rows, err := s.q.QueryContext(ctx, `
+		SELECT t.id FROM tasks t
+		WHERE t.status = 'pending' AND t.scheduled_at >= ? AND t.scheduled_at < ?
+		  AND NOT EXISTS (
+			SELECT 1 FROM webhook_deliveries d
+			WHERE d.webhook_id = ? AND d.event_type = 'task.overdue' AND d.resource_id = t.id
+		  )
+		ORDER BY t.scheduled_at, t.id
+	`, from.UTC().Unix(), before.UTC().Unix(), webhookID)
+	if err != nil {
+		return nil, err
+	}
+	// Iteration errors are returned by rows.Err; Close is cleanup only.
+	defer func() { _ = rows.Close() }()
+
+	ids := make([]int64, 0)
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// DueWebhookDeliveries returns pending deliveries of active webhooks whose
+// attempt is due, oldest first, without their log. Deliveries of a paused
+// webhook wait until it is active again.
+func (s *Store) DueWebhookDeliveries(ctx context.Context, now time.Time, limit int) ([]domain.WebhookDelivery, error) {
+	return s.listWebhookDeliveries(ctx, `
+		WHERE status = 'pending' AND next_attempt_at <= ?
+		  AND webhook_id IN (SELECT id FROM webhooks WHERE active = 1)
+		ORDER BY next_attempt_at, id LIMIT ?
+	`, now.UTC().Unix(), limit)
+}
+
+// ListWebhookDeliveries returns a webhook's most recent deliveries with
+// their retry log.
+func (s *Store) ListWebhookDeliveries(ctx context.Context, webhookID int64, limit int) ([]domain.WebhookDelivery, error) {
+	deliveries, err := s.listWebhookDeliveries(ctx, "WHERE webhook_id = ? ORDER BY id DESC LIMIT ?", webhookID, limit)
+	if err != nil || len(deliveries) == 0 {
+		return deliveries, err
+	}
+	byID := make(map[int64]*domain.WebhookDelivery, len(deliveries))
+	for index := range deliveries {
+		byID[deliveries[index].ID] = &deliveries[index]
+	}
+	rows, err := s.q.QueryContext(ctx, `
+		SELECT a.delivery_id, a.attempted_at, a.response_status, a.error, a.duration_ms
+		FROM webhook_attempts a
+		JOIN webhook_deliveries d ON d.id = a.delivery_id
+		WHERE d.webhook_id = ? AND d.id >= ?
+		ORDER BY a.id
+	`, webhookID, deliveries[len(deliveries)-1].ID)
+	if err != nil {
+		return nil, err
+	}
+	// Iteration errors are returned by rows.Err; Close is cleanup only.
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var deliveryID, attemptedAt int64
+		var status sql.NullInt64
+		var attempt domain.WebhookAttempt
+		if err := rows.Scan(&deliveryID, &attemptedAt, &status, &attempt.Error, &attempt.DurationMS); err != nil {
+			return nil, err
+		}
+		attempt.AttemptedAt = unixTime(attemptedAt)
+		if status.Valid {
+			code := int(status.Int64)
+			attempt.ResponseStatus = &code
+		}
+		if delivery, ok := byID[deliveryID]; ok {
+			delivery.Log = append(delivery.Log, attempt)
+		}
+	}
+	return deliveries, rows.Err()
+}
+
+func (s *Store) listWebhookDeliveries(ctx context.Context, clause string, args ...any) ([]domain.WebhookDelivery, error) {
+	rows, err := s.q.QueryContext(ctx, `
+		SELECT id, webhook_id, delivery_id, event_type, resource_id, payload, status, attempts,
+		       next_attempt_at, created_at
+		FROM webhook_deliveries `+clause, args...)
+	if err != nil {
+		return nil, err
+	}
+	// Iteration errors are returned by rows.Err; Close is cleanup only.
+	defer func() { _ = rows.Close() }()
+
+	deliveries := make([]domain.WebhookDelivery, 0)
+	for rows.Next() {
+		var delivery domain.WebhookDelivery
+		var resourceID sql.NullInt64
+		var payload string
+		var nextAttemptAt, createdAt int64
+		if err := rows.Scan(
+			&delivery.ID,
+			&delivery.WebhookID,
+			&delivery.DeliveryID,
+			&delivery.Event,
+			&resourceID,
+			&payload,
+			&delivery.Status,
+			&delivery.Attempts,
+			&nextAttemptAt,
+			&createdAt,
+		); err != nil {
+			return nil, err
+		}
+		if resourceID.Valid {
+			delivery.ResourceID = &resourceID.Int64
+		}
+		delivery.Payload = []byte(payload)
+		delivery.NextAttemptAt = unixTime(nextAttemptAt)
+		delivery.CreatedAt = unixTime(createdAt)
+		delivery.Log = make([]domain.WebhookAttempt, 0)
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, rows.Err()
+}
+
+// ClaimWebhookDelivery marks a pending delivery as being sent and counts the
+// attempt. It reports false when another run claimed it first.
+func (s *Store) ClaimWebhookDelivery(ctx context.Context, id int64) (bool, error) {
+	result, err := s.q.ExecContext(ctx, `
+		UPDATE webhook_deliveries SET status = 'sending', attempts = attempts + 1
+		WHERE id = ? AND status = 'pending'
+	`, id)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected == 1, err
+}
+
+// RecordWebhookAttempt appends an attempt to the retry log and settles the
+// delivery: sent, failed, or back in the queue at retryAt when it is
+// non-nil.
+func (s *Store) RecordWebhookAttempt(ctx context.Context, id int64, attempt domain.WebhookAttempt, status domain.DeliveryStatus, retryAt *time.Time) error {
+	return s.WithTx(ctx, func(tx *Store) error {
+		if _, err := tx.q.ExecContext(ctx, `
+			INSERT INTO webhook_attempts(delivery_id, attempted_at, response_status, error, duration_ms)
+			VALUES(?, ?, ?, ?, ?)
+		`, id, attempt.AttemptedAt.UTC().Unix(), attempt.ResponseStatus, attempt.Error, attempt.DurationMS); err != nil {
+			return err
+		}
+		if retryAt != nil {
+			_, err := tx.q.ExecContext(ctx, `
+				UPDATE webhook_deliveries SET status = 'pending', next_attempt_at = ? WHERE id = ?
+			`, retryAt.UTC().Unix(), id)
+			return err
+		}
+		_, err := tx.q.ExecContext(ctx, "UPDATE webhook_deliveries SET status = ? WHERE id = ?", status, id)
+		return err
+	})
+}
+
+// RequeueInterruptedWebhookDeliveries puts deliveries left in sending by a
+// stopped process back in the queue. Receivers drop the duplicate, if any,
+// by its delivery ID.
+func (s *Store) RequeueInterruptedWebhookDeliveries(ctx context.Context) (int64, error) {
+	result, err := s.q.ExecContext(ctx, `
+		UPDATE webhook_deliveries SET status = 'pending' WHERE status = 'sending'
+	`)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// PruneWebhookDeliveries deletes settled deliveries queued before cutoff,
+// with their retry log.
+func (s *Store) PruneWebhookDeliveries(ctx context.Context, cutoff time.Time) (int64, error) {
+	result, err := s.q.ExecContext(ctx, `
+		DELETE FROM webhook_deliveries WHERE status IN ('sent', 'failed') AND created_at < ?
+	`, cutoff.UTC().Unix())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -3,6 +3,7 @@ package task
 import (
 	"context"
 	"errors"
+	"log/slog"
 	"strings"
 	"time"
 
@@ -39,6 +40,15 @@ func NewService(store *sqlite.Store, planner *Planner) *Service {
 	return &Service{store: store, planner: planner, now: time.Now}
 }
 
+// InTx returns a copy of the service that works in tx, so a caller can
+// record further changes, such as an audit event, that commit or roll back
+// together with the generated batch.
+func (s *Service) InTx(tx *sqlite.Store) *Service {
+	service := *s
+	service.store = tx
+	return &service
+}
+
 func (s *Service) Generate(ctx context.Context, input GenerateInput) (GenerateResult, error) {
 	if input.Cycles == 0 {
 		input.Cycles = 4
@@ -94,5 +104,14 @@ func (s *Service) Generate(ctx context.Context, input GenerateInput) (GenerateRe
 		result = GenerateResult{Batch: batch, Tasks: len(drafts)}
 		return nil
 	})
-	return result, err
+	if err != nil {
+		return GenerateResult{}, err
+	}
+	slog.InfoContext(ctx, "生成任务批次",
+		"batch_id", result.Batch.ID,
+		"strategy_id", input.StrategyID,
+		"cycles", input.Cycles,
+		"tasks", result.Tasks,
+	)
+	return result, nil
 }
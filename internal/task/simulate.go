@@ -0,0 +1,214 @@
+package task
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/CoxxA/nomadbank/v2/internal/domain"
+)
+
+// Report summarizes a planned schedule so strategies can be tuned offline.
+type Report struct {
+	Tasks           int
+	TasksPerDay     []DayCount
+	AccountGaps     []AccountGap
+	AmountHistogram []AmountBucket
+	Violations      []Violation
+}
+
+type DayCount struct {
+	Date  string
+	Count int
+}
+
+// AccountGap describes the number of days between consecutive tasks that
+// touch an account, whichever side of the transfer it is on.
+type AccountGap struct {
+	AccountID   int64
+	Tasks       int
+	MinDays     int
+	MaxDays     int
+	AverageDays float64
+}
+
+type AmountBucket struct {
+	MinCents int64
+	MaxCents int64
+	Count    int
+}
+
+type Violation struct {
+	Rule   string
+	Date   string
+	Detail string
+}
+
+// Analyze checks drafts against the strategy and the planner invariants. It
+// never mutates drafts and does not depend on the random source that made them.
+func Analyze(strategy domain.Strategy, drafts []domain.TaskDraft, buckets int) Report {
+	report := Report{Tasks: len(drafts)}
+	if len(drafts) == 0 {
+		return report
+	}
+
+	dailyCounts := make(map[string]int)
+	touches := make(map[int64][]time.Time)
+	directions := make(map[string]string)
+	flows := make(map[string]time.Time)
+	cycleBalance := make(map[int]map[int64]int)
+
+	ordered := append([]domain.TaskDraft(nil), drafts...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].ScheduledAt.Before(ordered[j].ScheduledAt)
+	})
+
+	for _, draft := range ordered {
+		date := dayStart(draft.ScheduledAt)
+		dateKey := date.Format("2006-01-02")
+		dailyCounts[dateKey]++
+		touches[draft.FromAccountID] = append(touches[draft.FromAccountID], date)
+		touches[draft.ToAccountID] = append(touches[draft.ToAccountID], date)
+
+		violate := func(rule, format string, args ...any) {
+			report.Violations = append(report.Violations, Violation{
+				Rule:   rule,
+				Date:   dateKey,
+				Detail: fmt.Sprintf(format, args...),
+			})
+		}
+		if draft.FromAccountID == draft.ToAccountID {
+			violate("self_transfer", "账户 %d 转给自己", draft.FromAccountID)
+		}
+		if strategy.SkipWeekends &&
+			(date.Weekday() == time.Saturday || date.Weekday() == time.Sunday) {
+			violate("weekend", "账户 %d → %d 安排在周末", draft.FromAccountID, draft.ToAccountID)
+		}
+		minute := draft.ScheduledAt.Hour()*60 + draft.ScheduledAt.Minute()
+		if minute < strategy.TimeStartMinutes || minute >= strategy.TimeEndMinutes {
+			violate("time_window", "执行时间 %s 不在策略时段内", draft.ScheduledAt.Format("15:04"))
+		}
+		if draft.AmountCents < strategy.AmountMinCents || draft.AmountCents > strategy.AmountMaxCents {
+			violate("amount_range", "金额 %d 分超出策略范围", draft.AmountCents)
+		}
+		if directions[directionKey(draft.FromAccountID, dateKey)] == "in" ||
+			directions[directionKey(draft.ToAccountID, dateKey)] == "out" {
+			violate("direction", "账户 %d → %d 与当日其他任务方向冲突", draft.FromAccountID, draft.ToAccountID)
+		}
+		if reverseDate, ok := flows[flowKey(draft.ToAccountID, draft.FromAccountID)]; ok &&
+			date.Before(dayStart(reverseDate).AddDate(0, 0, 3)) {
+			violate("reverse_gap", "账户 %d → %d 距反向转账不足三天", draft.FromAccountID, draft.ToAccountID)
+		}
+		directions[directionKey(draft.FromAccountID, dateKey)] = "out"
+		directions[directionKey(draft.ToAccountID, dateKey)] = "in"
+		flows[flowKey(draft.FromAccountID, draft.ToAccountID)] = date
+
+		if cycleBalance[draft.CycleNo] == nil {
+			cycleBalance[draft.CycleNo] = make(map[int64]int)
+		}
+		cycleBalance[draft.CycleNo][draft.FromAccountID]--
+		cycleBalance[draft.CycleNo][draft.ToAccountID]++
+	}
+
+	days := make([]string, 0, len(dailyCounts))
+	for day := range dailyCounts {
+		days = append(days, day)
+	}
+	sort.Strings(days)
+	for _, day := range days {
+		report.TasksPerDay = append(report.TasksPerDay, DayCount{Date: day, Count: dailyCounts[day]})
+		if dailyCounts[day] > strategy.DailyLimit {
+			report.Violations = append(report.Violations, Violation{
+				Rule:   "daily_limit",
+				Date:   day,
+				Detail: fmt.Sprintf("当日 %d 个任务，超过上限 %d", dailyCounts[day], strategy.DailyLimit),
+			})
+		}
+	}
+
+	cycles := make([]int, 0, len(cycleBalance))
+	for cycle := range cycleBalance {
+		cycles = append(cycles, cycle)
+	}
+	sort.Ints(cycles)
+	for _, cycle := range cycles {
+		accountIDs := sortedAccountIDs(cycleBalance[cycle])
+		for _, accountID := range accountIDs {
+			if balance := cycleBalance[cycle][accountID]; balance != 0 {
+				report.Violations = append(report.Violations, Violation{
+					Rule:   "unbalanced_cycle",
+					Detail: fmt.Sprintf("周期 %d 中账户 %d 转入转出相差 %d 次", cycle, accountID, balance),
+				})
+			}
+		}
+	}
+
+	for _, accountID := range sortedAccountIDs(touches) {
+		report.AccountGaps = append(report.AccountGaps, accountGap(accountID, touches[accountID]))
+	}
+	report.AmountHistogram = amountHistogram(strategy, ordered, buckets)
+	return report
+}
+
+func accountGap(accountID int64, dates []time.Time) AccountGap {
+	gap := AccountGap{AccountID: accountID, Tasks: len(dates)}
+	if len(dates) < 2 {
+		return gap
+	}
+	total := 0
+	for index := 1; index < len(dates); index++ {
+		days := calendarDays(dates[index-1], dates[index])
+		if index == 1 || days < gap.MinDays {
+			gap.MinDays = days
+		}
+		if days > gap.MaxDays {
+			gap.MaxDays = days
+		}
+		total += days
+	}
+	gap.AverageDays = float64(total) / float64(len(dates)-1)
+	return gap
+}
+
+func amountHistogram(strategy domain.Strategy, drafts []domain.TaskDraft, buckets int) []AmountBucket {
+	minimum, maximum := strategy.AmountMinCents, strategy.AmountMaxCents
+	for _, draft := range drafts {
+		minimum = min(minimum, draft.AmountCents)
+		maximum = max(maximum, draft.AmountCents)
+	}
+	span := maximum - minimum + 1
+	if buckets < 1 {
+		buckets = 1
+	}
+	if int64(buckets) > span {
+		buckets = int(span)
+	}
+	width := (span + int64(buckets) - 1) / int64(buckets)
+
+	histogram := make([]AmountBucket, buckets)
+	for index := range histogram {
+		histogram[index].MinCents = minimum + int64(index)*width
+		histogram[index].MaxCents = min(maximum, histogram[index].MinCents+width-1)
+	}
+	for _, draft := range drafts {
+		histogram[(draft.AmountCents-minimum)/width].Count++
+	}
+	return histogram
+}
+
+// calendarDays counts date boundaries rather than elapsed hours so daylight
+// saving transitions do not shorten a gap.
+func calendarDays(from, to time.Time) int {
+	fromDate := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	toDate := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	return int(toDate.Sub(fromDate).Hours() / 24)
+}
+
+func sortedAccountIDs[V any](values map[int64]V) []int64 {
+	ids := make([]int64, 0, len(values))
+	for id := range values {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
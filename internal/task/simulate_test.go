@@ -0,0 +1,75 @@
+package task
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/CoxxA/nomadbank/v2/internal/domain"
+)
+
+func TestAnalyzeAcceptsPlannerOutput(t *testing.T) {
+	strategy := testStrategy()
+	strategy.IntervalMinDays = 3
+	strategy.IntervalMaxDays = 9
+	strategy.SkipWeekends = true
+	strategy.AmountMaxCents = 5000
+	strategy.DailyLimit = 2
+	accounts := []domain.Account{{ID: 1}, {ID: 2}, {ID: 3}, {ID: 4}, {ID: 5}}
+	location, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for seed := int64(1); seed <= 20; seed++ {
+		planner := NewPlanner(rand.New(rand.NewSource(seed)))
+		drafts := planner.Plan(PlanInput{
+			Accounts: accounts,
+			Strategy: strategy,
+			Cycles:   6,
+			Now:      time.Date(2026, time.March, 1, 8, 0, 0, 0, location),
+		})
+		report := Analyze(strategy, drafts, 5)
+		if report.Tasks != 30 {
+			t.Fatalf("seed %d: expected 30 tasks, got %d", seed, report.Tasks)
+		}
+		if len(report.Violations) != 0 {
+			t.Fatalf("seed %d: unexpected violations: %+v", seed, report.Violations)
+		}
+		if len(report.AccountGaps) != len(accounts) {
+			t.Fatalf("seed %d: expected gaps for every account, got %d", seed, len(report.AccountGaps))
+		}
+		counted := 0
+		for _, bucket := range report.AmountHistogram {
+			counted += bucket.Count
+		}
+		if counted != report.Tasks {
+			t.Fatalf("seed %d: histogram counted %d of %d tasks", seed, counted, report.Tasks)
+		}
+	}
+}
+
+func TestAnalyzeReportsViolations(t *testing.T) {
+	strategy := testStrategy()
+	strategy.SkipWeekends = true
+	strategy.DailyLimit = 1
+	saturday := time.Date(2026, time.January, 17, 9, 30, 0, 0, time.UTC)
+	drafts := []domain.TaskDraft{
+		{CycleNo: 1, ScheduledAt: saturday, FromAccountID: 1, ToAccountID: 2, AmountCents: 1000},
+		{CycleNo: 1, ScheduledAt: saturday.Add(time.Hour), FromAccountID: 2, ToAccountID: 1, AmountCents: 99},
+	}
+
+	report := Analyze(strategy, drafts, 4)
+	rules := make(map[string]int)
+	for _, violation := range report.Violations {
+		rules[violation.Rule]++
+	}
+	for _, rule := range []string{"weekend", "time_window", "amount_range", "direction", "reverse_gap", "daily_limit"} {
+		if rules[rule] == 0 {
+			t.Errorf("expected %s violation, got %+v", rule, report.Violations)
+		}
+	}
+	if rules["unbalanced_cycle"] != 0 {
+		t.Errorf("balanced cycle reported as unbalanced: %+v", report.Violations)
+	}
+}
@@ -0,0 +1,100 @@
+// Package tlscert serves a certificate key pair from disk and picks up
+// replacements, e.g. renewals written by certbot or acme.sh, without a
+// restart.
+package tlscert
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// checkInterval bounds how often handshakes stat the files.
+const checkInterval = 10 * time.Second
+
+// Reloader implements tls.Config.GetCertificate for one key pair.
+type Reloader struct {
+	certFile string
+	keyFile  string
+	now      func() time.Time
+
+	mu        sync.Mutex
+	cert      *tls.Certificate
+	loaded    [2]fileVersion
+	nextCheck time.Time
+}
+
+type fileVersion struct {
+	modTime time.Time
+	size    int64
+}
+
+// NewReloader loads the key pair and fails if it is unusable.
+func NewReloader(certFile, keyFile string) (*Reloader, error) {
+	r := &Reloader{certFile: certFile, keyFile: keyFile, now: time.Now}
+	versions, err := r.versions()
+	if err != nil {
+		return nil, err
+	}
+	if err := r.load(versions); err != nil {
+		return nil, err
+	}
+	r.nextCheck = r.now().Add(checkInterval)
+	return r, nil
+}
+
+// GetCertificate returns the current certificate, first reloading it if the
+// files changed since the last check. A pair that fails to load, such as a
+// certificate already replaced while its key is not yet, keeps the previous
+// certificate in use and is retried at the next check.
+func (r *Reloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if now := r.now(); !now.Before(r.nextCheck) {
+		r.nextCheck = now.Add(checkInterval)
+		if err := r.reloadIfChanged(); err != nil {
+			slog.Warn("重新加载 TLS 证书失败，继续使用当前证书", "error", err)
+		}
+	}
+	return r.cert, nil
+}
+
+func (r *Reloader) reloadIfChanged() error {
+	versions, err := r.versions()
+	if err != nil {
+		return err
+	}
+	if versions == r.loaded {
+		return nil
+	}
+	if err := r.load(versions); err != nil {
+		return err
+	}
+	slog.Info("已重新加载 TLS 证书", "cert_file", r.certFile, "not_after", r.cert.Leaf.NotAfter)
+	return nil
+}
+
+func (r *Reloader) load(versions [2]fileVersion) error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("加载 TLS 证书: %w", err)
+	}
+	r.cert = &cert
+	r.loaded = versions
+	return nil
+}
+
+func (r *Reloader) versions() ([2]fileVersion, error) {
+	var versions [2]fileVersion
+	for index, name := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(name)
+		if err != nil {
+			return versions, fmt.Errorf("读取 TLS 证书文件: %w", err)
+		}
+		versions[index] = fileVersion{modTime: info.ModTime(), size: info.Size()}
+	}
+	return versions, nil
+}
@@ -0,0 +1,105 @@
+package tlscert
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestReloaderPicksUpReplacedCertificate(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	writeKeyPair(t, certFile, keyFile, "first.example", start)
+
+	reloader, err := NewReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	reloader.now = func() time.Time { return now }
+	commonName := func() string {
+		t.Helper()
+		cert, err := reloader.GetCertificate(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cert.Leaf.Subject.CommonName
+	}
+	if got := commonName(); got != "first.example" {
+		t.Fatalf("unexpected initial certificate %q", got)
+	}
+
+	writeKeyPair(t, certFile, keyFile, "second.example", start.Add(time.Hour))
+	if got := commonName(); got != "first.example" {
+		t.Fatalf("certificate reloaded before the check interval: %q", got)
+	}
+	now = now.Add(checkInterval)
+	if got := commonName(); got != "second.example" {
+		t.Fatalf("expected renewed certificate, got %q", got)
+	}
+
+	if err := os.WriteFile(keyFile, []byte("not a key"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(keyFile, start.Add(2*time.Hour), start.Add(2*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(checkInterval)
+	if got := commonName(); got != "second.example" {
+		t.Fatalf("broken key pair replaced the working certificate: %q", got)
+	}
+}
+
+func TestNewReloaderRejectsMissingFiles(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := NewReloader(filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")); err == nil {
+		t.Fatal("expected missing files to be rejected")
+	}
+}
+
+// writeKeyPair writes a self-signed certificate and sets both files'
+// modification time, since renewals can land within one mtime tick.
+func writeKeyPair(t *testing.T, certFile, keyFile, commonName string, modTime time.Time) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(modTime.Unix()),
+		Subject:      pkix.Name{CommonName: commonName},
+		DNSNames:     []string{commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]*pem.Block{
+		certFile: {Type: "CERTIFICATE", Bytes: der},
+		keyFile:  {Type: "PRIVATE KEY", Bytes: keyDER},
+	}
+	for name, block := range files {
+		if err := os.WriteFile(name, pem.EncodeToMemory(block), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(name, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package web
 
 import (
+	"bytes"
 	"embed"
 	"io/fs"
 	"net/http"
@@ -12,41 +13,50 @@ import (
 //go:embed all:dist/*
 var distFS embed.FS
 
-// RegisterRoutes 注册前端静态文件路由
-func RegisterRoutes(e *echo.Echo) {
+// indexBase 是 index.html 中的 <base>，前端资源、路由和 API 地址都相对它解析。
+const indexBase = `<base href="/" />`
+
+// RegisterRoutes 注册前端静态文件路由。basePath 为 BASE_PATH，例如
+// "/nomadbank"；请求到达这里之前已去掉该前缀。
+func RegisterRoutes(e *echo.Echo, basePath string) {
 	// 尝试获取 dist 子目录
 	distSubFS, err := fs.Sub(distFS, "dist")
 	if err != nil {
 		// dist 目录不存在，跳过（开发模式）
 		return
 	}
+	registerFS(e, distSubFS, basePath)
+}
+
+func registerFS(e *echo.Echo, distSubFS fs.FS, basePath string) {
+	// index.html 中的 <base> 改写为部署路径
+	index, err := fs.ReadFile(distSubFS, "index.html")
+	if err == nil {
+		index = bytes.Replace(index, []byte(indexBase), []byte(`<base href="`+basePath+`/" />`), 1)
+	}
 
 	// 静态文件服务
-	staticHandler := http.FileServer(http.FS(distSubFS))
+	staticHandler := echo.WrapHandler(http.FileServer(http.FS(distSubFS)))
 
 	// 处理所有非 API 请求
-	spaHandler := echo.WrapHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// 尝试提供静态文件
-		path := r.URL.Path
-		if path == "/" {
-			path = "/index.html"
+	e.GET("/*", func(c echo.Context) error {
+		path := c.Request().URL.Path
+		if path == "/api" || strings.HasPrefix(path, "/api/") || path == "/health" || strings.HasPrefix(path, "/health/") {
+			return echo.ErrNotFound
 		}
 
-		// 检查文件是否存在
-		if _, err := fs.Stat(distSubFS, path[1:]); err == nil {
-			staticHandler.ServeHTTP(w, r)
-			return
+		// 尝试提供静态文件
+		if path != "/" && path != "/index.html" {
+			if _, err := fs.Stat(distSubFS, path[1:]); err == nil {
+				return staticHandler(c)
+			}
 		}
 
 		// 文件不存在，返回 index.html（SPA 路由）
-		r.URL.Path = "/"
-		staticHandler.ServeHTTP(w, r)
-	}))
-	e.GET("/*", func(c echo.Context) error {
-		path := c.Request().URL.Path
-		if path == "/api" || strings.HasPrefix(path, "/api/") || path == "/health" || strings.HasPrefix(path, "/health/") {
+		if index == nil {
 			return echo.ErrNotFound
 		}
-		return spaHandler(c)
+		c.Response().Header().Set(echo.HeaderCacheControl, "no-cache")
+		return c.HTMLBlob(http.StatusOK, index)
 	})
 }
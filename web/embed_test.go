@@ -3,14 +3,16 @@ package web
 import (
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"testing/fstest"
 
 	"github.com/labstack/echo/v4"
 )
 
 func TestRegisterRoutesDoesNotHideReservedPaths(t *testing.T) {
 	e := echo.New()
-	RegisterRoutes(e)
+	RegisterRoutes(e, "")
 
 	for _, path := range []string{"/api", "/api/v1/unknown", "/health", "/health/unknown"} {
 		request := httptest.NewRequest(http.MethodGet, path, nil)
@@ -22,3 +24,28 @@ func TestRegisterRoutesDoesNotHideReservedPaths(t *testing.T) {
 		}
 	}
 }
+
+func TestIndexBaseIsRewritten(t *testing.T) {
+	e := echo.New()
+	registerFS(e, fstest.MapFS{
+		"index.html":    {Data: []byte(`<head>` + indexBase + `<script src="./assets/app.js"></script></head>`)},
+		"assets/app.js": {Data: []byte(`console.log(1)`)},
+	}, "/nomadbank")
+
+	for _, path := range []string{"/", "/index.html", "/tasks"} {
+		request := httptest.NewRequest(http.MethodGet, path, nil)
+		response := httptest.NewRecorder()
+		e.ServeHTTP(response, request)
+
+		if response.Code != http.StatusOK || !strings.Contains(response.Body.String(), `<base href="/nomadbank/" />`) {
+			t.Fatalf("%s: expected rewritten index, got %d %s", path, response.Code, response.Body.String())
+		}
+	}
+
+	request := httptest.NewRequest(http.MethodGet, "/assets/app.js", nil)
+	response := httptest.NewRecorder()
+	e.ServeHTTP(response, request)
+	if response.Code != http.StatusOK || response.Body.String() != `console.log(1)` {
+		t.Fatalf("expected asset, got %d %s", response.Code, response.Body.String())
+	}
+}